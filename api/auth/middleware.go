@@ -0,0 +1,223 @@
+// Package auth authenticates API requests by bearer access tokens and authorizes them by permissions.
+//
+// Token is parsed once per request, principal it belongs to is put into request
+// context for handlers. Requests are refused with JSON error unless every check
+// passes, next handler is called at most once.
+//
+// Permissions are named "<resource>:<action>", like sessions:read or tickets:refund.
+// Users hold them through roles, superadmin holds all of them.
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	user "github.com/darkjedidj/cinema-service/internal/service/user"
+	tkn "github.com/darkjedidj/cinema-service/package/jwt"
+)
+
+// Error codes of refused requests
+const (
+	MissingToken   = "missing_token"
+	InvalidToken   = "invalid_token"
+	SessionRevoked = "session_revoked"
+	Forbidden      = "forbidden"
+	BadRequest     = "bad_request"
+	InternalError  = "internal_error"
+)
+
+// Users is a user store consulted by middleware
+type Users interface {
+	Active(claims *tkn.Claims, ctx context.Context) (bool, error)
+	RetrievePermissions(id int64, ctx context.Context) ([]string, error)
+	RetrieveTickets(ticket int64, user int64) (bool, error)
+}
+
+// Principal is a struct to store authenticated caller of request
+type Principal struct {
+	User_ID    int64
+	Session_ID int64
+}
+
+// Error is a struct to store reason request was refused
+type Error struct {
+	Error   string `json:"error"`
+	Message string `json:"message"`
+}
+
+// Middleware is a struct to store user store and logger
+type Middleware struct {
+	users Users
+	parse func(token string) (*tkn.Claims, error) // Verifies token, tkn.ParseClaims outside of tests
+	log   *zap.Logger
+}
+
+// principalKey is a context key for authenticated principal
+type principalKey struct{}
+
+// Init returns Middleware object
+func Init(db *sql.DB, l *zap.Logger) *Middleware {
+
+	return &Middleware{
+		users: user.Init(db, l),
+		parse: tkn.ParseClaims,
+		log:   l,
+	}
+}
+
+// NewContext returns context carrying authenticated principal
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns authenticated principal stored in context
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+
+	return p, ok && p != nil
+}
+
+// Authenticate passes request of signed in user to next handler with principal in context
+func (m *Middleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			m.refuse(w, http.StatusUnauthorized, MissingToken, "Missing Authorization header")
+			return
+		}
+
+		if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+			m.refuse(w, http.StatusUnauthorized, InvalidToken, "Authorization header must be a bearer token")
+			return
+		}
+
+		claims, err := m.parse(strings.TrimSpace(header[len("Bearer "):]))
+		if err != nil || claims.ID == 0 || claims.Session == 0 {
+			m.log.Info("Failed to verify token.",
+				zap.Error(err),
+			)
+
+			m.refuse(w, http.StatusUnauthorized, InvalidToken, "Access token is invalid or expired")
+			return
+		}
+
+		active, err := m.users.Active(claims, r.Context())
+		if err != nil {
+			m.refuse(w, http.StatusInternalServerError, InternalError, "Unable to verify session, please try again later")
+			return
+		}
+
+		if !active {
+			m.refuse(w, http.StatusUnauthorized, SessionRevoked, "Session is revoked or expired")
+			return
+		}
+
+		p := &Principal{User_ID: claims.ID, Session_ID: claims.Session}
+
+		next(w, r.WithContext(NewContext(r.Context(), p)))
+	}
+}
+
+// Require passes request of user holding permission to next handler
+func (m *Middleware) Require(permission string, next http.HandlerFunc) http.HandlerFunc {
+	return m.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+
+		permissions, err := m.users.RetrievePermissions(p.User_ID, r.Context())
+		if err != nil {
+			m.log.Info("Failed to get permissions.",
+				zap.Error(err),
+			)
+
+			m.refuse(w, http.StatusInternalServerError, InternalError, "Unable to check permissions, please try again later")
+			return
+		}
+
+		for _, granted := range permissions {
+			if granted == permission {
+				next(w, r)
+				return
+			}
+		}
+
+		m.refuse(w, http.StatusForbidden, Forbidden, "Missing "+permission+" permission")
+	})
+}
+
+// Resource passes request to next handler when user may read or write resource:
+// GET and HEAD need "<resource>:read" permission, other methods "<resource>:write"
+func (m *Middleware) Resource(resource string, next http.HandlerFunc) http.HandlerFunc {
+	read := m.Require(resource+":read", next)
+	write := m.Require(resource+":write", next)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			read(w, r)
+			return
+		}
+
+		write(w, r)
+	}
+}
+
+// OwnTicket passes request for ticket of route id to next handler when user owns the ticket
+func (m *Middleware) OwnTicket(next http.HandlerFunc) http.HandlerFunc {
+	return m.Authenticate(func(w http.ResponseWriter, r *http.Request) {
+		p, _ := FromContext(r.Context())
+
+		ticket, err := strconv.Atoi(mux.Vars(r)["id"])
+		if err != nil {
+			m.log.Info("Failed to parse ticket id.",
+				zap.Error(err),
+			)
+
+			m.refuse(w, http.StatusBadRequest, BadRequest, "Ticket id must be a number")
+			return
+		}
+
+		owns, err := m.users.RetrieveTickets(int64(ticket), p.User_ID)
+		if err != nil {
+			m.log.Info("Failed to get ticket owner.",
+				zap.Error(err),
+			)
+
+			m.refuse(w, http.StatusInternalServerError, InternalError, "Unable to check ticket, please try again later")
+			return
+		}
+
+		if !owns {
+			m.refuse(w, http.StatusForbidden, Forbidden, "Ticket belongs to another user")
+			return
+		}
+
+		next(w, r)
+	})
+}
+
+// refuse writes JSON error, 401 responses ask client to authenticate with bearer token
+func (m *Middleware) refuse(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case code == MissingToken:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	case status == http.StatusUnauthorized:
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(&Error{Error: code, Message: message})
+	if err != nil {
+		m.log.Info("Failed to write auth response.",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,428 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	tkn "github.com/darkjedidj/cinema-service/package/jwt"
+)
+
+// fakeUsers is a user store answering with fixed results
+type fakeUsers struct {
+	active      bool
+	permissions []string
+	owns        bool
+	err         error
+}
+
+func (f *fakeUsers) Active(claims *tkn.Claims, ctx context.Context) (bool, error) {
+	return f.active, f.err
+}
+
+func (f *fakeUsers) RetrievePermissions(id int64, ctx context.Context) ([]string, error) {
+	return f.permissions, f.err
+}
+
+func (f *fakeUsers) RetrieveTickets(ticket int64, user int64) (bool, error) {
+	return f.owns, f.err
+}
+
+// newKeys returns ephemeral keyring for tests
+func newKeys() *tkn.Keyring {
+	keys, err := tkn.Ephemeral()
+	if err != nil {
+		log.Fatalf("can't generate keys: %v", err)
+	}
+
+	return keys
+}
+
+// newLogger returns production logger for tests
+func newLogger() *zap.Logger {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	return logger
+}
+
+// sign issues token of user session with passed keyring and lifetime
+func sign(keys *tkn.Keyring, id int64, session int64, ttl time.Duration) string {
+	claims := &tkn.Claims{ID: id, Session: session}
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	token, err := keys.Sign(claims)
+	if err != nil {
+		log.Fatalf("can't sign token: %v", err)
+	}
+
+	return token
+}
+
+// hmac issues HS256 token naming the key of keyring, verifier must not accept it
+func hmac(id int64, session int64) string {
+	claims := &tkn.Claims{ID: id, Session: session}
+	claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+	claims.Issuer = tkn.DefaultIssuer
+	claims.Audience = tkn.DefaultIssuer
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = "ephemeral"
+
+	signed, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		log.Fatalf("can't sign token: %v", err)
+	}
+
+	return signed
+}
+
+func TestAuthenticate(t *testing.T) {
+	keys := newKeys()
+
+	testAuthenticateCases := []struct {
+		name           string
+		header         string
+		users          *fakeUsers
+		expectedStatus int
+		expectedError  string
+		expectedUser   *Principal
+	}{
+		{
+			name:           "failure: missing header",
+			header:         "",
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  MissingToken,
+		},
+		{
+			name:           "failure: not a bearer token",
+			header:         "Basic dXNlcjpwYXNz",
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: malformed token",
+			header:         "Bearer not-a-token",
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: expired token",
+			header:         "Bearer " + sign(keys, 7, 4, -time.Hour),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: forged token",
+			header:         "Bearer " + sign(newKeys(), 7, 4, time.Hour),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: wrong algorithm",
+			header:         "Bearer " + hmac(7, 4),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: missing user claim",
+			header:         "Bearer " + sign(keys, 0, 4, time.Hour),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: missing session claim",
+			header:         "Bearer " + sign(keys, 7, 0, time.Hour),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  InvalidToken,
+		},
+		{
+			name:           "failure: revoked session",
+			header:         "Bearer " + sign(keys, 7, 4, time.Hour),
+			users:          &fakeUsers{active: false},
+			expectedStatus: http.StatusUnauthorized,
+			expectedError:  SessionRevoked,
+		},
+		{
+			name:           "failure: session store error",
+			header:         "Bearer " + sign(keys, 7, 4, time.Hour),
+			users:          &fakeUsers{err: internal.ErrInternalFailure},
+			expectedStatus: http.StatusInternalServerError,
+			expectedError:  InternalError,
+		},
+		{
+			name:           "success",
+			header:         "Bearer " + sign(keys, 7, 4, time.Hour),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusOK,
+			expectedUser:   &Principal{User_ID: 7, Session_ID: 4},
+		},
+		{
+			name:           "success, lowercase scheme",
+			header:         "bearer " + sign(keys, 7, 4, time.Hour),
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusOK,
+			expectedUser:   &Principal{User_ID: 7, Session_ID: 4},
+		},
+	}
+
+	for _, tc := range testAuthenticateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			var user *Principal
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				user, _ = FromContext(r.Context())
+
+				w.WriteHeader(http.StatusOK)
+			}
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/me/tickets", nil)
+
+			if tc.header != "" {
+				r.Header.Set("Authorization", tc.header)
+			}
+
+			m := &Middleware{users: tc.users, parse: keys.Parse, log: newLogger()}
+			m.Authenticate(next)(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedUser, user)
+
+			if tc.expectedError == "" {
+				assert.Equal(t, 1, calls)
+				return
+			}
+
+			assert.Equal(t, 0, calls)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			var body Error
+			assert.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+			assert.Equal(t, tc.expectedError, body.Error)
+			assert.NotEmpty(t, body.Message)
+
+			if tc.expectedStatus == http.StatusUnauthorized {
+				assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestRequire(t *testing.T) {
+	keys := newKeys()
+	valid := "Bearer " + sign(keys, 7, 4, time.Hour)
+
+	testRequireCases := []struct {
+		name           string
+		header         string
+		users          *fakeUsers
+		expectedStatus int
+		expectedCalls  int
+	}{
+		{
+			name:           "failure: invalid token",
+			header:         "Bearer " + sign(keys, 7, 4, -time.Hour),
+			users:          &fakeUsers{active: true, permissions: []string{"halls:write"}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "failure: missing permission",
+			header:         valid,
+			users:          &fakeUsers{active: true, permissions: []string{"halls:read", "movies:write"}},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "failure: no permissions",
+			header:         valid,
+			users:          &fakeUsers{active: true},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "success, handler runs once",
+			header:         valid,
+			users:          &fakeUsers{active: true, permissions: []string{"halls:write", "halls:write", "movies:read"}},
+			expectedStatus: http.StatusCreated,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tc := range testRequireCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				w.WriteHeader(http.StatusCreated)
+			}
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/halls", nil)
+			r.Header.Set("Authorization", tc.header)
+
+			m := &Middleware{users: tc.users, parse: keys.Parse, log: newLogger()}
+			m.Require("halls:write", next)(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func TestResource(t *testing.T) {
+	keys := newKeys()
+	valid := "Bearer " + sign(keys, 7, 4, time.Hour)
+
+	testResourceCases := []struct {
+		name           string
+		method         string
+		permissions    []string
+		expectedStatus int
+		expectedCalls  int
+	}{
+		{
+			name:           "success, read with read permission",
+			method:         http.MethodGet,
+			permissions:    []string{"sessions:read"},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  1,
+		},
+		{
+			name:           "success, head with read permission",
+			method:         http.MethodHead,
+			permissions:    []string{"sessions:read"},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  1,
+		},
+		{
+			name:           "failure: write with read permission",
+			method:         http.MethodPost,
+			permissions:    []string{"sessions:read"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "failure: read with write permission",
+			method:         http.MethodGet,
+			permissions:    []string{"sessions:write"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "failure: delete with other resource permission",
+			method:         http.MethodDelete,
+			permissions:    []string{"movies:write"},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "success, delete with write permission",
+			method:         http.MethodDelete,
+			permissions:    []string{"sessions:write"},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tc := range testResourceCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				w.WriteHeader(http.StatusOK)
+			}
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/sessions/3", nil)
+			r.Header.Set("Authorization", valid)
+
+			m := &Middleware{users: &fakeUsers{active: true, permissions: tc.permissions}, parse: keys.Parse, log: newLogger()}
+			m.Resource("sessions", next)(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
+
+func TestOwnTicket(t *testing.T) {
+	keys := newKeys()
+	valid := "Bearer " + sign(keys, 7, 4, time.Hour)
+
+	testOwnTicketCases := []struct {
+		name           string
+		id             string
+		users          *fakeUsers
+		expectedStatus int
+		expectedCalls  int
+	}{
+		{
+			name:           "failure: wrong id",
+			id:             "abc",
+			users:          &fakeUsers{active: true, owns: true},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failure: ticket of another user",
+			id:             "3",
+			users:          &fakeUsers{active: true, owns: false},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "success",
+			id:             "3",
+			users:          &fakeUsers{active: true, owns: true},
+			expectedStatus: http.StatusOK,
+			expectedCalls:  1,
+		},
+	}
+
+	for _, tc := range testOwnTicketCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+
+			next := func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				w.WriteHeader(http.StatusOK)
+			}
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/tickets/"+tc.id+"/download", nil)
+			r.Header.Set("Authorization", valid)
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			m := &Middleware{users: tc.users, parse: keys.Parse, log: newLogger()}
+			m.OwnTicket(next)(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedCalls, calls)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -14,10 +15,11 @@ import (
 	"github.com/darkjedidj/cinema-service/internal"
 	repo "github.com/darkjedidj/cinema-service/internal/repository/halls"
 	service "github.com/darkjedidj/cinema-service/internal/service/halls"
+	"github.com/darkjedidj/cinema-service/package/etag"
 )
 
 type Handler struct {
-	s   internal.Service // Allows use service features
+	s   internal.VersionedService // Allows use service features
 	log *zap.Logger
 }
 
@@ -37,6 +39,8 @@ func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request)
 	switch request.Method {
 	case http.MethodGet:
 		h.Get(response, request) // GET BASE_URL/v1/halls/{id}
+	case http.MethodPut, http.MethodPatch:
+		h.Update(response, request) // PUT or PATCH BASE_URL/v1/halls/{id}
 	case http.MethodDelete:
 		h.Delete(response, request) // DELETE BASE_URL/v1/halls/{id}
 	default:
@@ -124,11 +128,13 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 // @Summary      Delete hall
 // @Description  Deletes hall
 // @Param        id  path  integer  true  "Hall ID"
+// @Param        If-Match  header  string  false  "ETag of hall to delete"
 // @Tags         Halls
 // @Accept       json
 // @Produce      json
 // @Success      200
 // @Failure      400
+// @Failure      412
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -149,9 +155,15 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = h.s.Delete(int64(id), ctx)
+	version, ok := h.precondition(response, request, int64(id), ctx)
+	if !ok {
+		return
+	}
+
+	err = h.s.DeleteVersion(int64(id), version, ctx)
 	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
+		h.writeError(response, err)
+		return
 	}
 
 	response.WriteHeader(http.StatusOK)
@@ -163,10 +175,13 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 // @Summary      Get hall
 // @Description  Gets hall
 // @Param        id  path  integer  true  "Hall ID"
+// @Param        If-None-Match  header  string  false  "ETag of cached hall"
 // @Tags         Halls
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of hall"
+// @Success      304
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -201,6 +216,132 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	tag := etag.Of(resource)
+	response.Header().Set("ETag", tag)
+
+	if etag.Fresh(request.Header.Get("If-None-Match"), tag) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall hall structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write hall response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Update replaces hall with PUT or changes only passed fields with PATCH
+// Update godoc
+// @Security     ApiKeyAuth
+// @Summary      Update hall
+// @Description  PUT replaces hall, PATCH changes only fields present in body
+// @Tags         Halls
+// @Param        id  path  integer  true  "Hall ID"
+// @Param        If-Match  header  string  false  "ETag of hall the change is based on"
+// @Param        Body  body  repo.Resource  true  "The body to update a hall"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of updated hall"
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      412
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /halls/{id} [put]
+// @Router       /halls/{id} [patch]
+func (h *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var hall repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse hall id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.precondition(response, request, int64(id), ctx)
+	if !ok {
+		return
+	}
+
+	if request.Method == http.MethodPatch {
+		current, err := h.s.Retrieve(int64(id), ctx)
+		if err != nil {
+			response.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		if current == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		res, ok := current.(*repo.Resource)
+		if !ok {
+			h.log.Info("Failed to assert hall object.",
+				zap.Bool("ok", ok),
+			)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		hall = *res
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&hall)
+	if err != nil {
+		h.log.Info("Failed to decode hall json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	hall.Version = version
+
+	resource, err := h.s.Update(int64(id), &hall, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response.Header().Set("ETag", etag.Of(resource))
+
 	body, err := json.Marshal(resource)
 	if err != nil {
 		h.log.Info("Failed to marshall hall structure.",
@@ -230,7 +371,12 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 // @Tags         Halls
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}  []repo.Resource
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, vip, rows; prefixed by minus for descending order"
+// @Param        vip  query  bool  false  "Only VIP or regular halls"
+// @Param        rows  query  int  false  "Number of rows"
+// @Success      200  {object}  internal.Page
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -242,14 +388,28 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 
 	response.Header().Set("Content-Type", "application/json")
 
-	resource, err := h.s.RetrieveAll(ctx)
-	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
-		return
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
 	}
 
-	if resource == nil {
-		response.WriteHeader(http.StatusNotFound)
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write hall response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
@@ -273,3 +433,61 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 }
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrTicketsSold):
+		status = http.StatusConflict
+	case errors.Is(err, internal.ErrVersionMismatch):
+		status = http.StatusPreconditionFailed
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write hall response.",
+			zap.Error(err),
+		)
+	}
+}
+
+// precondition checks If-Match header against current hall and writes 412 when
+// client changes stale copy. It returns hall version client expects, zero without header.
+func (h *Handler) precondition(response http.ResponseWriter, request *http.Request, id int64, ctx context.Context) (int64, bool) {
+	match := request.Header.Get("If-Match")
+	if match == "" {
+		return 0, true
+	}
+
+	current, err := h.s.Retrieve(id, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !etag.Match(match, etag.Of(current)) {
+		response.WriteHeader(http.StatusPreconditionFailed)
+		return 0, false
+	}
+
+	versioned, ok := current.(etag.Versioned)
+	if !ok {
+		h.log.Info("Failed to assert hall object.",
+			zap.Bool("ok", ok),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return versioned.Revision(), true
+}
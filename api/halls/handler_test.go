@@ -82,7 +82,9 @@ func TestRetrieve(t *testing.T) {
 		name           string
 		mockService    *test.MockService
 		id             int64
+		ifNoneMatch    string
 		expectedStatus int
+		expectedTag    string
 	}{
 		{
 			name: "failure: no rows",
@@ -95,10 +97,31 @@ func TestRetrieve(t *testing.T) {
 		{
 			name: "success",
 			mockService: &test.MockService{
-				ExpectedResult: &hall.Resource{ID: 15, VIP: true, Seats: 15},
+				ExpectedResult: &hall.Resource{ID: 15, VIP: true, Seats: 15, Version: 2},
 			},
 			id:             15,
 			expectedStatus: http.StatusOK,
+			expectedTag:    `"2"`,
+		},
+		{
+			name: "success: changed since cached",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, VIP: true, Seats: 15, Version: 2},
+			},
+			id:             15,
+			ifNoneMatch:    `"1"`,
+			expectedStatus: http.StatusOK,
+			expectedTag:    `"2"`,
+		},
+		{
+			name: "success: not modified",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, VIP: true, Seats: 15, Version: 2},
+			},
+			id:             15,
+			ifNoneMatch:    `"2"`,
+			expectedStatus: http.StatusNotModified,
+			expectedTag:    `"2"`,
 		},
 		{
 			name: "failure: DB error",
@@ -134,11 +157,15 @@ func TestRetrieve(t *testing.T) {
 			r = mux.SetURLVars(r, vars)
 
 			r.Header.Set("Content-Type", "application/json")
+			if tc.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
 
 			(&Handler{s: tc.mockService}).HandleID(w, r)
 
 			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedTag, w.Header().Get("ETag"))
 
 		})
 	}
@@ -148,14 +175,13 @@ func TestRetrieveAll(t *testing.T) {
 	testRetrieveAllCases := []struct {
 		name           string
 		mockService    *test.MockService
+		target         string
 		expectedStatus int
 	}{
 		{
-			name: "failure: no rows",
-			mockService: &test.MockService{
-				ExpectedArray: nil,
-			},
-			expectedStatus: http.StatusNotFound,
+			name:           "success: empty list",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "success",
@@ -171,6 +197,20 @@ func TestRetrieveAll(t *testing.T) {
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name:           "failure: limit too big",
+			mockService:    &test.MockService{},
+			target:         "/?limit=500",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: unknown filter",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: list can't be filtered by \"color\"", internal.ErrValidationFailed),
+			},
+			target:         "/?color=red",
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 	for _, tc := range testRetrieveAllCases {
 
@@ -188,10 +228,151 @@ func TestRetrieveAll(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
 
-			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			target := tc.target
+			if target == "" {
+				target = "/"
+			}
+
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	testUpdateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		ifMatch        string
+		expectedStatus int
+	}{
+		{
+			name: "success: put",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, VIP: true, Seats: 20, Rows: 2},
+			},
+			method:         http.MethodPut,
+			body:           `{"VIP": true, "seats": 20, "rows": 2}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "success: patch",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, Seats: 20, Rows: 2},
+			},
+			method:         http.MethodPatch,
+			body:           `{"VIP": true}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: patch of missing hall",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			method:         http.MethodPatch,
+			body:           `{"VIP": true}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: broken json",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, Seats: 20, Rows: 2},
+			},
+			method:         http.MethodPut,
+			body:           `{"seats": `,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: validation",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: hall must have at least one row", internal.ErrValidationFailed),
+			},
+			method:         http.MethodPut,
+			body:           `{"seats": 20}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: sold seats lost",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrTicketsSold,
+			},
+			method:         http.MethodPut,
+			body:           `{"seats": 5, "rows": 1}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "success: current if-match",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, Seats: 20, Rows: 2, Version: 3},
+			},
+			method:         http.MethodPut,
+			body:           `{"seats": 20, "rows": 2}`,
+			ifMatch:        `"3"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: stale if-match",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, Seats: 20, Rows: 2, Version: 3},
+			},
+			method:         http.MethodPatch,
+			body:           `{"VIP": true}`,
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "failure: changed meanwhile",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			method:         http.MethodPut,
+			body:           `{"seats": 20, "rows": 2}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			method:         http.MethodPut,
+			body:           `{"seats": 20, "rows": 2}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testUpdateCases {
+
+		logger, err := zap.NewProduction()
+		if err != nil {
+			log.Fatalf("can't initialize zap logger: %v", err)
+		}
+
+		defer func() {
+			if err := logger.Sync(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "/15", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, map[string]string{"id": "15"})
+
 			r.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
 
-			(&Handler{s: tc.mockService}).Handle(w, r)
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
 
 			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -205,6 +386,7 @@ func TestDelete(t *testing.T) {
 		name           string
 		mockService    *test.MockService
 		id             int64
+		ifMatch        string
 		expectedStatus int
 		prepare        func() *zap.Logger
 	}{
@@ -216,6 +398,33 @@ func TestDelete(t *testing.T) {
 			id:             15,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "success: any existing hall",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, Version: 4},
+			},
+			id:             15,
+			ifMatch:        "*",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: stale if-match",
+			mockService: &test.MockService{
+				ExpectedResult: &hall.Resource{ID: 15, Version: 4},
+			},
+			id:             15,
+			ifMatch:        `"3"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "failure: if-match of missing hall",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			id:             15,
+			ifMatch:        "*",
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 		{
 			name: "failure: DB error",
 			mockService: &test.MockService{
@@ -224,6 +433,14 @@ func TestDelete(t *testing.T) {
 			id:             15,
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "failure: hall changed before delete",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			id:             15,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 	}
 	for _, tc := range testDeleteCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -250,6 +467,9 @@ func TestDelete(t *testing.T) {
 			r = mux.SetURLVars(r, vars)
 
 			r.Header.Set("Content-Type", "application/json")
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
 
 			(&Handler{s: tc.mockService}).HandleID(w, r)
 
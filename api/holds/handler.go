@@ -0,0 +1,244 @@
+package holds
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/api/auth"
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	service "github.com/darkjedidj/cinema-service/internal/service/holds"
+)
+
+type Handler struct {
+	s   internal.HoldService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// HandleID handles all endpoints on this route
+func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.Get(response, request) // GET BASE_URL/v1/holds/{token}
+	case http.MethodDelete:
+		h.Delete(response, request) // DELETE BASE_URL/v1/holds/{token}
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Create get json and holds seats of session
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Hold seats
+// @Description  Reserves up to 10 seats for checkout of authorized user and returns hold token, user can have 3 active holds
+// @Tags         Holds
+// @Param        id    path  integer        true  "Session ID"
+// @Param        Body  body  repo.Resource  true  "Seats to hold"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /sessions/{id}/holds [post]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse session id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var hold repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&hold)
+	if err != nil {
+		h.log.Info("Failed to decode hold json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	hold.Session_ID = int64(id)
+	hold.User_ID = principal.User_ID
+	resource, err := h.s.Create(&hold, ctx)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+
+		switch {
+		case errors.Is(err, internal.ErrValidationFailed):
+			status = http.StatusBadRequest
+		case errors.Is(err, internal.ErrSeatTaken), errors.Is(err, internal.ErrHoldLimit):
+			status = http.StatusConflict
+		default:
+			response.WriteHeader(status)
+			return
+		}
+
+		response.WriteHeader(status)
+
+		_, err = response.Write([]byte(err.Error()))
+		if err != nil {
+			h.log.Info("Failed to write hold response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall hold structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write hold response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Get token and selects active hold with the same token
+// Get godoc
+// @Security     ApiKeyAuth
+// @Summary      Get hold
+// @Description  Gets active seat hold of authorized user
+// @Param        token  path  string  true  "Hold token"
+// @Tags         Holds
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /holds/{token} [get]
+func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	resource, err := h.s.RetrieveHold(vars["token"], principal.User_ID, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall hold structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write hold response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete token and releases hold with the same token
+// Delete godoc
+// @Security     ApiKeyAuth
+// @Summary      Release hold
+// @Description  Releases seats held by authorized user
+// @Param        token  path  string  true  "Hold token"
+// @Tags         Holds
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /holds/{token} [delete]
+func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	err := h.s.DeleteHold(vars["token"], principal.User_ID, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
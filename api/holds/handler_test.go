@@ -0,0 +1,245 @@
+package holds
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/api/auth"
+	"github.com/darkjedidj/cinema-service/internal"
+	hold "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var seatHold = &hold.Resource{
+	Token:      "f00d",
+	Session_ID: 4,
+	Seats:      []hold.Seat{{Row: 1, Number: 2}},
+	Expires_at: time.Date(2022, time.April, 1, 18, 10, 0, 0, time.UTC),
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		anonymous      bool
+		expectedStatus int
+	}{
+		{
+			name: "failure: unauthenticated",
+			mockService: &test.MockService{
+				ExpectedResult: seatHold,
+			},
+			body:           `{"seats": [{"row": 1, "number": 2}]}`,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: seatHold,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: seatHold,
+			},
+			body:           `{"seats": [{"row": 1, "number": 2}]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{"seats": []}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: seat taken",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrSeatTaken,
+			},
+			body:           `{"seats": [{"row": 1, "number": 2}]}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: too many holds",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrHoldLimit,
+			},
+			body:           `{"seats": [{"row": 1, "number": 2}]}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           `{"seats": [{"row": 1, "number": 2}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "4",
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/sessions/4/holds", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, vars)
+
+			r.Header.Set("Content-Type", "application/json")
+
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).Create(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	testRetrieveCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name: "failure: hold expired",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: seatHold,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"token": "f00d",
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/holds/f00d", nil)
+
+			r = mux.SetURLVars(r, vars)
+			r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testDeleteCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"token": "f00d",
+			}
+
+			r := httptest.NewRequest(http.MethodDelete, "http://localhost:8085/v1/holds/f00d", nil)
+
+			r = mux.SetURLVars(r, vars)
+			r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package layouts
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	service "github.com/darkjedidj/cinema-service/internal/service/layouts"
+)
+
+type Handler struct {
+	s   internal.LayoutService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// Handle handles all endpoints on this route
+func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.Get(response, request) // GET BASE_URL/v1/halls/{id}/layout
+	case http.MethodPost, http.MethodPut:
+		h.Create(response, request) // POST, PUT BASE_URL/v1/halls/{id}/layout
+	case http.MethodDelete:
+		h.Delete(response, request) // DELETE BASE_URL/v1/halls/{id}/layout
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Create get json and replaces layout of hall
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Save hall layout
+// @Description  Replaces hall layout and returns saved object
+// @Tags         Layouts
+// @Param        id    path  integer        true  "Hall ID"
+// @Param        Body  body  repo.Resource  true  "The body to save a layout"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /halls/{id}/layout [put]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse hall id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var layout repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	err = json.NewDecoder(request.Body).Decode(&layout)
+	if err != nil {
+		h.log.Info("Failed to decode layout json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	layout.Hall_ID = int64(id)
+	resource, err := h.s.Create(&layout, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall layout structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write layout response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete get ID and deletes layout of hall with the same ID
+// Delete godoc
+// @Security     ApiKeyAuth
+// @Summary      Delete hall layout
+// @Description  Deletes hall layout
+// @Param        id  path  integer  true  "Hall ID"
+// @Tags         Layouts
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /halls/{id}/layout [delete]
+func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse hall id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.s.Delete(int64(id), ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// Get ID and selects layout of hall with the same ID
+// Get godoc
+// @Security     ApiKeyAuth
+// @Summary      Get hall layout
+// @Description  Gets hall layout
+// @Param        id  path  integer  true  "Hall ID"
+// @Tags         Layouts
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /halls/{id}/layout [get]
+func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse hall id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Retrieve(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall layout structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write layout response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrLayoutInUse):
+		status = http.StatusConflict
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write layout response.",
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,230 @@
+package layouts
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	layout "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var hallLayout = &layout.Resource{
+	Hall_ID: 15,
+	Rows: []layout.Row{
+		{Row: 1, Seats: []layout.Seat{{Number: 1, Category: layout.Standard}}},
+	},
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: hallLayout,
+			},
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: hallLayout,
+			},
+			method:         http.MethodPut,
+			body:           `{"rows": [{"row": 1, "seats": [{"number": 1}]}]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			method:         http.MethodPut,
+			body:           `{"rows": []}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: tickets sold",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrLayoutInUse,
+			},
+			method:         http.MethodPost,
+			body:           `{"rows": [{"row": 1, "seats": [{"number": 1}]}]}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			method:         http.MethodPut,
+			body:           `{"rows": [{"row": 1, "seats": [{"number": 1}]}]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "15",
+			}
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/halls/15/layout", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, vars)
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	testRetrieveCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name: "failure: no layout",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: hallLayout,
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "15",
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/halls/15/layout", nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: tickets sold",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrLayoutInUse,
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testDeleteCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "15",
+			}
+
+			r := httptest.NewRequest(http.MethodDelete, "http://localhost:8085/v1/halls/15/layout", nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -13,11 +13,14 @@ import (
 
 	"github.com/darkjedidj/cinema-service/internal"
 	repo "github.com/darkjedidj/cinema-service/internal/repository/movies"
+	session "github.com/darkjedidj/cinema-service/internal/repository/sessions"
 	service "github.com/darkjedidj/cinema-service/internal/service/movies"
+	"github.com/darkjedidj/cinema-service/internal/service/sessions"
+	"github.com/darkjedidj/cinema-service/package/etag"
 )
 
 type Handler struct {
-	s   internal.Service // Allows use service features
+	s   internal.VersionedService // Allows use service features
 	log *zap.Logger
 }
 
@@ -31,12 +34,20 @@ func Init(db *sql.DB, l *zap.Logger) *Handler {
 	}
 }
 
+// Conflict is a response body listing sessions new movie duration would overlap
+type Conflict struct {
+	Error    string              `json:"error"`
+	Sessions []*session.Resource `json:"sessions"`
+}
+
 // HandleID handles all endpoints on this route
 func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
 
 	switch request.Method {
 	case http.MethodGet:
 		h.Get(response, request) // GET BASE_URL/v1/movies/{id}
+	case http.MethodPut, http.MethodPatch:
+		h.Update(response, request) // PUT or PATCH BASE_URL/v1/movies/{id}
 	case http.MethodDelete:
 		h.Delete(response, request) // DELETE BASE_URL/v1/movies/{id}
 	default:
@@ -140,11 +151,13 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 // @Summary      Delete movie
 // @Description  Deletes movie
 // @Param        id  path  integer  true  "Movie ID"
+// @Param        If-Match  header  string  false  "ETag of movie to delete"
 // @Tags         Movies
 // @Accept       json
 // @Produce      json
 // @Success      200
 // @Failure      400
+// @Failure      412
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -165,9 +178,15 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = h.s.Delete(int64(id), ctx)
+	version, ok := h.precondition(response, request, int64(id), ctx)
+	if !ok {
+		return
+	}
+
+	err = h.s.DeleteVersion(int64(id), version, ctx)
 	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
+		h.writeError(response, err)
+		return
 	}
 
 	response.WriteHeader(http.StatusOK)
@@ -179,10 +198,13 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 // @Summary      Get movie
 // @Description  Gets movie
 // @Param        id  path  integer  true  "Movie ID"
+// @Param        If-None-Match  header  string  false  "ETag of cached movie"
 // @Tags         Movies
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of movie"
+// @Success      304
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -217,6 +239,132 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	tag := etag.Of(resource)
+	response.Header().Set("ETag", tag)
+
+	if etag.Fresh(request.Header.Get("If-None-Match"), tag) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall movie structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write movie response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Update replaces movie with PUT or changes only passed fields with PATCH
+// Update godoc
+// @Security     ApiKeyAuth
+// @Summary      Update movie
+// @Description  PUT replaces movie, PATCH changes only fields present in body
+// @Tags         Movies
+// @Param        id  path  integer  true  "Movie ID"
+// @Param        If-Match  header  string  false  "ETag of movie the change is based on"
+// @Param        Body  body  repo.Resource  true  "The body to update a movie"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of updated movie"
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      412
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /movies/{id} [put]
+// @Router       /movies/{id} [patch]
+func (h *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var movie repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse movie id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.precondition(response, request, int64(id), ctx)
+	if !ok {
+		return
+	}
+
+	if request.Method == http.MethodPatch {
+		current, err := h.s.Retrieve(int64(id), ctx)
+		if err != nil {
+			response.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		if current == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		res, ok := current.(*repo.Resource)
+		if !ok {
+			h.log.Info("Failed to assert movie object.",
+				zap.Bool("ok", ok),
+			)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		movie = *res
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&movie)
+	if err != nil {
+		h.log.Info("Failed to decode movie json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	movie.Version = version
+
+	resource, err := h.s.Update(int64(id), &movie, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response.Header().Set("ETag", etag.Of(resource))
+
 	body, err := json.Marshal(resource)
 	if err != nil {
 		h.log.Info("Failed to marshall movie structure.",
@@ -246,7 +394,14 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 // @Tags         Movies
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}  []repo.Resource
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, name, age_rating, language; prefixed by minus for descending order"
+// @Param        name  query  string  false  "Movie name"
+// @Param        genre  query  string  false  "Genre of movie"
+// @Param        age_rating  query  string  false  "Age rating: 0+, 6+, 12+, 16+ or 18+"
+// @Param        language  query  string  false  "Original language tag"
+// @Success      200  {object}  internal.Page
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -258,14 +413,28 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 
 	response.Header().Set("Content-Type", "application/json")
 
-	resource, err := h.s.RetrieveAll(ctx)
-	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
-		return
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
 	}
 
-	if resource == nil {
-		response.WriteHeader(http.StatusNotFound)
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write movie response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
@@ -289,3 +458,84 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 }
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	var conflict *sessions.Conflict
+	if errors.As(err, &conflict) {
+		body, err := json.Marshal(&Conflict{Error: conflict.Error(), Sessions: conflict.Sessions})
+		if err != nil {
+			h.log.Info("Failed to marshall movie conflict structure.",
+				zap.Error(err),
+			)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response.WriteHeader(http.StatusConflict)
+
+		_, err = response.Write(body)
+		if err != nil {
+			h.log.Info("Failed to write movie response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrTicketsSold):
+		status = http.StatusConflict
+	case errors.Is(err, internal.ErrVersionMismatch):
+		status = http.StatusPreconditionFailed
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write movie response.",
+			zap.Error(err),
+		)
+	}
+}
+
+// precondition checks If-Match header against current movie and writes 412 when
+// client changes stale copy. It returns movie version client expects, zero without header.
+func (h *Handler) precondition(response http.ResponseWriter, request *http.Request, id int64, ctx context.Context) (int64, bool) {
+	match := request.Header.Get("If-Match")
+	if match == "" {
+		return 0, true
+	}
+
+	current, err := h.s.Retrieve(id, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !etag.Match(match, etag.Of(current)) {
+		response.WriteHeader(http.StatusPreconditionFailed)
+		return 0, false
+	}
+
+	versioned, ok := current.(etag.Versioned)
+	if !ok {
+		h.log.Info("Failed to assert movie object.",
+			zap.Bool("ok", ok),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return versioned.Revision(), true
+}
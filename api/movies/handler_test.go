@@ -14,6 +14,8 @@ import (
 
 	"github.com/darkjedidj/cinema-service/internal"
 	movie "github.com/darkjedidj/cinema-service/internal/repository/movies"
+	session "github.com/darkjedidj/cinema-service/internal/repository/sessions"
+	"github.com/darkjedidj/cinema-service/internal/service/sessions"
 	"github.com/darkjedidj/cinema-service/test"
 )
 
@@ -151,11 +153,9 @@ func TestRetrieveAll(t *testing.T) {
 		expectedStatus int
 	}{
 		{
-			name: "failure: no rows",
-			mockService: &test.MockService{
-				ExpectedArray: nil,
-			},
-			expectedStatus: http.StatusNotFound,
+			name:           "success: empty list",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "success",
@@ -200,6 +200,91 @@ func TestRetrieveAll(t *testing.T) {
 	}
 }
 
+func TestUpdate(t *testing.T) {
+	testUpdateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "success: put",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 15, Name: "Harry Potter", Duration: "2h15m"},
+			},
+			method:         http.MethodPut,
+			body:           `{"Name": "Harry Potter", "Duration": "2h15m"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "success: patch",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 15, Name: "Harry Potter", Duration: "02:15:00"},
+			},
+			method:         http.MethodPatch,
+			body:           `{"Name": "Harry Potter"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: patch of missing movie",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			method:         http.MethodPatch,
+			body:           `{"Name": "Harry Potter"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: validation",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: duration too short", internal.ErrValidationFailed),
+			},
+			method:         http.MethodPut,
+			body:           `{"Name": "Harry Potter", "Duration": "5m"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: sessions overlap",
+			mockService: &test.MockService{
+				ExpectedError: &sessions.Conflict{Sessions: []*session.Resource{{ID: 7, Hall_id: 2}}},
+			},
+			method:         http.MethodPut,
+			body:           `{"Name": "Harry Potter", "Duration": "5h"}`,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+	for _, tc := range testUpdateCases {
+
+		logger, err := zap.NewProduction()
+		if err != nil {
+			log.Fatalf("can't initialize zap logger: %v", err)
+		}
+
+		defer func() {
+			if err := logger.Sync(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "/15", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, map[string]string{"id": "15"})
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+		})
+	}
+}
+
 func TestDelete(t *testing.T) {
 	testDeleteCases := []struct {
 		name           string
@@ -224,6 +309,14 @@ func TestDelete(t *testing.T) {
 			id:             15,
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "failure: movie changed before delete",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			id:             15,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 	}
 	for _, tc := range testDeleteCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -0,0 +1,227 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/api/auth"
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/orders"
+	service "github.com/darkjedidj/cinema-service/internal/service/orders"
+)
+
+type Handler struct {
+	s   internal.OrderService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// Create get json and buys all order tickets at once
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Create order
+// @Description  Buys held seats for authorized user, possibly across sessions, either all tickets are bought or none
+// @Tags         Orders
+// @Param        Body  body  repo.Resource  true  "Tickets with session, row, number and hold token"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      401
+// @Failure      402
+// @Failure      403
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Router       /orders [post]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodPost {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var order repo.Resource
+
+	err := json.NewDecoder(request.Body).Decode(&order)
+	if err != nil {
+		h.log.Info("Failed to decode order json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	spoofed := order.User_ID != 0
+	for _, ticket := range order.Tickets {
+		spoofed = spoofed || (ticket != nil && ticket.User_ID != 0)
+	}
+
+	if spoofed {
+		response.WriteHeader(http.StatusBadRequest)
+
+		_, err = response.Write([]byte("user id is taken from access token"))
+		if err != nil {
+			h.log.Info("Failed to write order response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	order.User_ID = principal.User_ID
+	resource, err := h.s.Create(&order, ctx)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+
+		switch {
+		case errors.Is(err, internal.ErrValidationFailed), errors.Is(err, internal.ErrNoSeats), errors.Is(err, internal.ErrPromoInvalid):
+			status = http.StatusBadRequest
+		case errors.Is(err, internal.ErrSeatTaken), errors.Is(err, internal.ErrHoldInvalid):
+			status = http.StatusConflict
+		case errors.Is(err, internal.ErrPaymentFailed):
+			status = http.StatusPaymentRequired
+		case errors.Is(err, internal.ErrAgeRestricted):
+			status = http.StatusForbidden
+		default:
+			response.WriteHeader(status)
+			return
+		}
+
+		response.WriteHeader(status)
+
+		_, err = response.Write([]byte(err.Error()))
+		if err != nil {
+			h.log.Info("Failed to write order response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall order structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write order response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Mine returns order of authorized user with its tickets and payment
+// Mine godoc
+// @Security     ApiKeyAuth
+// @Summary      Retrieve my order
+// @Description  Returns order of authorized user, tickets are issued once its payment is captured
+// @Tags         Orders
+// @Param        id  path  int  true  "Order ID"
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Router       /me/orders/{id} [get]
+func (h *Handler) Mine(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodGet {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse order id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Retrieve(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	order, ok := resource.(*repo.Resource)
+	if !ok || order.User_ID != principal.User_ID {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := json.Marshal(order)
+	if err != nil {
+		h.log.Info("Failed to marshall order structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write order response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,221 @@
+package orders
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/api/auth"
+	"github.com/darkjedidj/cinema-service/internal"
+	order "github.com/darkjedidj/cinema-service/internal/repository/orders"
+	ticket "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var basket = &order.Resource{
+	ID:      1,
+	User_ID: 1,
+	Total:   24.4,
+	Tickets: []*ticket.Resource{
+		{ID: 3, User_ID: 1, Session_ID: 4, Row: 1, Number: 2, Price: 12.2},
+		{ID: 4, User_ID: 1, Session_ID: 5, Row: 1, Number: 3, Price: 12.2},
+	},
+}
+
+func TestCreate(t *testing.T) {
+	body := `{
+		"Tickets": [
+			{"Session_ID": 4, "Row": 1, "Number": 2, "Hold": "f00d"},
+			{"Session_ID": 5, "Row": 1, "Number": 3, "Hold": "beef"}
+		]
+	}`
+
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		anonymous      bool
+		expectedStatus int
+	}{
+		{
+			name: "failure: unauthenticated",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			body:           body,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			body:           body,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: spoofed user id",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			body:           `{"Tickets": [{"User_ID": 2, "Session_ID": 4, "Row": 1, "Number": 2, "Hold": "f00d"}]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: no tickets",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{"Tickets": []}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: one seat is taken",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("ticket 2: %w", internal.ErrSeatTaken),
+			},
+			body:           body,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: payment declined",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrPaymentFailed,
+			},
+			body:           body,
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           body,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/orders", strings.NewReader(tc.body))
+
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).Create(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMine(t *testing.T) {
+	testMineCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		user           int64
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			id:             "1",
+			user:           1,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: order of other user",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			id:             "1",
+			user:           2,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "failure: no order",
+			mockService:    &test.MockService{},
+			id:             "1",
+			user:           1,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: wrong id",
+			mockService: &test.MockService{
+				ExpectedResult: basket,
+			},
+			id:             "one",
+			user:           1,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			user:           1,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testMineCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/me/orders/"+tc.id, nil)
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+			r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: tc.user}))
+
+			(&Handler{s: tc.mockService, log: logger}).Mine(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
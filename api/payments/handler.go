@@ -0,0 +1,81 @@
+package payments
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	service "github.com/darkjedidj/cinema-service/internal/service/orders"
+)
+
+// maxWebhook limits size of provider callback body
+const maxWebhook = 64 << 10
+
+// SignatureHeader carries provider signature of webhook body
+const SignatureHeader = "X-Payment-Signature"
+
+type Handler struct {
+	s   internal.PaymentService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// Webhook applies payment provider callback
+// Webhook godoc
+// @Summary      Payment webhook
+// @Description  Receives signed payment provider events, repeated deliveries are acknowledged without changes
+// @Tags         Payments
+// @Param        X-Payment-Signature  header  string  true  "Provider signature of body"
+// @Accept       json
+// @Success      200
+// @Failure      400
+// @Failure      413
+// @Failure      422
+// @Router       /payments/webhook [post]
+func (h *Handler) Webhook(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if request.Method != http.MethodPost {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := ioutil.ReadAll(http.MaxBytesReader(response, request.Body, maxWebhook))
+	if err != nil {
+		h.log.Info("Failed to read payment webhook.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+	defer request.Body.Close()
+
+	err = h.s.Webhook(payload, request.Header.Get(SignatureHeader), ctx)
+	if errors.Is(err, internal.ErrWebhookInvalid) {
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
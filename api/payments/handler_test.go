@@ -0,0 +1,90 @@
+package payments
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+func TestWebhook(t *testing.T) {
+	testWebhookCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			method:         http.MethodPost,
+			body:           `{"id": "evt_1", "type": "payment.captured", "reference": "fake_1"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad signature",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: invalid webhook signature", internal.ErrWebhookInvalid),
+			},
+			method:         http.MethodPost,
+			body:           `{"id": "evt_1", "type": "payment.captured", "reference": "fake_1"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failure: body too large",
+			mockService:    &test.MockService{},
+			method:         http.MethodPost,
+			body:           strings.Repeat("a", maxWebhook+1),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			method:         http.MethodPost,
+			body:           `{"id": "evt_1", "type": "payment.captured", "reference": "fake_1"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "failure: wrong method",
+			mockService:    &test.MockService{},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testWebhookCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/payments/webhook", strings.NewReader(tc.body))
+			r.Header.Set(SignatureHeader, "f00d")
+
+			(&Handler{s: tc.mockService, log: logger}).Webhook(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+	service "github.com/darkjedidj/cinema-service/internal/service/pricing"
+	"github.com/darkjedidj/cinema-service/package/etag"
+)
+
+type Handler struct {
+	s   internal.Service // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// HandleID handles all endpoints on this route
+func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.Get(response, request) // GET BASE_URL/v1/pricing/rules/{id}
+	case http.MethodDelete:
+		h.Delete(response, request) // DELETE BASE_URL/v1/pricing/rules/{id}
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Handle handles all endpoints on this route
+func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.GetAll(response, request) // GET BASE_URL/v1/pricing/rules
+	case http.MethodPost:
+		h.Create(response, request) // POST BASE_URL/v1/pricing/rules
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Create get json and creates new pricing rule
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Create pricing rule
+// @Description  Creates pricing rule and returns created object
+// @Tags         Pricing
+// @Param        Body  body  repo.Resource  true  "The body to create a pricing rule"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /pricing/rules [post]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var rule repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	err := json.NewDecoder(request.Body).Decode(&rule)
+	if err != nil {
+		h.log.Info("Failed to decode pricing rule json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Create(&rule, ctx)
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write pricing rule response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall pricing rule structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write pricing rule response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete get ID and deletes pricing rule with the same ID
+// Delete godoc
+// @Security     ApiKeyAuth
+// @Summary      Delete pricing rule
+// @Description  Deletes pricing rule
+// @Param        id  path  integer  true  "Rule ID"
+// @Tags         Pricing
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /pricing/rules/{id} [delete]
+func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse pricing rule id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.s.Delete(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// Get ID and selects pricing rule with the same ID
+// Get godoc
+// @Security     ApiKeyAuth
+// @Summary      Get pricing rule
+// @Description  Gets pricing rule
+// @Param        id  path  integer  true  "Rule ID"
+// @Param        If-None-Match  header  string  false  "ETag of cached pricing rule"
+// @Tags         Pricing
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of pricing rule"
+// @Success      304
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /pricing/rules/{id} [get]
+func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse pricing rule id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Retrieve(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tag := etag.Of(resource)
+	response.Header().Set("ETag", tag)
+
+	if etag.Fresh(request.Header.Get("If-None-Match"), tag) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall pricing rule structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write pricing rule response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetAll selects all pricing rules
+// GetAll godoc
+// @Security     ApiKeyAuth
+// @Summary      List pricing rules
+// @Description  get pricing rules
+// @Tags         Pricing
+// @Accept       json
+// @Produce      json
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, kind, name; prefixed by minus for descending order"
+// @Param        kind  query  string  false  "Rule kind"
+// @Param        name  query  string  false  "Rule name"
+// @Success      200  {object}  internal.Page
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /pricing/rules [get]
+func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
+	}
+
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write pricing rule response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall pricing rule structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write pricing rule response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
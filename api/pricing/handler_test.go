@@ -0,0 +1,287 @@
+package pricing
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	rule "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var matinee = &rule.Resource{
+	ID:     1,
+	Kind:   rule.Matinee,
+	Name:   "Matinee",
+	Amount: 20,
+	Starts: "00:00",
+	Ends:   "12:00",
+
+	Version: 3,
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: matinee,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: matinee,
+			},
+			body:           `{"kind": "matinee", "name": "Matinee", "amount": 20, "starts": "00:00", "ends": "12:00"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{"kind": "matinee", "name": "Matinee", "amount": 20}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           `{"kind": "base", "name": "Standard ticket", "amount": 10}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/pricing/rules", strings.NewReader(tc.body))
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	testRetrieveCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		ifNoneMatch    string
+		expectedStatus int
+		expectedTag    string
+	}{
+		{
+			name: "failure: no rows",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: matinee,
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedTag:    `"3"`,
+		},
+		{
+			name: "success: not modified",
+			mockService: &test.MockService{
+				ExpectedResult: matinee,
+			},
+			id:             "1",
+			ifNoneMatch:    `"3"`,
+			expectedStatus: http.StatusNotModified,
+			expectedTag:    `"3"`,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: matinee,
+			},
+			id:             "first",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": tc.id,
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/pricing/rules/"+tc.id, nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			if tc.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedTag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestRetrieveAll(t *testing.T) {
+	testRetrieveAllCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{matinee},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveAllCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/pricing/rules", nil)
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testDeleteCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "1",
+			}
+
+			r := httptest.NewRequest(http.MethodDelete, "http://localhost:8085/v1/pricing/rules/1", nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package privileges
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/privileges"
+	service "github.com/darkjedidj/cinema-service/internal/service/privileges"
+)
+
+type Handler struct {
+	s   internal.EditableService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// HandleID handles all endpoints on this route
+func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.Get(response, request) // GET BASE_URL/v1/privileges/{id}
+	case http.MethodPut, http.MethodPatch:
+		h.Update(response, request) // PUT or PATCH BASE_URL/v1/privileges/{id}
+	case http.MethodDelete:
+		h.Delete(response, request) // DELETE BASE_URL/v1/privileges/{id}
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Handle handles all endpoints on this route
+func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.GetAll(response, request) // GET BASE_URL/v1/privileges
+	case http.MethodPost:
+		h.Create(response, request) // POST BASE_URL/v1/privileges
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Create get json and creates new privilege
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Create privilege
+// @Description  Adds privilege to catalog and returns created object
+// @Tags         Privileges
+// @Param        Body  body  repo.Resource  true  "The body to create a privilege"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /privileges [post]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var privilege repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	err := json.NewDecoder(request.Body).Decode(&privilege)
+	if err != nil {
+		h.log.Info("Failed to decode privilege json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Create(&privilege, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// Delete get ID and deletes privilege with the same ID
+// Delete godoc
+// @Security     ApiKeyAuth
+// @Summary      Delete privilege
+// @Description  Deletes privilege, privileges granted to users or referred by permissions can't be deleted
+// @Param        id  path  integer  true  "Privilege ID"
+// @Tags         Privileges
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /privileges/{id} [delete]
+func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse privilege id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.s.Delete(int64(id), ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// Get ID and selects privilege with the same ID
+// Get godoc
+// @Security     ApiKeyAuth
+// @Summary      Get privilege
+// @Description  Gets privilege with its description
+// @Param        id  path  integer  true  "Privilege ID"
+// @Tags         Privileges
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /privileges/{id} [get]
+func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse privilege id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Retrieve(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// Update replaces privilege with PUT or changes only passed fields with PATCH
+// Update godoc
+// @Security     ApiKeyAuth
+// @Summary      Update privilege
+// @Description  PUT replaces privilege, PATCH changes only fields present in body. Renames are passed on to permissions.
+// @Tags         Privileges
+// @Param        id  path  integer  true  "Privilege ID"
+// @Param        Body  body  repo.Resource  true  "The body to update a privilege"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /privileges/{id} [put]
+// @Router       /privileges/{id} [patch]
+func (h *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var privilege repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse privilege id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Method == http.MethodPatch {
+		current, err := h.s.Retrieve(int64(id), ctx)
+		if err != nil {
+			response.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		if current == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		res, ok := current.(*repo.Resource)
+		if !ok {
+			h.log.Info("Failed to assert privilege object.",
+				zap.Bool("ok", ok),
+			)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		privilege = *res
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&privilege)
+	if err != nil {
+		h.log.Info("Failed to decode privilege json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Update(int64(id), &privilege, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// GetAll selects all privileges
+// GetAll godoc
+// @Security     ApiKeyAuth
+// @Summary      List privileges
+// @Description  get privilege catalog
+// @Tags         Privileges
+// @Accept       json
+// @Produce      json
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, name; prefixed by minus for descending order"
+// @Param        name  query  string  false  "Privilege name"
+// @Success      200  {object}  internal.Page
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /privileges [get]
+func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
+	}
+
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrPrivilegeExists), errors.Is(err, internal.ErrPrivilegeInUse):
+		status = http.StatusConflict
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write privilege response.",
+			zap.Error(err),
+		)
+	}
+}
+
+// write marshals privilege response
+func (h *Handler) write(response http.ResponseWriter, resource interface{}) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall privilege structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write privilege response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,350 @@
+package privileges
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	privilege "github.com/darkjedidj/cinema-service/internal/repository/privileges"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var checkin = &privilege.Resource{
+	ID:          5,
+	Name:        "checkin",
+	Description: "Verify ticket codes and admit holders to sessions",
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: checkin,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: checkin,
+			},
+			body:           `{"name": "ushers", "description": "Admit holders to sessions"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{"name": "Ushers!"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: privilege exists",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrPrivilegeExists,
+			},
+			body:           `{"name": "checkin"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           `{"name": "ushers"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/privileges", strings.NewReader(tc.body))
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	testUpdateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: checkin,
+			},
+			id:             "5",
+			body:           `{"name": "checkin", "description": "Admit holders to sessions"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failure: no rows",
+			mockService:    &test.MockService{},
+			id:             "9",
+			body:           `{"name": "ushers"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: checkin,
+			},
+			id:             "second",
+			body:           `{"name": "checkin"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: renamed to existing privilege",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrPrivilegeExists,
+			},
+			id:             "5",
+			body:           `{"name": "tickets"}`,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+	for _, tc := range testUpdateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPut, "http://localhost:8085/v1/privileges/"+tc.id, strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	testRetrieveCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		expectedStatus int
+	}{
+		{
+			name:           "failure: no rows",
+			mockService:    &test.MockService{},
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: checkin,
+			},
+			id:             "5",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: checkin,
+			},
+			id:             "second",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "5",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/privileges/"+tc.id, nil)
+
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieveAll(t *testing.T) {
+	testRetrieveAllCases := []struct {
+		name           string
+		mockService    *test.MockService
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{checkin},
+			},
+			query:          "?name=checkin",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad limit",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{checkin},
+			},
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveAllCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/privileges"+tc.query, nil)
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: privilege in use",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrPrivilegeInUse,
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testDeleteCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodDelete, "http://localhost:8085/v1/privileges/5", nil)
+
+			r = mux.SetURLVars(r, map[string]string{"id": "5"})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
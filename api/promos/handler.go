@@ -0,0 +1,334 @@
+package promos
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/promos"
+	service "github.com/darkjedidj/cinema-service/internal/service/promos"
+	"github.com/darkjedidj/cinema-service/package/etag"
+)
+
+type Handler struct {
+	s   internal.Service // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// HandleID handles all endpoints on this route
+func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.Get(response, request) // GET BASE_URL/v1/promos/{id}
+	case http.MethodDelete:
+		h.Delete(response, request) // DELETE BASE_URL/v1/promos/{id}
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Handle handles all endpoints on this route
+func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.GetAll(response, request) // GET BASE_URL/v1/promos
+	case http.MethodPost:
+		h.Create(response, request) // POST BASE_URL/v1/promos
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Create get json and creates new promo code
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Create promo code
+// @Description  Creates promo code and returns created object
+// @Tags         Promos
+// @Param        Body  body  repo.Resource  true  "The body to create a promo code"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /promos [post]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var promo repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	err := json.NewDecoder(request.Body).Decode(&promo)
+	if err != nil {
+		h.log.Info("Failed to decode promo code json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Create(&promo, ctx)
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write promo code response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		if errors.Is(err, internal.ErrPromoExists) {
+			response.WriteHeader(http.StatusConflict)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write promo code response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall promo code structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write promo code response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Delete get ID and deletes promo code with the same ID
+// Delete godoc
+// @Security     ApiKeyAuth
+// @Summary      Delete promo code
+// @Description  Deletes promo code
+// @Param        id  path  integer  true  "Promo code ID"
+// @Tags         Promos
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /promos/{id} [delete]
+func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse promo code id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.s.Delete(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// Get ID and selects promo code with the same ID
+// Get godoc
+// @Security     ApiKeyAuth
+// @Summary      Get promo code
+// @Description  Gets promo code
+// @Param        id  path  integer  true  "Promo code ID"
+// @Param        If-None-Match  header  string  false  "ETag of cached promo code"
+// @Tags         Promos
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of promo code"
+// @Success      304
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /promos/{id} [get]
+func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse promo code id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Retrieve(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	tag := etag.Of(resource)
+	response.Header().Set("ETag", tag)
+
+	if etag.Fresh(request.Header.Get("If-None-Match"), tag) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall promo code structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write promo code response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetAll selects all promo codes
+// GetAll godoc
+// @Security     ApiKeyAuth
+// @Summary      List promo codes
+// @Description  get promo codes
+// @Tags         Promos
+// @Accept       json
+// @Produce      json
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, code, kind; prefixed by minus for descending order"
+// @Param        code  query  string  false  "Promo code"
+// @Param        kind  query  string  false  "percent, fixed or voucher"
+// @Success      200  {object}  internal.Page
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /promos [get]
+func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
+	}
+
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write promo code response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall promo code structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write promo code response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,334 @@
+package promos
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	promo "github.com/darkjedidj/cinema-service/internal/repository/promos"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var spring = &promo.Resource{
+	ID:       1,
+	Code:     "SPRING",
+	Kind:     promo.Percent,
+	Amount:   15,
+	Per_user: 1,
+
+	Version: 3,
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: spring,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: spring,
+			},
+			body:           `{"code": "spring", "kind": "percent", "amount": 15, "max_uses": 100, "per_user": 1, "starts_at": "2022-04-01T00:00:00Z", "ends_at": "2022-06-01T00:00:00Z"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "success: voucher",
+			mockService: &test.MockService{
+				ExpectedResult: &promo.Resource{ID: 2, Code: "GIFT50", Kind: promo.Voucher, Amount: 50, Balance: 50, Max_uses: 1},
+			},
+			body:           `{"code": "gift50", "kind": "voucher", "amount": 50}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: malformed window",
+			mockService: &test.MockService{
+				ExpectedResult: spring,
+			},
+			body:           `{"code": "spring", "kind": "percent", "amount": 15, "ends_at": "June"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: percent over 100",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: discount can't exceed 100 percent", internal.ErrValidationFailed),
+			},
+			body:           `{"code": "spring", "kind": "percent", "amount": 120}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: unknown kind",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: unknown promo code kind \"spring\"", internal.ErrValidationFailed),
+			},
+			body:           `{"code": "spring", "kind": "spring", "amount": 15}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: negative usage limit",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: usage limits can't be negative", internal.ErrValidationFailed),
+			},
+			body:           `{"code": "spring", "kind": "percent", "amount": 15, "per_user": -1}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: expired window",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: promo code has already ended", internal.ErrValidationFailed),
+			},
+			body:           `{"code": "spring", "kind": "percent", "amount": 15, "starts_at": "2021-03-01T00:00:00Z", "ends_at": "2021-06-01T00:00:00Z"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: duplicate code",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrPromoExists,
+			},
+			body:           `{"code": "spring", "kind": "percent", "amount": 15}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           `{"code": "gift", "kind": "voucher", "amount": 50}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/promos", strings.NewReader(tc.body))
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	testRetrieveCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		ifNoneMatch    string
+		expectedStatus int
+		expectedTag    string
+	}{
+		{
+			name: "failure: no rows",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: spring,
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+			expectedTag:    `"3"`,
+		},
+		{
+			name: "success: not modified",
+			mockService: &test.MockService{
+				ExpectedResult: spring,
+			},
+			id:             "1",
+			ifNoneMatch:    `"3"`,
+			expectedStatus: http.StatusNotModified,
+			expectedTag:    `"3"`,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: spring,
+			},
+			id:             "first",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": tc.id,
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/promos/"+tc.id, nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			if tc.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedTag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestRetrieveAll(t *testing.T) {
+	testRetrieveAllCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{spring},
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveAllCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/promos", nil)
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testDeleteCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "1",
+			}
+
+			r := httptest.NewRequest(http.MethodDelete, "http://localhost:8085/v1/promos/1", nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
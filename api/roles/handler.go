@@ -0,0 +1,466 @@
+package roles
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/roles"
+	service "github.com/darkjedidj/cinema-service/internal/service/roles"
+)
+
+type Handler struct {
+	s   internal.RoleService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// HandleID handles all endpoints on this route
+func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.Get(response, request) // GET BASE_URL/v1/roles/{id}
+	case http.MethodPut:
+		h.Update(response, request) // PUT BASE_URL/v1/roles/{id}
+	case http.MethodDelete:
+		h.Delete(response, request) // DELETE BASE_URL/v1/roles/{id}
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Handle handles all endpoints on this route
+func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.GetAll(response, request) // GET BASE_URL/v1/roles
+	case http.MethodPost:
+		h.Create(response, request) // POST BASE_URL/v1/roles
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// HandleUser handles all endpoints on this route
+func (h *Handler) HandleUser(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.UserRoles(response, request) // GET BASE_URL/v1/users/{id}/roles
+	case http.MethodPut:
+		h.Assign(response, request) // PUT BASE_URL/v1/users/{id}/roles
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Create get json and creates new role
+// Create godoc
+// @Security     ApiKeyAuth
+// @Summary      Create role
+// @Description  Creates role of permissions and returns created object
+// @Tags         Roles
+// @Param        Body  body  repo.Resource  true  "The body to create a role"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /roles [post]
+func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var role repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	err := json.NewDecoder(request.Body).Decode(&role)
+	if err != nil {
+		h.log.Info("Failed to decode role json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Create(&role, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// Delete get ID and deletes role with the same ID
+// Delete godoc
+// @Security     ApiKeyAuth
+// @Summary      Delete role
+// @Description  Deletes role and takes it from users, built-in roles can't be deleted
+// @Param        id  path  integer  true  "Role ID"
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      200
+// @Failure      400
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /roles/{id} [delete]
+func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse role id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = h.s.Delete(int64(id), ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	response.WriteHeader(http.StatusOK)
+}
+
+// Get ID and selects role with the same ID
+// Get godoc
+// @Security     ApiKeyAuth
+// @Summary      Get role
+// @Description  Gets role with its permissions
+// @Param        id  path  integer  true  "Role ID"
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /roles/{id} [get]
+func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse role id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Retrieve(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// Update get ID and json and replaces role with the same ID
+// Update godoc
+// @Security     ApiKeyAuth
+// @Summary      Update role
+// @Description  Replaces role name, description and permissions. Built-in roles keep their names, superadmin keeps every permission.
+// @Tags         Roles
+// @Param        id  path  integer  true  "Role ID"
+// @Param        Body  body  repo.Resource  true  "The body to update a role"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /roles/{id} [put]
+func (h *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var role repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse role id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&role)
+	if err != nil {
+		h.log.Info("Failed to decode role json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Update(int64(id), &role, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// GetAll selects all roles
+// GetAll godoc
+// @Security     ApiKeyAuth
+// @Summary      List roles
+// @Description  get roles with their permissions
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, name, system; prefixed by minus for descending order"
+// @Param        name  query  string  false  "Role name"
+// @Param        system  query  bool  false  "Built-in roles only"
+// @Param        permission  query  string  false  "Roles holding permission"
+// @Success      200  {object}  internal.Page
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /roles [get]
+func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
+	}
+
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	h.write(response, resource)
+}
+
+// UserRoles get user ID and selects roles of user
+// UserRoles godoc
+// @Security     ApiKeyAuth
+// @Summary      List roles of user
+// @Description  Gets roles assigned to user
+// @Param        id  path  integer  true  "User ID"
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}  repo.Resource
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /users/{id}/roles [get]
+func (h *Handler) UserRoles(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse user id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	roles, err := h.s.UserRoles(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.write(response, roles)
+}
+
+// Assign get user ID and json and replaces roles of user
+// Assign godoc
+// @Security     ApiKeyAuth
+// @Summary      Assign roles to user
+// @Description  Replaces roles of user with roles of passed names, the last superadmin can't lose the role
+// @Param        id  path  integer  true  "User ID"
+// @Param        Body  body  service.Assignment  true  "Names of roles user holds"
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      200  {array}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Failure      403
+// @Router       /users/{id}/roles [put]
+func (h *Handler) Assign(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var assignment service.Assignment
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse user id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&assignment)
+	if err != nil {
+		h.log.Info("Failed to decode role assignment json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	roles, err := h.s.Assign(int64(id), assignment.Roles, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	h.write(response, roles)
+}
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, internal.ErrRoleExists), errors.Is(err, internal.ErrSystemRole):
+		status = http.StatusConflict
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write role response.",
+			zap.Error(err),
+		)
+	}
+}
+
+// write marshals role response
+func (h *Handler) write(response http.ResponseWriter, resource interface{}) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall role structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write role response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,478 @@
+package roles
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	role "github.com/darkjedidj/cinema-service/internal/repository/roles"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var cashier = &role.Resource{
+	ID:          2,
+	Name:        role.Cashier,
+	System:      true,
+	Permissions: []string{"tickets:checkin", "tickets:read"},
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: cashier,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: cashier,
+			},
+			body:           `{"name": "usher", "permissions": ["tickets:checkin"]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{"name": "usher", "permissions": ["tickets:fly"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: role exists",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrRoleExists,
+			},
+			body:           `{"name": "cashier"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           `{"name": "usher"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCreateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/roles", strings.NewReader(tc.body))
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	testUpdateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: cashier,
+			},
+			id:             "2",
+			body:           `{"name": "cashier", "permissions": ["tickets:checkin", "tickets:read"]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failure: no rows",
+			mockService:    &test.MockService{},
+			id:             "9",
+			body:           `{"name": "usher"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: cashier,
+			},
+			id:             "second",
+			body:           `{"name": "cashier"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: built-in role renamed",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrSystemRole,
+			},
+			id:             "2",
+			body:           `{"name": "teller"}`,
+			expectedStatus: http.StatusConflict,
+		},
+	}
+	for _, tc := range testUpdateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPut, "http://localhost:8085/v1/roles/"+tc.id, strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	testRetrieveCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		expectedStatus int
+	}{
+		{
+			name:           "failure: no rows",
+			mockService:    &test.MockService{},
+			id:             "1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: cashier,
+			},
+			id:             "2",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: cashier,
+			},
+			id:             "second",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "2",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/roles/"+tc.id, nil)
+
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRetrieveAll(t *testing.T) {
+	testRetrieveAllCases := []struct {
+		name           string
+		mockService    *test.MockService
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{cashier},
+			},
+			query:          "?permission=tickets:checkin",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad limit",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{cashier},
+			},
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testRetrieveAllCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/roles"+tc.query, nil)
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name           string
+		mockService    *test.MockService
+		expectedStatus int
+	}{
+		{
+			name:           "success",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: built-in role",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrSystemRole,
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testDeleteCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodDelete, "http://localhost:8085/v1/roles/5", nil)
+
+			r = mux.SetURLVars(r, map[string]string{"id": "5"})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestUserRoles(t *testing.T) {
+	testUserRolesCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		id             string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success, list",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{cashier},
+			},
+			method:         http.MethodGet,
+			id:             "7",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "success, no roles",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{},
+			},
+			method:         http.MethodGet,
+			id:             "7",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[]",
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{cashier},
+			},
+			method:         http.MethodGet,
+			id:             "seventh",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success, assign",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{cashier},
+			},
+			method:         http.MethodPut,
+			id:             "7",
+			body:           `{"roles": ["cashier"]}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: assign empty body",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{cashier},
+			},
+			method:         http.MethodPut,
+			id:             "7",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: unknown role",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			method:         http.MethodPut,
+			id:             "7",
+			body:           `{"roles": ["wizard"]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: unknown user",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrNotFound,
+			},
+			method:         http.MethodPut,
+			id:             "99",
+			body:           `{"roles": ["cashier"]}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: last superadmin",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrSystemRole,
+			},
+			method:         http.MethodPut,
+			id:             "1",
+			body:           `{"roles": []}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "failure: method not allowed",
+			mockService:    &test.MockService{},
+			method:         http.MethodDelete,
+			id:             "7",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testUserRolesCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/users/"+tc.id+"/roles", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			(&Handler{s: tc.mockService, log: logger}).HandleUser(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,52 +1,148 @@
 package server
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/gorilla/mux"
 	httpSwagger "github.com/swaggo/http-swagger" // http-swagger middleware
 	"go.uber.org/zap"
 
+	"github.com/darkjedidj/cinema-service/api/auth"
 	"github.com/darkjedidj/cinema-service/api/halls"
+	"github.com/darkjedidj/cinema-service/api/holds"
+	"github.com/darkjedidj/cinema-service/api/layouts"
 	"github.com/darkjedidj/cinema-service/api/movies"
+	"github.com/darkjedidj/cinema-service/api/orders"
+	"github.com/darkjedidj/cinema-service/api/payments"
+	"github.com/darkjedidj/cinema-service/api/pricing"
+	"github.com/darkjedidj/cinema-service/api/privileges"
+	"github.com/darkjedidj/cinema-service/api/promos"
+	"github.com/darkjedidj/cinema-service/api/roles"
 	"github.com/darkjedidj/cinema-service/api/sessions"
 	"github.com/darkjedidj/cinema-service/api/tickets"
 	"github.com/darkjedidj/cinema-service/api/user_privileges"
 	"github.com/darkjedidj/cinema-service/api/users"
+	"github.com/darkjedidj/cinema-service/api/waitlist"
+	hold "github.com/darkjedidj/cinema-service/internal/service/holds"
+	order "github.com/darkjedidj/cinema-service/internal/service/orders"
+	role "github.com/darkjedidj/cinema-service/internal/service/roles"
+	ticket "github.com/darkjedidj/cinema-service/internal/service/tickets"
+	queue "github.com/darkjedidj/cinema-service/internal/service/waitlist"
+	"github.com/darkjedidj/cinema-service/package/clock"
+	tkn "github.com/darkjedidj/cinema-service/package/jwt"
 )
 
 type App struct {
 	Router *mux.Router
+	stop   context.CancelFunc // Stops background workers
 }
 
-// New creates router with handler
-func (a *App) New(db *sql.DB, l *zap.Logger) {
+// New creates router with handler, it fails when access token keys can't be loaded.
+// Temporary key is used only in development mode, JWT_DEV_KEYS=true without JWT_KEYS_DIR.
+func (a *App) New(db *sql.DB, l *zap.Logger) error {
+
+	keys, err := tkn.KeysFromEnv()
+	switch {
+	case err == nil:
+		tkn.SetKeys(keys)
+	case os.Getenv("JWT_KEYS_DIR") == "" && os.Getenv("JWT_DEV_KEYS") == "true":
+		l.Info("Access tokens are signed with temporary key, they don't survive restart.")
+	default:
+		return fmt.Errorf("failed to load access token keys: %w", err)
+	}
+
+	guard := auth.Init(db, l)
 
 	myRouter := mux.NewRouter().StrictSlash(false)
-	myRouter.HandleFunc("/v1/tickets/{id}", tickets.Init(db, l).HandleID)
-	myRouter.HandleFunc("/v1/tickets/{id}/download", users.Init(db, l).CheckTicket(tickets.Init(db, l).Download))
-	myRouter.HandleFunc("/v1/tickets", users.Init(db, l).CheckPrivileges("tickets", tickets.Init(db, l).Handle))
-	myRouter.HandleFunc("/v1/sessions/{id}/tickets", tickets.Init(db, l).Create)
-	myRouter.HandleFunc("/v1/sessions/{id}", users.Init(db, l).CheckPrivileges("sessions", sessions.Init(db, l).HandleID))
-	myRouter.HandleFunc("/v1/sessions", users.Init(db, l).CheckPrivileges("sessions", sessions.Init(db, l).Handle))
-	myRouter.HandleFunc("/v1/halls/{id}/sessions", users.Init(db, l).CheckPrivileges("sessions", sessions.Init(db, l).Create))
-	myRouter.HandleFunc("/v1/movies/{id}", users.Init(db, l).CheckPrivileges("movies", movies.Init(db, l).HandleID))
-	myRouter.HandleFunc("/v1/movies", users.Init(db, l).CheckPrivileges("movies", movies.Init(db, l).Handle))
-	myRouter.HandleFunc("/v1/halls/{id}", users.Init(db, l).CheckPrivileges("halls", halls.Init(db, l).HandleID))
-	myRouter.HandleFunc("/v1/halls", users.Init(db, l).CheckPrivileges("halls", halls.Init(db, l).Handle))
-	myRouter.HandleFunc("/v1/user_privileges/{id}", users.Init(db, l).CheckPrivileges("privileges", user_privileges.Init(db, l).HandleID))
-	myRouter.HandleFunc("/v1/user_privileges", users.Init(db, l).CheckPrivileges("privileges", user_privileges.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/tickets/verify", guard.Require("tickets:checkin", tickets.Init(db, l).Verify))
+	myRouter.HandleFunc("/v1/tickets/{id}", guard.Resource("tickets", tickets.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/tickets/{id}/refund", guard.Require("tickets:refund", tickets.Init(db, l).Refund))
+	myRouter.HandleFunc("/v1/tickets/{id}/checkin", guard.Require("tickets:checkin", tickets.Init(db, l).CheckIn))
+	myRouter.HandleFunc("/v1/tickets/{id}/download", guard.OwnTicket(tickets.Init(db, l).Download))
+	myRouter.HandleFunc("/v1/tickets", guard.Resource("tickets", tickets.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/me/tickets/{id}/refund", guard.Authenticate(tickets.Init(db, l).Cancel))
+	myRouter.HandleFunc("/v1/me/tickets/{id}/code", guard.Authenticate(tickets.Init(db, l).Code))
+	myRouter.HandleFunc("/v1/me/tickets", guard.Authenticate(tickets.Init(db, l).Mine))
+	myRouter.HandleFunc("/v1/me/waitlist", guard.Authenticate(waitlist.Init(db, l).Mine))
+	myRouter.HandleFunc("/v1/me/orders/{id}", guard.Authenticate(orders.Init(db, l).Mine))
+	myRouter.HandleFunc("/v1/orders", guard.Authenticate(orders.Init(db, l).Create))
+	myRouter.HandleFunc("/v1/payments/webhook", payments.Init(db, l).Webhook)
+	myRouter.HandleFunc("/v1/sessions/{id}/tickets", guard.Authenticate(tickets.Init(db, l).Create))
+	myRouter.HandleFunc("/v1/sessions/{id}/waitlist", guard.Authenticate(waitlist.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/sessions/{id}/seats", tickets.Init(db, l).Seats)
+	myRouter.HandleFunc("/v1/sessions/{id}/holds", guard.Authenticate(holds.Init(db, l).Create))
+	myRouter.HandleFunc("/v1/holds/{token}", guard.Authenticate(holds.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/sessions/{id}", guard.Resource("sessions", sessions.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/sessions", guard.Resource("sessions", sessions.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/halls/{id}/sessions", guard.Require("sessions:write", sessions.Init(db, l).Create))
+	myRouter.HandleFunc("/v1/showtimes", sessions.Init(db, l).Showtimes)
+	myRouter.HandleFunc("/v1/schedules", guard.Require("sessions:write", sessions.Init(db, l).Schedule))
+	myRouter.HandleFunc("/v1/movies/{id}", guard.Resource("movies", movies.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/movies", guard.Resource("movies", movies.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/halls/{id}/layout", guard.Resource("halls", layouts.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/halls/{id}", guard.Resource("halls", halls.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/halls", guard.Resource("halls", halls.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/pricing/rules/{id}", guard.Resource("pricing", pricing.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/pricing/rules", guard.Resource("pricing", pricing.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/promos/{id}", guard.Resource("promos", promos.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/promos", guard.Resource("promos", promos.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/privileges/{id}", guard.Resource("privileges", privileges.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/privileges", guard.Resource("privileges", privileges.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/user_privileges/{id}", guard.Resource("privileges", user_privileges.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/user_privileges", guard.Resource("privileges", user_privileges.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/roles/{id}", guard.Resource("roles", roles.Init(db, l).HandleID))
+	myRouter.HandleFunc("/v1/roles", guard.Resource("roles", roles.Init(db, l).Handle))
+	myRouter.HandleFunc("/v1/users/{id}/roles", guard.Resource("roles", roles.Init(db, l).HandleUser))
+	myRouter.HandleFunc("/v1/users/{id}/birth_date", guard.Require("users:verify", users.Init(db, l).BirthDate))
 	myRouter.HandleFunc("/v1/signin", users.Init(db, l).Signin)
 	myRouter.HandleFunc("/v1/signup", users.Init(db, l).Signup)
+	myRouter.HandleFunc("/.well-known/jwks.json", users.Init(db, l).JWKS)
+	myRouter.HandleFunc("/v1/token/refresh", users.Init(db, l).Refresh)
+	myRouter.HandleFunc("/v1/logout", guard.Authenticate(users.Init(db, l).Logout))
 	myRouter.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
 		httpSwagger.URL("http://cinema-alb-dev-o81jt53c-906642332.us-east-1.elb.amazonaws.com:8085/swagger/doc.json"), //The url pointing to API definition
 	))
 	a.Router = myRouter
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stop = cancel
+
+	go hold.NewSweeper(db, l, clock.Real{}, hold.SweepInterval).Run(ctx)
+	go ticket.NewExpirer(db, l, clock.Real{}, ticket.ExpireInterval).Run(ctx)
+	go order.NewExpirer(db, l, clock.Real{}, order.ExpireInterval).Run(ctx)
+	go queue.NewDispatcher(db, l, clock.Real{}, queue.DispatchInterval).Run(ctx)
+
+	return nil
+}
+
+// Bootstrap makes user of email the first superadmin unless database already has one,
+// user signs up with password when missing
+func (a *App) Bootstrap(db *sql.DB, l *zap.Logger, email string, password string) {
+	created, err := role.Init(db, l).Bootstrap(email, password, context.Background())
+	if err != nil {
+		l.Info("Failed to bootstrap superadmin.",
+			zap.String("email", email),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	if created {
+		l.Info("Superadmin is bootstrapped.",
+			zap.String("email", email),
+		)
+	}
 }
 
 // Run starts server
 func (a *App) Run(addr string) {
-	log.Fatal(http.ListenAndServe(addr, a.Router))
+	err := http.ListenAndServe(addr, a.Router)
+	a.stop()
+	log.Fatal(err)
 }
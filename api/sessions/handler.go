@@ -14,10 +14,11 @@ import (
 	"github.com/darkjedidj/cinema-service/internal"
 	repo "github.com/darkjedidj/cinema-service/internal/repository/sessions"
 	service "github.com/darkjedidj/cinema-service/internal/service/sessions"
+	"github.com/darkjedidj/cinema-service/package/etag"
 )
 
 type Handler struct {
-	s   internal.Service // Allows use service features
+	s   internal.SessionService // Allows use service features
 	log *zap.Logger
 }
 
@@ -31,12 +32,21 @@ func Init(db *sql.DB, l *zap.Logger) *Handler {
 	}
 }
 
+// Conflict is a response body listing sessions new ones overlap with
+type Conflict struct {
+	Error    string            `json:"error"`
+	Sessions []*repo.Resource  `json:"sessions"`
+	Schedule *service.Schedule `json:"schedule,omitempty"`
+}
+
 // HandleID handles all endpoints on this route
 func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request) {
 
 	switch request.Method {
 	case http.MethodGet:
 		h.Get(response, request) // GET BASE_URL/v1/sessions/{id}
+	case http.MethodPut, http.MethodPatch:
+		h.Update(response, request) // PUT or PATCH BASE_URL/v1/sessions/{id}
 	case http.MethodDelete:
 		h.Delete(response, request) // DELETE BASE_URL/v1/sessions/{id}
 	default:
@@ -67,6 +77,7 @@ func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
 // @Produce      json
 // @Success      200  {object}  repo.Resource
 // @Failure      400
+// @Failure      409  {object}  Conflict
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -104,6 +115,12 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 	session.Hall_id = int64(id)
 	resource, err := h.s.Create(&session, ctx)
 	if err != nil {
+		var conflict *service.Conflict
+		if errors.As(err, &conflict) {
+			h.conflict(response, conflict)
+			return
+		}
+
 		if errors.Is(err, internal.ErrValidationFailed) {
 			response.WriteHeader(http.StatusBadRequest)
 
@@ -152,11 +169,13 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 // @Summary      Delete session
 // @Description  Deletes session
 // @Param        id  path  integer  true  "Session ID"
+// @Param        If-Match  header  string  false  "ETag of session to delete"
 // @Tags         Sessions
 // @Accept       json
 // @Produce      json
 // @Success      200
 // @Failure      400
+// @Failure      412
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -178,9 +197,15 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = h.s.Delete(int64(id), ctx)
+	version, ok := h.precondition(response, request, int64(id), ctx)
+	if !ok {
+		return
+	}
+
+	err = h.s.DeleteVersion(int64(id), version, ctx)
 	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
+		h.writeError(response, err)
+		return
 	}
 
 	response.WriteHeader(http.StatusOK)
@@ -192,10 +217,13 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 // @Summary      Get session
 // @Description  Gets session
 // @Param        id  path  integer  true  "Session ID"
+// @Param        If-None-Match  header  string  false  "ETag of cached session"
 // @Tags         Sessions
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of session"
+// @Success      304
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -230,6 +258,138 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	tag := etag.Of(resource)
+	response.Header().Set("ETag", tag)
+
+	if etag.Fresh(request.Header.Get("If-None-Match"), tag) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall session structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write session response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Update replaces session with PUT or changes only passed fields with PATCH
+// Update godoc
+// @Security     ApiKeyAuth
+// @Summary      Update session
+// @Description  PUT replaces session, PATCH changes only fields present in body
+// @Tags         Sessions
+// @Param        id  path  integer  true  "Session ID"
+// @Param        If-Match  header  string  false  "ETag of session the change is based on"
+// @Param        Body  body  repo.Resource  true  "The body to update a session"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of updated session"
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      412
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /sessions/{id} [put]
+// @Router       /sessions/{id} [patch]
+func (h *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var session repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse session id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.precondition(response, request, int64(id), ctx)
+	if !ok {
+		return
+	}
+
+	if request.Method == http.MethodPatch {
+		current, err := h.s.Retrieve(int64(id), ctx)
+		if err != nil {
+			response.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		if current == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		res, ok := current.(*repo.Resource)
+		if !ok {
+			h.log.Info("Failed to assert session object.",
+				zap.Bool("ok", ok),
+			)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		session = *res
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&session)
+	if err != nil {
+		h.log.Info("Failed to decode session json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	session.Version = version
+
+	resource, err := h.s.Update(int64(id), &session, ctx)
+	if err != nil {
+		var conflict *service.Conflict
+		if errors.As(err, &conflict) {
+			h.conflict(response, conflict)
+			return
+		}
+
+		h.writeError(response, err)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response.Header().Set("ETag", etag.Of(resource))
+
 	body, err := json.Marshal(resource)
 	if err != nil {
 		h.log.Info("Failed to marshall session structure.",
@@ -259,7 +419,13 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 // @Tags         Sessions
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}  []repo.Resource
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, starts_at, hall_id, movie_id; prefixed by minus for descending order"
+// @Param        starts_at  query  string  false  "Start time, RFC 3339"
+// @Param        hall_id  query  int  false  "Hall ID"
+// @Param        movie_id  query  int  false  "Movie ID"
+// @Success      200  {object}  internal.Page
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -271,20 +437,119 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 
 	response.Header().Set("Content-Type", "application/json")
 
-	resource, err := h.s.RetrieveAll(ctx)
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
+	}
+
 	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write session response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
 		response.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
-	if resource == nil {
-		response.WriteHeader(http.StatusNotFound)
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall session structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write session response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Schedule get template and generates sessions from it
+// Schedule godoc
+// @Security     ApiKeyAuth
+// @Summary      Schedule sessions
+// @Description  Generates sessions of movie in halls for every day of date range, at listed times or one after another between opening and closing. Dry run returns proposed sessions and their conflicts without saving them.
+// @Tags         Sessions
+// @Param        Body  body  service.Template  true  "Schedule template"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  service.Schedule
+// @Failure      400
+// @Failure      409  {object}  Conflict
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /schedules [post]
+func (h *Handler) Schedule(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodPost {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var template service.Template
+
+	err := json.NewDecoder(request.Body).Decode(&template)
+	if err != nil {
+		h.log.Info("Failed to decode schedule template json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Schedule(&template, ctx)
+	if err != nil {
+		var conflict *service.Conflict
+		if errors.As(err, &conflict) {
+			h.conflict(response, conflict)
+			return
+		}
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write session response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
 	body, err := json.Marshal(resource)
 	if err != nil {
-		h.log.Info("Failed to marshall session structure.",
+		h.log.Info("Failed to marshall schedule structure.",
 			zap.Error(err),
 		)
 
@@ -302,3 +567,161 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 }
+
+// Showtimes lists upcoming sessions for customers
+// Showtimes godoc
+// @Summary      List showtimes
+// @Description  Public list of upcoming sessions with seats left for sale, sorted by start time
+// @Tags         Sessions
+// @Param        from       query  string   false  "First day, 2022-04-01, today by default"
+// @Param        to         query  string   false  "Last day, inclusive"
+// @Param        movie_id   query  integer  false  "Movie ID"
+// @Param        hall_id    query  integer  false  "Hall ID"
+// @Param        vip        query  boolean  false  "VIP halls only when true, regular halls when false"
+// @Param        available  query  boolean  false  "Only sessions with seats left"
+// @Param        limit      query  int      false  "Page size, 20 by default and 100 at most"
+// @Param        cursor     query  string   false  "Next cursor of previous page"
+// @Param        sort       query  string   false  "Sort field: id, starts_at; prefixed by minus for descending order. Start time by default"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  internal.Page
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Router       /showtimes [get]
+func (h *Handler) Showtimes(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodGet {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.Showtimes(query, ctx)
+	}
+
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write session response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall showtime structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write session response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// conflict writes 409 with sessions which keep hall busy
+func (h *Handler) conflict(response http.ResponseWriter, conflict *service.Conflict) {
+	body, err := json.Marshal(&Conflict{Error: conflict.Error(), Sessions: conflict.Sessions, Schedule: conflict.Schedule})
+	if err != nil {
+		h.log.Info("Failed to marshall session conflict structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response.WriteHeader(http.StatusConflict)
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write session response.",
+			zap.Error(err),
+		)
+	}
+}
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrTicketsSold):
+		status = http.StatusConflict
+	case errors.Is(err, internal.ErrVersionMismatch):
+		status = http.StatusPreconditionFailed
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write session response.",
+			zap.Error(err),
+		)
+	}
+}
+
+// precondition checks If-Match header against current session and writes 412 when
+// client changes stale copy. It returns session version client expects, zero without header.
+func (h *Handler) precondition(response http.ResponseWriter, request *http.Request, id int64, ctx context.Context) (int64, bool) {
+	match := request.Header.Get("If-Match")
+	if match == "" {
+		return 0, true
+	}
+
+	current, err := h.s.Retrieve(id, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	if !etag.Match(match, etag.Of(current)) {
+		response.WriteHeader(http.StatusPreconditionFailed)
+		return 0, false
+	}
+
+	versioned, ok := current.(etag.Versioned)
+	if !ok {
+		h.log.Info("Failed to assert session object.",
+			zap.Bool("ok", ok),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return versioned.Revision(), true
+}
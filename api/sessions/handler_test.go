@@ -14,6 +14,7 @@ import (
 
 	"github.com/darkjedidj/cinema-service/internal"
 	movie "github.com/darkjedidj/cinema-service/internal/repository/sessions"
+	service "github.com/darkjedidj/cinema-service/internal/service/sessions"
 	"github.com/darkjedidj/cinema-service/test"
 )
 
@@ -52,6 +53,21 @@ func TestCreate(t *testing.T) {
 			id:             4,
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name: "failure: hall is busy",
+			mockService: &test.MockService{
+				ExpectedError: &service.Conflict{Sessions: []*movie.Resource{
+					{ID: 3, Hall_id: 4, Movie_id: 1, Starts_at: "2022-01-01 07:00:00", Ends_at: "2022-01-01 09:00:00"},
+				}},
+			},
+			body: `{
+				"Hall_id":  4,
+				"Movie_id": 2,
+				"Starts_at": "2022-01-01 08:00:00"
+			},`,
+			id:             4,
+			expectedStatus: http.StatusConflict,
+		},
 		{
 			name: "failure: DB error",
 			mockService: &test.MockService{
@@ -180,11 +196,9 @@ func TestRetrieveAll(t *testing.T) {
 		expectedStatus int
 	}{
 		{
-			name: "failure: no rows",
-			mockService: &test.MockService{
-				ExpectedArray: nil,
-			},
-			expectedStatus: http.StatusNotFound,
+			name:           "success: empty list",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "success",
@@ -233,6 +247,91 @@ func TestRetrieveAll(t *testing.T) {
 	}
 }
 
+func TestUpdate(t *testing.T) {
+	testUpdateCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "success: put",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 15, Hall_id: 2, Movie_id: 3, Starts_at: "2022-04-01 18:00:00"},
+			},
+			method:         http.MethodPut,
+			body:           `{"hall_id": 2, "movie_id": 3, "Starts_at": "2022-04-01 18:00:00"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "success: patch",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 15, Hall_id: 2, Movie_id: 3, Starts_at: "2022-04-01 18:00:00"},
+			},
+			method:         http.MethodPatch,
+			body:           `{"Starts_at": "2022-04-01 20:00:00"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: overlap",
+			mockService: &test.MockService{
+				ExpectedError: &service.Conflict{Sessions: []*movie.Resource{{ID: 7, Hall_id: 2}}},
+			},
+			method:         http.MethodPut,
+			body:           `{"hall_id": 2, "movie_id": 3, "Starts_at": "2022-04-01 18:00:00"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: hall with sold tickets",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrTicketsSold,
+			},
+			method:         http.MethodPut,
+			body:           `{"hall_id": 4, "movie_id": 3, "Starts_at": "2022-04-01 18:00:00"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: missing hall",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: hall does not exist", internal.ErrValidationFailed),
+			},
+			method:         http.MethodPut,
+			body:           `{"hall_id": 40, "movie_id": 3, "Starts_at": "2022-04-01 18:00:00"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testUpdateCases {
+
+		logger, err := zap.NewProduction()
+		if err != nil {
+			log.Fatalf("can't initialize zap logger: %v", err)
+		}
+
+		defer func() {
+			if err := logger.Sync(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "/15", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, map[string]string{"id": "15"})
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).HandleID(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+		})
+	}
+}
+
 func TestDelete(t *testing.T) {
 	testDeleteCases := []struct {
 		name           string
@@ -257,6 +356,14 @@ func TestDelete(t *testing.T) {
 			id:             15,
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "failure: session changed before delete",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			id:             15,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 	}
 	for _, tc := range testDeleteCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -291,3 +398,164 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestSchedule(t *testing.T) {
+	planned := &service.Schedule{Dry_run: true, Slots: []*service.Slot{
+		{Session: &movie.Resource{Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 08:00:00"}},
+	}}
+
+	testScheduleCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "success: dry run",
+			mockService: &test.MockService{
+				ExpectedResult: planned,
+			},
+			method:         http.MethodPost,
+			body:           `{"movie_id": 2, "halls": [4], "from": "2022-01-01", "to": "2022-01-01", "times": ["08:00"], "dry_run": true}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `"dry_run":true`,
+		},
+		{
+			name:           "failure: empty body",
+			mockService:    &test.MockService{},
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			method:         http.MethodPost,
+			body:           `{"movie_id": 2, "halls": [4], "from": "2022-01-01", "to": "2022-01-01"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: hall is busy",
+			mockService: &test.MockService{
+				ExpectedError: &service.Conflict{
+					Sessions: []*movie.Resource{{ID: 3, Hall_id: 4, Movie_id: 1, Starts_at: "2022-01-01 07:00:00"}},
+					Schedule: planned,
+				},
+			},
+			method:         http.MethodPost,
+			body:           `{"movie_id": 2, "halls": [4], "from": "2022-01-01", "to": "2022-01-01", "times": ["08:00"]}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   `"sessions":[{"ID":3`,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			method:         http.MethodPost,
+			body:           `{"movie_id": 2, "halls": [4], "from": "2022-01-01", "to": "2022-01-01", "times": ["08:00"]}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "failure: wrong method",
+			mockService:    &test.MockService{},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testScheduleCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/schedules", strings.NewReader(tc.body))
+
+			r.Header.Set("Content-Type", "application/json")
+
+			(&Handler{s: tc.mockService, log: logger}).Schedule(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tc.expectedBody)
+		})
+	}
+}
+
+func TestShowtimes(t *testing.T) {
+	testShowtimesCases := []struct {
+		name           string
+		mockService    *test.MockService
+		query          string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{&movie.Showtime{ID: 15, Movie_ID: 2, Hall_ID: 4, Capacity: 50, Remaining: 12}},
+			},
+			query:          "?from=2022-04-01&to=2022-04-07&movie_id=2&vip=true&available=true&limit=10",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failure: bad limit",
+			mockService:    &test.MockService{},
+			query:          "?limit=0",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: validation error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			query:          "?from=tomorrow",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testShowtimesCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/showtimes"+tc.query, nil)
+
+			(&Handler{s: tc.mockService, log: logger}).Showtimes(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
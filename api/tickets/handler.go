@@ -12,16 +12,21 @@ import (
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 
+	"github.com/darkjedidj/cinema-service/api/auth"
 	"github.com/darkjedidj/cinema-service/internal"
+	order "github.com/darkjedidj/cinema-service/internal/repository/orders"
 	repo "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/internal/service/orders"
 	service "github.com/darkjedidj/cinema-service/internal/service/tickets"
+	"github.com/darkjedidj/cinema-service/package/etag"
 	g "github.com/darkjedidj/cinema-service/package/generator"
 )
 
 type Handler struct {
-	s   internal.Service // Allows use service features
-	log *zap.Logger
-	gen g.Client
+	s      internal.TicketService // Allows use service features
+	orders internal.OrderService  // Buys tickets, they are issued once payment is captured
+	log    *zap.Logger
+	gen    g.Client
 }
 
 func Init(db *sql.DB, l *zap.Logger) *Handler {
@@ -30,9 +35,10 @@ func Init(db *sql.DB, l *zap.Logger) *Handler {
 	generator := g.Init(db, l)
 
 	return &Handler{
-		s:   service,
-		log: l,
-		gen: *generator,
+		s:      service,
+		orders: orders.Init(db, l),
+		log:    l,
+		gen:    *generator,
 	}
 }
 
@@ -64,7 +70,7 @@ func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
 // Create godoc
 // @Security     ApiKeyAuth
 // @Summary      Create ticket
-// @Description  Creates ticket and returns created object
+// @Description  Buys ticket for authorized user in single ticket order priced by pricing rules, ticket is reserved until order payment is captured
 // @Tags      Tickets
 // @Param        id    path  integer        true  "ticket ID"
 // @Param        Body  body  repo.Resource  true  "The body to create a ticket"
@@ -72,6 +78,9 @@ func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
 // @Produce   json
 // @Success      200  {object}  repo.Resource
 // @Failure   400
+// @Failure   402
+// @Failure   403
+// @Failure   409
 // @Failure   422
 // @Failure   500
 // @Failure   401
@@ -96,6 +105,12 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 
 	response.Header().Set("Content-Type", "application/json")
 
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
 	err = json.NewDecoder(request.Body).Decode(&ticket)
 	if err != nil {
 		h.log.Info("Failed to decode ticket json.",
@@ -107,13 +122,57 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 	}
 	defer request.Body.Close()
 
+	if ticket.User_ID != 0 {
+		response.WriteHeader(http.StatusBadRequest)
+
+		_, err = response.Write([]byte("user id is taken from access token"))
+		if err != nil {
+			h.log.Info("Failed to write ticket response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	ticket.User_ID = principal.User_ID
 	ticket.Session_ID = int64(id)
-	resource, err := h.s.Create(&ticket, ctx)
+
+	bought := &order.Resource{User_ID: principal.User_ID, Promo_Code: ticket.Promo_Code, Tickets: []*repo.Resource{&ticket}}
+	ticket.Promo_Code = ""
+
+	resource, err := h.orders.Create(bought, ctx)
 	if err != nil {
 
+		if errors.Is(err, internal.ErrPaymentFailed) {
+			response.WriteHeader(http.StatusPaymentRequired)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write ticket response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
 		if errors.Is(err, internal.ErrNoSeats) {
 			response.WriteHeader(http.StatusBadRequest)
 
+			_, err = response.Write([]byte(err.Error() + ", join session waitlist to get freed seats"))
+			if err != nil {
+				h.log.Info("Failed to write ticket response.",
+					zap.Error(err),
+				)
+
+				response.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		if errors.Is(err, internal.ErrAgeRestricted) {
+			response.WriteHeader(http.StatusForbidden)
+
 			_, err = response.Write([]byte(err.Error()))
 			if err != nil {
 				h.log.Info("Failed to write ticket response.",
@@ -126,7 +185,22 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 			return
 		}
 
-		if errors.Is(err, internal.ErrValidationFailed) {
+		if errors.Is(err, internal.ErrSeatTaken) || errors.Is(err, internal.ErrHoldInvalid) {
+			response.WriteHeader(http.StatusConflict)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write ticket response.",
+					zap.Error(err),
+				)
+
+				response.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		if errors.Is(err, internal.ErrValidationFailed) || errors.Is(err, internal.ErrPromoInvalid) {
 			response.WriteHeader(http.StatusBadRequest)
 
 			_, err = response.Write([]byte(err.Error()))
@@ -146,7 +220,17 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	body, err := json.Marshal(resource)
+	res, ok := resource.(*order.Resource)
+	if !ok || len(res.Tickets) != 1 {
+		h.log.Info("Failed to assert order object.",
+			zap.Bool("ok", ok),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	body, err := json.Marshal(res.Tickets[0])
 	if err != nil {
 		h.log.Info("Failed to marshall ticket structure.",
 			zap.Error(err),
@@ -172,13 +256,15 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 // Delete godoc
 // @Security     ApiKeyAuth
 // @Summary      Delete ticket
-// @Description  Deletes ticket
+// @Description  Refunds full ticket price and keeps ticket as refunded
 // @Param     id  path  integer  true  "ticket ID"
+// @Param     If-Match  header  string  false  "ETag of ticket to delete"
 // @Tags         Tickets
 // @Accept       json
 // @Produce      json
 // @Success      200
 // @Failure      400
+// @Failure      412
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -199,9 +285,20 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	err = h.s.Delete(int64(id), ctx)
+	version, ok := h.precondition(response, request, int64(id), 0, ctx)
+	if !ok {
+		return
+	}
+
+	err = h.s.DeleteVersion(int64(id), version, ctx)
+	if errors.Is(err, internal.ErrVersionMismatch) {
+		response.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
 	if err != nil {
 		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
 	}
 
 	response.WriteHeader(http.StatusOK)
@@ -213,10 +310,13 @@ func (h *Handler) Delete(response http.ResponseWriter, request *http.Request) {
 // @Summary      Get ticket
 // @Description  Gets ticket
 // @Param        id  path  integer  true  "ticket ID"
+// @Param        If-None-Match  header  string  false  "ETag of cached ticket"
 // @Tags         Tickets
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of ticket"
+// @Success      304
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -251,6 +351,14 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	tag := etag.Of(resource)
+	response.Header().Set("ETag", tag)
+
+	if etag.Fresh(request.Header.Get("If-None-Match"), tag) {
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	body, err := json.Marshal(resource)
 	if err != nil {
 		h.log.Info("Failed to marshall ticket structure.",
@@ -280,7 +388,13 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 // @Tags         Tickets
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}  []repo.Resource
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, user_id, session_id, status; prefixed by minus for descending order"
+// @Param        user_id  query  int  false  "User ID"
+// @Param        session_id  query  int  false  "Session ID"
+// @Param        status  query  string  false  "Ticket status"
+// @Success      200  {object}  internal.Page
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -292,17 +406,369 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 
 	response.Header().Set("Content-Type", "application/json")
 
-	resource, err := h.s.RetrieveAll(ctx)
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
+	}
+
 	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write ticket response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
 		response.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall ticket structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write ticket response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Seats gets ID and selects seat availability of session with the same ID
+// Seats godoc
+// @Summary      Session seats
+// @Description  Gets availability map of session seats
+// @Param        id  path  integer  true  "Session ID"
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.SeatMap
+// @Failure      400
+// @Failure      404
+// @Failure      422
+// @Failure      500
+// @Router       /sessions/{id}/seats [get]
+func (h *Handler) Seats(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse session id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.RetrieveSeats(int64(id), ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall seats structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write seats response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Mine selects tickets of authorized user
+// Mine godoc
+// @Security     ApiKeyAuth
+// @Summary      My tickets
+// @Description  Lists tickets of authorized user with movie, hall, seat and start time
+// @Param        when  query  string  false  "upcoming or past"
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, starts_at; prefixed by minus for descending order. Session start, latest first by default, soonest first for upcoming tickets"
+// @Param        session_id  query  int  false  "Session ID"
+// @Param        status  query  string  false  "Ticket status"
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  internal.Page
+// @Failure      400
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /me/tickets [get]
+func (h *Handler) Mine(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodGet {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveByUser(principal.User_ID, query, ctx)
+	}
+
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write ticket response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall ticket structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write ticket response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Cancel gets ID and refunds ticket of authorized user by refund policy
+// Cancel godoc
+// @Security     ApiKeyAuth
+// @Summary      Cancel ticket
+// @Description  Refunds own ticket before cutoff, refund amount depends on time left to session start
+// @Param        id  path  integer  true  "Ticket ID"
+// @Param        If-Match  header  string  false  "ETag of ticket to refund"
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of refunded ticket"
+// @Failure      400
+// @Failure      402
+// @Failure      404
+// @Failure      409
+// @Failure      412
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /me/tickets/{id}/refund [post]
+func (h *Handler) Cancel(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodPost {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse ticket id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	version, ok := h.precondition(response, request, int64(id), principal.User_ID, ctx)
+	if !ok {
+		return
+	}
+
+	resource, err := h.s.Cancel(int64(id), principal.User_ID, version, ctx)
+	h.writeTicket(response, resource, err)
+}
+
+// Refund gets ID and json with reason and refunds full ticket price
+// Refund godoc
+// @Security     ApiKeyAuth
+// @Summary      Force refund ticket
+// @Description  Refunds full ticket price regardless of refund policy and records reason
+// @Param        id    path  integer  true  "Ticket ID"
+// @Param        If-Match  header  string  false  "ETag of ticket to refund"
+// @Param        Body  body  Reason   true  "Refund reason"
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of refunded ticket"
+// @Failure      400
+// @Failure      402
+// @Failure      404
+// @Failure      409
+// @Failure      412
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /tickets/{id}/refund [post]
+func (h *Handler) Refund(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodPost {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse ticket id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var reason Reason
+
+	err = json.NewDecoder(request.Body).Decode(&reason)
+	if err != nil {
+		h.log.Info("Failed to decode refund json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	version, ok := h.precondition(response, request, int64(id), 0, ctx)
+	if !ok {
+		return
+	}
+
+	resource, err := h.s.Refund(int64(id), reason.Reason, version, ctx)
+	h.writeTicket(response, resource, err)
+}
+
+// Reason is a body of forced refund
+type Reason struct {
+	Reason string `json:"reason"`
+}
+
+// writeTicket writes ticket resource or maps ticket lifecycle error to status
+func (h *Handler) writeTicket(response http.ResponseWriter, resource internal.Identifiable, err error) {
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+
+		switch {
+		case errors.Is(err, internal.ErrValidationFailed), errors.Is(err, internal.ErrCodeInvalid):
+			status = http.StatusBadRequest
+		case errors.Is(err, internal.ErrRefunded), errors.Is(err, internal.ErrRefundClosed),
+			errors.Is(err, internal.ErrTicketStatus), errors.Is(err, internal.ErrCheckedIn),
+			errors.Is(err, internal.ErrWrongSession), errors.Is(err, internal.ErrAdmissionClosed):
+			status = http.StatusConflict
+		case errors.Is(err, internal.ErrPaymentFailed):
+			status = http.StatusPaymentRequired
+		case errors.Is(err, internal.ErrVersionMismatch):
+			status = http.StatusPreconditionFailed
+		default:
+			response.WriteHeader(status)
+			return
+		}
+
+		response.WriteHeader(status)
+
+		_, err = response.Write([]byte(err.Error()))
+		if err != nil {
+			h.log.Info("Failed to write ticket response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
 	if resource == nil {
 		response.WriteHeader(http.StatusNotFound)
 		return
 	}
 
+	response.Header().Set("ETag", etag.Of(resource))
+
 	body, err := json.Marshal(resource)
 	if err != nil {
 		h.log.Info("Failed to marshall ticket structure.",
@@ -324,6 +790,229 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// CheckIn admits ticket holder at session door
+// CheckIn godoc
+// @Security  ApiKeyAuth
+// @Summary      Check ticket in
+// @Description  Admits ticket holder to session. Rejects tickets already checked in, tickets for another session and sessions with closed doors.
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Param        id  path  integer  true  "ticket ID"
+// @Param        If-Match  header  string  false  "ETag of ticket to check in"
+// @Param        body  body  Admission  true  "Session at the door"
+// @Success      200  {object}  repo.Resource
+// @Header       200  {string}  ETag  "Version of checked in ticket"
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      409
+// @Failure      412
+// @Failure      422
+// @Failure      500
+// @Router       /tickets/{id}/checkin [post]
+func (h *Handler) CheckIn(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodPost {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse ticket id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var admission Admission
+
+	err = json.NewDecoder(request.Body).Decode(&admission)
+	if err != nil {
+		h.log.Info("Failed to decode admission json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	version, ok := h.precondition(response, request, int64(id), 0, ctx)
+	if !ok {
+		return
+	}
+
+	resource, err := h.s.CheckIn(int64(id), admission.Session_ID, version, ctx)
+	h.writeTicket(response, resource, err)
+}
+
+// Admission is a body of ticket check in
+type Admission struct {
+	Session_ID int64 `json:"session_id"`
+}
+
+// Code gets ID and returns signed validation code of authorized user ticket
+// Code godoc
+// @Security     ApiKeyAuth
+// @Summary      Get ticket code
+// @Description  Returns signed code to render as QR, door scanners verify it offline until admission closes
+// @Param        id  path  integer  true  "Ticket ID"
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Code
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      422
+// @Failure      401
+// @Router       /me/tickets/{id}/code [get]
+func (h *Handler) Code(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodGet {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse ticket id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Code(int64(id), principal.User_ID, ctx)
+	h.writeTicket(response, resource, err)
+}
+
+// Verify handles ticket code verification endpoints
+func (h *Handler) Verify(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodGet:
+		h.VerifyKey(response, request) // GET BASE_URL/v1/tickets/verify
+	case http.MethodPost:
+		h.VerifyCode(response, request) // POST BASE_URL/v1/tickets/verify
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// VerifyCode checks signed ticket code
+// VerifyCode godoc
+// @Security     ApiKeyAuth
+// @Summary      Verify ticket code
+// @Description  Checks code signature and expiry, returns ticket when it can be admitted
+// @Tags         Tickets
+// @Accept       json
+// @Produce      json
+// @Param        body  body  Verification  true  "Scanned code"
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      409
+// @Failure      422
+// @Router       /tickets/verify [post]
+func (h *Handler) VerifyCode(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	var verification Verification
+
+	err := json.NewDecoder(request.Body).Decode(&verification)
+	if err != nil {
+		h.log.Info("Failed to decode verification json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	resource, err := h.s.Verify(verification.Code, ctx)
+	h.writeTicket(response, resource, err)
+}
+
+// VerifyKey returns public key for offline scanners
+// VerifyKey godoc
+// @Security     ApiKeyAuth
+// @Summary      Get ticket code verification key
+// @Description  Returns base64 Ed25519 public key door scanners use to verify codes offline
+// @Tags         Tickets
+// @Produce      json
+// @Success      200  {object}  VerificationKey
+// @Failure      401
+// @Failure      422
+// @Router       /tickets/verify [get]
+func (h *Handler) VerifyKey(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+
+	key, err := h.s.VerifyKey()
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	body, err := json.Marshal(VerificationKey{Key: key})
+	if err != nil {
+		h.log.Info("Failed to marshall key structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write key response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Verification is a body of ticket code verification
+type Verification struct {
+	Code string `json:"code"`
+}
+
+// VerificationKey is a public key of ticket codes
+type VerificationKey struct {
+	Key string `json:"key"`
+}
+
 // Download bought ticket
 // Download godoc
 // @Security  ApiKeyAuth
@@ -334,6 +1023,8 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 // @Produce      json
 // @Success   200  {object}  g.Link
 // @Failure      400
+// @Failure      404
+// @Failure      409
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -354,13 +1045,37 @@ func (h *Handler) Download(response http.ResponseWriter, request *http.Request)
 		return
 	}
 
-	url, err := h.gen.GetTicket(ctx, int64(id))
+	resource, err := h.s.Code(int64(id), 0, ctx)
+	if err != nil || resource == nil {
+		h.writeTicket(response, resource, err)
+		return
+	}
+
+	code, ok := resource.(*repo.Code)
+	if !ok {
+		h.log.Info("Failed to assert ticket code object.",
+			zap.Bool("ok", ok),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	url, err := h.gen.GetTicket(ctx, int64(id), code.Code)
 	if err != nil {
 		h.log.Info("Failed to get ticket fron bucket.",
 			zap.Error(err),
 		)
 
 		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = h.s.Issue(int64(id), ctx)
+	if err != nil {
+		h.log.Info("Failed to mark ticket as issued.",
+			zap.Error(err),
+		)
 	}
 
 	bf := bytes.NewBuffer([]byte{})
@@ -387,3 +1102,40 @@ func (h *Handler) Download(response http.ResponseWriter, request *http.Request)
 
 	response.WriteHeader(http.StatusOK)
 }
+
+// precondition checks If-Match header against current ticket and writes 412 when client
+// changes stale copy. Tickets of other users than owner, if set, are treated as missing.
+// It returns ticket version client expects, zero without header.
+func (h *Handler) precondition(response http.ResponseWriter, request *http.Request, id int64, owner int64, ctx context.Context) (int64, bool) {
+	match := request.Header.Get("If-Match")
+	if match == "" {
+		return 0, true
+	}
+
+	current, err := h.s.Retrieve(id, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	ticket, ok := current.(*repo.Resource)
+	if ok && owner != 0 && ticket.User_ID != owner {
+		current = nil
+	}
+
+	if !etag.Match(match, etag.Of(current)) {
+		response.WriteHeader(http.StatusPreconditionFailed)
+		return 0, false
+	}
+
+	if !ok {
+		h.log.Info("Failed to assert ticket object.",
+			zap.Bool("ok", ok),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return 0, false
+	}
+
+	return ticket.Version, true
+}
@@ -12,7 +12,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
+	"github.com/darkjedidj/cinema-service/api/auth"
 	"github.com/darkjedidj/cinema-service/internal"
+	order "github.com/darkjedidj/cinema-service/internal/repository/orders"
 	movie "github.com/darkjedidj/cinema-service/internal/repository/tickets"
 	"github.com/darkjedidj/cinema-service/test"
 )
@@ -23,35 +25,84 @@ func TestCreate(t *testing.T) {
 		mockService    *test.MockService
 		body           string
 		id             int64
+		anonymous      bool
 		expectedStatus int
 	}{
 		{
-			name: "failure: empty body",
+			name: "failure: unauthenticated",
 			mockService: &test.MockService{
 				ExpectedResult: &movie.Resource{
-					Starts_at:  "13:25",
-					Price:      12.2,
-					Seat:       1,
 					ID:         1,
-					Title:      "Matrix",
 					User_ID:    1,
-					Session_ID: 1,
+					Session_ID: 4,
 				},
 			},
+			body: `{
+				"Row": 1,
+				"Number": 1,
+				"Hold": "f00d"
+			}`,
 			id:             4,
-			expectedStatus: http.StatusBadRequest,
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
 		},
 		{
-			name: "success",
+			name: "failure: spoofed user id",
 			mockService: &test.MockService{
 				ExpectedResult: &movie.Resource{
-					Starts_at:  "13:25",
-					Price:      12.2,
-					Seat:       1,
 					ID:         1,
-					Title:      "Matrix",
-					User_ID:    1,
-					Session_ID: 1,
+					User_ID:    2,
+					Session_ID: 4,
+				},
+			},
+			body: `{
+				"User_ID": 2,
+				"Row": 1,
+				"Number": 1,
+				"Hold": "f00d"
+			}`,
+			id:             4,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: &order.Resource{
+					ID:      1,
+					User_ID: 1,
+					Status:  "paid",
+					Tickets: []*movie.Resource{{
+						Starts_at:  "13:25",
+						Price:      12.2,
+						Seat:       1,
+						ID:         1,
+						Title:      "Matrix",
+						User_ID:    1,
+						Session_ID: 1,
+						Status:     movie.Paid,
+					}},
+				},
+			},
+			id:             4,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: &order.Resource{
+					ID:      1,
+					User_ID: 1,
+					Status:  "paid",
+					Tickets: []*movie.Resource{{
+						Starts_at:  "13:25",
+						Price:      12.2,
+						Seat:       1,
+						ID:         1,
+						Title:      "Matrix",
+						User_ID:    1,
+						Session_ID: 1,
+						Status:     movie.Paid,
+					}},
 				},
 			},
 			body: `{
@@ -73,6 +124,56 @@ func TestCreate(t *testing.T) {
 			id:             4,
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "failure: seat taken",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrSeatTaken,
+			},
+			body: `{
+				"Row": 1,
+				"Number": 1
+			}`,
+			id:             4,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: hold expired",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrHoldInvalid,
+			},
+			body: `{
+				"Row": 1,
+				"Number": 1,
+				"Hold": "f00d"
+			}`,
+			id:             4,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: payment declined",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrPaymentFailed,
+			},
+			body: `{
+				"Row": 1,
+				"Number": 1,
+				"Hold": "f00d"
+			}`,
+			id:             4,
+			expectedStatus: http.StatusPaymentRequired,
+		},
+		{
+			name: "failure: seat outside of hall",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body: `{
+				"Row": 100,
+				"Number": 1
+			}`,
+			id:             4,
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 	for _, tc := range testCreateCases {
 
@@ -101,7 +202,11 @@ func TestCreate(t *testing.T) {
 
 			r.Header.Set("Content-Type", "application/json")
 
-			(&Handler{s: tc.mockService, log: logger}).Create(w, r)
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			(&Handler{orders: tc.mockService, log: logger}).Create(w, r)
 
 			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
 			assert.Equal(t, tc.expectedStatus, w.Code)
@@ -192,11 +297,9 @@ func TestRetrieveAll(t *testing.T) {
 		expectedStatus int
 	}{
 		{
-			name: "failure: no rows",
-			mockService: &test.MockService{
-				ExpectedArray: nil,
-			},
-			expectedStatus: http.StatusNotFound,
+			name:           "success: empty list",
+			mockService:    &test.MockService{},
+			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "success",
@@ -249,6 +352,83 @@ func TestRetrieveAll(t *testing.T) {
 	}
 }
 
+func TestSeats(t *testing.T) {
+	testSeatsCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		expectedStatus int
+	}{
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.SeatMap{
+					Session_ID: 1,
+					Free:       1,
+					Seats: []movie.Seat{
+						{Row: 1, Number: 1, Taken: true},
+						{Row: 1, Number: 2, Taken: false},
+					},
+				},
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: no session",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			id:             "20",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "failure: wrong id",
+			mockService:    &test.MockService{},
+			id:             "first",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testSeatsCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": tc.id,
+			}
+
+			r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("http://localhost:8085/v1/sessions/%s/seats", tc.id), nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).Seats(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
 func TestDelete(t *testing.T) {
 	testDeleteCases := []struct {
 		name           string
@@ -273,6 +453,14 @@ func TestDelete(t *testing.T) {
 			id:             15,
 			expectedStatus: http.StatusUnprocessableEntity,
 		},
+		{
+			name: "failure: ticket changed before delete",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			id:             15,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
 	}
 	for _, tc := range testDeleteCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -307,3 +495,637 @@ func TestDelete(t *testing.T) {
 		})
 	}
 }
+
+func TestMine(t *testing.T) {
+	testMineCases := []struct {
+		name           string
+		mockService    *test.MockService
+		query          string
+		anonymous      bool
+		expectedStatus int
+	}{
+		{
+			name: "failure: unauthenticated",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{},
+			},
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{&movie.Resource{
+					Starts_at:  "13:25",
+					Price:      12.2,
+					Seat:       1,
+					Row:        1,
+					Number:     1,
+					ID:         1,
+					Title:      "Matrix",
+					User_ID:    1,
+					Session_ID: 1,
+					Hall_ID:    2,
+				}},
+			},
+			query:          "?when=upcoming&limit=10&sort=-starts_at",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad limit",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{},
+			},
+			query:          "?limit=-1",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: limit too big",
+			mockService: &test.MockService{
+				ExpectedArray: []internal.Identifiable{},
+			},
+			query:          "?limit=1000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: unknown filter",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			query:          "?when=tomorrow",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testMineCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/me/tickets"+tc.query, nil)
+
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).Mine(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCancel(t *testing.T) {
+	testCancelCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		anonymous      bool
+		ifMatch        string
+		expectedStatus int
+	}{
+		{
+			name: "failure: unauthenticated",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, User_ID: 1, Status: movie.Refunded},
+			},
+			id:             "1",
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, User_ID: 1, Status: movie.Refunded},
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: bad id",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, User_ID: 1, Status: movie.Refunded},
+			},
+			id:             "first",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: not own ticket",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			id:             "2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success: current if-match",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, User_ID: 1, Status: movie.Paid, Version: 2},
+			},
+			id:             "1",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: stale if-match",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, User_ID: 1, Status: movie.Paid, Version: 2},
+			},
+			id:             "1",
+			ifMatch:        `"1"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "failure: if-match of not own ticket",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 2, User_ID: 7, Status: movie.Paid, Version: 2},
+			},
+			id:             "2",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "failure: changed after if-match check",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			id:             "1",
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name: "failure: if-match with DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			ifMatch:        `"2"`,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "failure: cutoff passed",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrRefundClosed,
+			},
+			id:             "1",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: already refunded",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrRefunded,
+			},
+			id:             "1",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCancelCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": tc.id,
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/me/tickets/"+tc.id+"/refund", nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).Cancel(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestRefund(t *testing.T) {
+	testRefundCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, Status: movie.Refunded},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, Status: movie.Refunded},
+			},
+			body:           `{"reason": "Projector is broken"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: missing reason",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{"reason": ""}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: no ticket",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			body:           `{"reason": "Projector is broken"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: already refunded",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrRefunded,
+			},
+			body:           `{"reason": "Projector is broken"}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: changed after if-match check",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			body:           `{"reason": "Projector is broken"}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+	for _, tc := range testRefundCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "1",
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/tickets/1/refund", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).Refund(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCheckIn(t *testing.T) {
+	testCheckInCases := []struct {
+		name           string
+		mockService    *test.MockService
+		body           string
+		expectedStatus int
+	}{
+		{
+			name: "failure: empty body",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, Status: movie.CheckedIn},
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, Status: movie.CheckedIn},
+			},
+			body:           `{"session_id": 1}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: missing session",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrValidationFailed,
+			},
+			body:           `{}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: no ticket",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			body:           `{"session_id": 1}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: double admission",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrCheckedIn,
+			},
+			body:           `{"session_id": 1}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: wrong session",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrWrongSession,
+			},
+			body:           `{"session_id": 2}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: doors closed",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrAdmissionClosed,
+			},
+			body:           `{"session_id": 1}`,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: internal error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			body:           `{"session_id": 1}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "failure: changed after if-match check",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrVersionMismatch,
+			},
+			body:           `{"session_id": 1}`,
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+	}
+	for _, tc := range testCheckInCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": "1",
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/tickets/1/checkin", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: tc.mockService, log: logger}).CheckIn(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCode(t *testing.T) {
+	testCodeCases := []struct {
+		name           string
+		mockService    *test.MockService
+		id             string
+		anonymous      bool
+		expectedStatus int
+	}{
+		{
+			name: "failure: unauthenticated",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Code{Ticket_ID: 1, Code: "code"},
+			},
+			id:             "1",
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "success",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Code{Ticket_ID: 1, Code: "code"},
+			},
+			id:             "1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: not own ticket",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			id:             "2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "failure: ticket was used",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrTicketStatus,
+			},
+			id:             "1",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: signing key is missing",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			id:             "1",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testCodeCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": tc.id,
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/me/tickets/"+tc.id+"/code", nil)
+
+			r = mux.SetURLVars(r, vars)
+
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).Code(w, r)
+
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	testVerifyCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "success: public key",
+			mockService:    &test.MockService{},
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"key":"key"}`,
+		},
+		{
+			name: "success: valid code",
+			mockService: &test.MockService{
+				ExpectedResult: &movie.Resource{ID: 1, Status: movie.Issued},
+			},
+			method:         http.MethodPost,
+			body:           `{"code": "code"}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failure: empty body",
+			mockService:    &test.MockService{},
+			method:         http.MethodPost,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: forged code",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrCodeInvalid,
+			},
+			method:         http.MethodPost,
+			body:           `{"code": "forged"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   internal.ErrCodeInvalid.Error(),
+		},
+		{
+			name: "failure: already checked in",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrCheckedIn,
+			},
+			method:         http.MethodPost,
+			body:           `{"code": "code"}`,
+			expectedStatus: http.StatusConflict,
+			expectedBody:   internal.ErrCheckedIn.Error(),
+		},
+		{
+			name: "failure: ticket is gone",
+			mockService: &test.MockService{
+				ExpectedResult: nil,
+			},
+			method:         http.MethodPost,
+			body:           `{"code": "code"}`,
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "failure: method not allowed",
+			mockService:    &test.MockService{},
+			method:         http.MethodDelete,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testVerifyCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/tickets/verify", strings.NewReader(tc.body))
+
+			(&Handler{s: tc.mockService, log: logger}).Verify(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -17,7 +18,7 @@ import (
 )
 
 type Handler struct {
-	s   internal.Service // Allows use service features
+	s   internal.EditableService // Allows use service features
 	log *zap.Logger
 }
 
@@ -37,6 +38,8 @@ func (h *Handler) HandleID(response http.ResponseWriter, request *http.Request)
 	switch request.Method {
 	case http.MethodGet:
 		h.Get(response, request) // GET BASE_URL/v1/user_privileges/{id}
+	case http.MethodPut, http.MethodPatch:
+		h.Update(response, request) // PUT or PATCH BASE_URL/v1/user_privileges/{id}
 	case http.MethodDelete:
 		h.Delete(response, request) // DELETE BASE_URL/v1/user_privileges/{id}
 	default:
@@ -61,13 +64,15 @@ func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
 // Create godoc
 // @Security     ApiKeyAuth
 // @Summary      Create User Privilege
-// @Description  Creates User Privilege and returns created object
+// @Description  Grants existing privilege to user and returns created object
 // @Tags         User Privileges
 // @Param        Body  body  repo.Resource  true  "The body to create a User Privilege"
 // @Accept       json
 // @Produce      json
 // @Success      200  {object}  repo.Resource
 // @Failure      400
+// @Failure      404
+// @Failure      409
 // @Failure      422
 // @Failure      500
 // @Failure      401
@@ -92,7 +97,7 @@ func (h *Handler) Create(response http.ResponseWriter, request *http.Request) {
 
 	resource, err := h.s.Create(&user_privilege, ctx)
 	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
+		h.writeError(response, err)
 		return
 	}
 
@@ -222,6 +227,112 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 	}
 }
 
+// Update replaces User Privilege with PUT or changes only passed fields with PATCH
+// Update godoc
+// @Security     ApiKeyAuth
+// @Summary      Update User Privilege
+// @Description  PUT replaces User Privilege, PATCH changes only fields present in body
+// @Tags         User Privileges
+// @Param        id  path  integer  true  "User Privilege ID"
+// @Param        Body  body  repo.Resource  true  "The body to update a User Privilege"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      404
+// @Failure      409
+// @Failure      422
+// @Failure      500
+// @Failure      401
+// @Router       /user_privileges/{id} [put]
+// @Router       /user_privileges/{id} [patch]
+func (h *Handler) Update(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var user_privilege repo.Resource
+
+	response.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse User Privilege id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if request.Method == http.MethodPatch {
+		current, err := h.s.Retrieve(int64(id), ctx)
+		if err != nil {
+			response.WriteHeader(http.StatusUnprocessableEntity)
+			return
+		}
+
+		if current == nil {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		res, ok := current.(*repo.Resource)
+		if !ok {
+			h.log.Info("Failed to assert user_privilege object.",
+				zap.Bool("ok", ok),
+			)
+
+			response.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		user_privilege = *res
+	}
+
+	err = json.NewDecoder(request.Body).Decode(&user_privilege)
+	if err != nil {
+		h.log.Info("Failed to decode user_privilege json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := h.s.Update(int64(id), &user_privilege, ctx)
+	if err != nil {
+		h.writeError(response, err)
+		return
+	}
+
+	if resource == nil {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall user_privilege structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write user_privilege response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 // GetAll selects all User Privileges
 // GetAll godoc
 // @Security     ApiKeyAuth
@@ -230,7 +341,12 @@ func (h *Handler) Get(response http.ResponseWriter, request *http.Request) {
 // @Tags         User Privileges
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}  []repo.Resource
+// @Param        limit  query  int  false  "Page size, 20 by default and 100 at most"
+// @Param        cursor  query  string  false  "Next cursor of previous page"
+// @Param        sort  query  string  false  "Sort field: id, user_id, privilege_id; prefixed by minus for descending order"
+// @Param        user_id  query  int  false  "User ID"
+// @Param        privilege_id  query  int  false  "Privilege ID"
+// @Success      200  {object}  internal.Page
 // @Failure      400
 // @Failure      422
 // @Failure      500
@@ -242,14 +358,28 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 
 	response.Header().Set("Content-Type", "application/json")
 
-	resource, err := h.s.RetrieveAll(ctx)
-	if err != nil {
-		response.WriteHeader(http.StatusUnprocessableEntity)
-		return
+	var resource *internal.Page
+
+	query, err := internal.ParseQuery(request.URL.Query())
+	if err == nil {
+		resource, err = h.s.RetrieveAll(query, ctx)
 	}
 
-	if resource == nil {
-		response.WriteHeader(http.StatusNotFound)
+	if err != nil {
+
+		if errors.Is(err, internal.ErrValidationFailed) {
+			response.WriteHeader(http.StatusBadRequest)
+
+			_, err = response.Write([]byte(err.Error()))
+			if err != nil {
+				h.log.Info("Failed to write user_privilege response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
@@ -273,3 +403,29 @@ func (h *Handler) GetAll(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 }
+
+// writeError maps service error to response status
+func (h *Handler) writeError(response http.ResponseWriter, err error) {
+	status := http.StatusUnprocessableEntity
+
+	switch {
+	case errors.Is(err, internal.ErrValidationFailed):
+		status = http.StatusBadRequest
+	case errors.Is(err, internal.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, internal.ErrPrivilegeGranted):
+		status = http.StatusConflict
+	default:
+		response.WriteHeader(status)
+		return
+	}
+
+	response.WriteHeader(status)
+
+	_, err = response.Write([]byte(err.Error()))
+	if err != nil {
+		h.log.Info("Failed to write user_privilege response.",
+			zap.Error(err),
+		)
+	}
+}
@@ -7,18 +7,17 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
-	"strings"
 
+	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/darkjedidj/cinema-service/api/auth"
 	"github.com/darkjedidj/cinema-service/internal"
 	repo "github.com/darkjedidj/cinema-service/internal/repository/users"
 	user "github.com/darkjedidj/cinema-service/internal/service/user"
 	e "github.com/darkjedidj/cinema-service/package"
 	tkn "github.com/darkjedidj/cinema-service/package/jwt"
-	"github.com/dgrijalva/jwt-go"
-	"github.com/gorilla/mux"
 )
 
 type Handler struct {
@@ -36,108 +35,6 @@ func Init(db *sql.DB, l *zap.Logger) *Handler {
 	}
 }
 
-// CheckPrivileges of user
-func (h *Handler) CheckPrivileges(route string, next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		header := r.Header.Get("Authorization")
-		if len(header) == 0 {
-			w.WriteHeader(http.StatusUnauthorized)
-			_, err := w.Write([]byte("Missing Authorization Header"))
-			if err != nil {
-				h.log.Info("Failed to write ticket response.",
-					zap.Error(err),
-				)
-
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			return
-		}
-
-		header = strings.Replace(header, "Bearer ", "", 1)
-
-		claims, err := tkn.VerifyToken(header)
-		if err != nil {
-			h.log.Info("Failed to verify token.",
-				zap.Error(err),
-			)
-		}
-
-		id := claims.(jwt.MapClaims)["ID"].(float64)
-		privileges, err := h.s.RetrievePrivileges(int64(id))
-		if err != nil {
-			h.log.Info("Failed to get privileges.",
-				zap.Error(err),
-			)
-		}
-
-		for _, s := range privileges {
-			if s == route {
-				next(w, r)
-			}
-		}
-
-		w.WriteHeader(http.StatusUnauthorized)
-	}
-}
-
-// CheckTicket to download for user
-func (h *Handler) CheckTicket(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-
-		vars := mux.Vars(r)
-
-		ticket, err := strconv.Atoi(vars["id"])
-		if err != nil {
-			h.log.Info("Failed to parse ticket id.",
-				zap.Error(err),
-			)
-
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-
-		header := r.Header.Get("Authorization")
-		if len(header) == 0 {
-			w.WriteHeader(http.StatusUnauthorized)
-			_, err := w.Write([]byte("Missing Authorization Header"))
-			if err != nil {
-				h.log.Info("Failed to write ticket response.",
-					zap.Error(err),
-				)
-
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			return
-		}
-
-		header = strings.Replace(header, "Bearer ", "", 1)
-
-		claims, err := tkn.VerifyToken(header)
-		if err != nil {
-			h.log.Info("Failed to verify token.",
-				zap.Error(err),
-			)
-		}
-
-		id := claims.(jwt.MapClaims)["ID"].(float64)
-
-		privileges, err := h.s.RetrieveTickets(int64(ticket), int64(id))
-		if err != nil {
-			h.log.Info("Failed to get privileges.",
-				zap.Error(err),
-			)
-		}
-
-		if privileges {
-			next(w, r)
-		}
-
-		w.WriteHeader(http.StatusUnauthorized)
-	}
-}
-
 // Signup
 // Signup godoc
 // @Summary      Signup
@@ -217,8 +114,9 @@ func (h *Handler) Signup(response http.ResponseWriter, request *http.Request) {
 // @Param        Body  body  repo.Resource  true  "Email and password"
 // @Accept       json
 // @Produce      json
-// @Success      200  {object}  string
+// @Success      200  {object}  user.Tokens
 // @Failure      400
+// @Failure      401
 // @Failure      422
 // @Failure      500
 // @Router       /signin [post]
@@ -298,15 +196,209 @@ func (h *Handler) Signin(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
-	jwtToken, err := tkn.GenerateJWT(resource.GID())
+	tokens, err := h.s.Login(resource.GID(), ctx)
 	if err != nil {
 		response.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	_, err = response.Write([]byte(`{"token":"` + jwtToken + `"}`))
+	err = json.NewEncoder(response).Encode(tokens)
+	if err != nil {
+		h.log.Info("Failed to encode user tokens.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Refresh
+// Refresh godoc
+// @Summary      Refresh tokens
+// @Description  Exchange refresh token for new access and refresh tokens, reused refresh token revokes the session
+// @Tags         Users
+// @Param        Body  body  user.Tokens  true  "Refresh token"
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  user.Tokens
+// @Failure      400
+// @Failure      401
+// @Failure      500
+// @Router       /token/refresh [post]
+func (h *Handler) Refresh(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var body user.Tokens
+
+	err := json.NewDecoder(request.Body).Decode(&body)
+	if err != nil || body.Refresh_token == "" {
+		h.log.Info("Failed to decode refresh token json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.s.Refresh(body.Refresh_token, ctx)
+	if err != nil {
+		if errors.Is(err, internal.ErrRefreshInvalid) || errors.Is(err, internal.ErrRefreshReused) {
+			response.WriteHeader(http.StatusUnauthorized)
+
+			_, err = response.Write([]byte(`{"message":"` + err.Error() + `"}`))
+			if err != nil {
+				h.log.Info("Failed to write user response.",
+					zap.Error(err),
+				)
+			}
+			return
+		}
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	err = json.NewEncoder(response).Encode(tokens)
+	if err != nil {
+		h.log.Info("Failed to encode user tokens.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// Logout
+// Logout godoc
+// @Security     ApiKeyAuth
+// @Summary      Logout
+// @Description  Revoke session of access token, its access and refresh tokens stop working
+// @Tags         Users
+// @Success      204
+// @Failure      401
+// @Failure      500
+// @Router       /logout [post]
+func (h *Handler) Logout(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	err := h.s.Logout(principal.Session_ID, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// BirthDate
+// BirthDate godoc
+// @Security     ApiKeyAuth
+// @Summary      Verify birth date
+// @Description  Records birth date staff checked with document of user, age rated tickets need it
+// @Tags         Users
+// @Param        id    path  integer             true  "User ID"
+// @Param        Body  body  user.Verification  true  "Birth date from document"
+// @Accept       json
+// @Produce      json
+// @Success      204
+// @Failure      400
+// @Failure      401
+// @Failure      403
+// @Failure      404
+// @Failure      422
+// @Router       /users/{id}/birth_date [put]
+func (h *Handler) BirthDate(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if request.Method != http.MethodPut {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse user id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var verification user.Verification
+
+	err = json.NewDecoder(request.Body).Decode(&verification)
+	if err != nil {
+		h.log.Info("Failed to decode birth date json.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer request.Body.Close()
+
+	err = h.s.VerifyBirthDate(int64(id), &verification, ctx)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+
+		switch {
+		case errors.Is(err, internal.ErrValidationFailed):
+			status = http.StatusBadRequest
+		case errors.Is(err, internal.ErrNotFound):
+			status = http.StatusNotFound
+		default:
+			response.WriteHeader(status)
+			return
+		}
+
+		response.WriteHeader(status)
+
+		_, err = response.Write([]byte(`{"message":"` + err.Error() + `"}`))
+		if err != nil {
+			h.log.Info("Failed to write user response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	response.WriteHeader(http.StatusNoContent)
+}
+
+// JWKS
+// JWKS godoc
+// @Summary      Token verification keys
+// @Description  Public keys access tokens are signed with, identified by kid header of tokens
+// @Tags         Users
+// @Produce      json
+// @Success      200  {object}  tkn.JWKS
+// @Failure      500
+// @Router       /.well-known/jwks.json [get]
+func (h *Handler) JWKS(response http.ResponseWriter, request *http.Request) {
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Cache-Control", "public, max-age=300")
+
+	err := json.NewEncoder(response).Encode(tkn.Keys().JWKS())
 	if err != nil {
-		h.log.Info("Failed to write user response.",
+		h.log.Info("Failed to encode token keys.",
 			zap.Error(err),
 		)
 
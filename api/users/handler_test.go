@@ -0,0 +1,201 @@
+package users
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	user "github.com/darkjedidj/cinema-service/internal/service/user"
+	tkn "github.com/darkjedidj/cinema-service/package/jwt"
+)
+
+func TestRefresh(t *testing.T) {
+	testRefreshCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{
+			name:           "failure: malformed body",
+			body:           `{"refresh_token":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failure: missing refresh token",
+			body:           `{"token":"access"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testRefreshCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(http.MethodPost, "http://localhost:8085/v1/token/refresh", strings.NewReader(tc.body))
+
+			(&Handler{log: logger}).Refresh(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/.well-known/jwks.json", nil)
+
+	(&Handler{log: logger}).JWKS(w, r)
+
+	var res tkn.JWKS
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+	assert.Equal(t, tkn.Keys().JWKS(), &res)
+}
+
+func TestBirthDate(t *testing.T) {
+	born := time.Date(2004, time.May, 17, 0, 0, 0, 0, time.UTC)
+	update := regexp.QuoteMeta("UPDATE users SET birth_date = $1 WHERE id = $2")
+
+	testBirthDateCases := []struct {
+		name           string
+		method         string
+		id             string
+		body           string
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+		expectedStatus int
+	}{
+		{
+			name:   "success",
+			method: http.MethodPut,
+			id:     "7",
+			body:   `{"birth_date": "2004-05-17"}`,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WithArgs(born, 7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "failure: unknown user",
+			method: http.MethodPut,
+			id:     "8",
+			body:   `{"birth_date": "2004-05-17"}`,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WithArgs(born, 8).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "failure: malformed date",
+			method:         http.MethodPut,
+			id:             "7",
+			body:           `{"birth_date": "17.05.2004"}`,
+			prepare:        func(sqlm2 sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failure: empty body",
+			method:         http.MethodPut,
+			id:             "7",
+			prepare:        func(sqlm2 sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failure: bad id",
+			method:         http.MethodPut,
+			id:             "first",
+			body:           `{"birth_date": "2004-05-17"}`,
+			prepare:        func(sqlm2 sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "failure: wrong method",
+			method:         http.MethodGet,
+			id:             "7",
+			prepare:        func(sqlm2 sqlmock.Sqlmock) {},
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "failure: DB error",
+			method: http.MethodPut,
+			id:     "7",
+			body:   `{"birth_date": "2004-05-17"}`,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WillReturnError(fmt.Errorf("connection reset"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+	for _, tc := range testBirthDateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			tc.prepare(mock)
+
+			w := httptest.NewRecorder()
+
+			vars := map[string]string{
+				"id": tc.id,
+			}
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/users/"+tc.id+"/birth_date", strings.NewReader(tc.body))
+
+			r = mux.SetURLVars(r, vars)
+
+			(&Handler{s: *user.Init(db, logger), log: logger}).BirthDate(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package waitlist
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/api/auth"
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/waitlist"
+	service "github.com/darkjedidj/cinema-service/internal/service/waitlist"
+)
+
+type Handler struct {
+	s   internal.WaitlistService // Allows use service features
+	log *zap.Logger
+}
+
+func Init(db *sql.DB, l *zap.Logger) *Handler {
+
+	service := service.Init(db, l)
+
+	return &Handler{
+		s:   service,
+		log: l,
+	}
+}
+
+// Handle handles all endpoints on this route
+func (h *Handler) Handle(response http.ResponseWriter, request *http.Request) {
+
+	switch request.Method {
+	case http.MethodPost:
+		h.Join(response, request) // POST BASE_URL/v1/sessions/{id}/waitlist
+	case http.MethodGet:
+		h.Position(response, request) // GET BASE_URL/v1/sessions/{id}/waitlist
+	case http.MethodDelete:
+		h.Leave(response, request) // DELETE BASE_URL/v1/sessions/{id}/waitlist
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// Join puts authorized user in queue for sold out session
+// Join godoc
+// @Security     ApiKeyAuth
+// @Summary      Join waitlist
+// @Description  Queues user for sold out session, freed seats are held for queued users in turn for WAITLIST_OFFER_TTL
+// @Tags         Waitlist
+// @Param        id  path  integer  true  "Session ID"
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      401
+// @Failure      409
+// @Failure      422
+// @Router       /sessions/{id}/waitlist [post]
+func (h *Handler) Join(response http.ResponseWriter, request *http.Request) {
+	h.serve(response, request, h.s.Join)
+}
+
+// Position returns queue position or seat offer of authorized user
+// Position godoc
+// @Security     ApiKeyAuth
+// @Summary      Retrieve waitlist position
+// @Description  Returns queue position while waiting, or offered seat with hold token and offer expiry
+// @Tags         Waitlist
+// @Param        id  path  integer  true  "Session ID"
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      422
+// @Router       /sessions/{id}/waitlist [get]
+func (h *Handler) Position(response http.ResponseWriter, request *http.Request) {
+	h.serve(response, request, h.s.Position)
+}
+
+// Leave removes authorized user from session queue
+// Leave godoc
+// @Security     ApiKeyAuth
+// @Summary      Leave waitlist
+// @Description  Removes user from queue, offered seat is passed to the next user
+// @Tags         Waitlist
+// @Param        id  path  integer  true  "Session ID"
+// @Produce      json
+// @Success      200  {object}  repo.Resource
+// @Failure      400
+// @Failure      401
+// @Failure      404
+// @Failure      422
+// @Router       /sessions/{id}/waitlist [delete]
+func (h *Handler) Leave(response http.ResponseWriter, request *http.Request) {
+	h.serve(response, request, h.s.Leave)
+}
+
+// Mine returns waitlist entries of authorized user
+// Mine godoc
+// @Security     ApiKeyAuth
+// @Summary      My waitlist
+// @Description  Returns all waitlist entries of user with queue positions and seat offers
+// @Tags         Waitlist
+// @Produce      json
+// @Success      200  {array}  repo.Resource
+// @Failure      401
+// @Failure      422
+// @Router       /me/waitlist [get]
+func (h *Handler) Mine(response http.ResponseWriter, request *http.Request) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	if request.Method != http.MethodGet {
+		response.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	entries, err := h.s.Entries(principal.User_ID, ctx)
+	if err != nil {
+		response.WriteHeader(http.StatusUnprocessableEntity)
+		return
+	}
+
+	h.write(response, entries)
+}
+
+// serve runs session waitlist action for authorized user
+func (h *Handler) serve(response http.ResponseWriter, request *http.Request,
+	action func(session int64, user int64, ctx context.Context) (internal.Identifiable, error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response.Header().Set("Content-Type", "application/json")
+
+	principal, ok := auth.FromContext(request.Context())
+	if !ok {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	vars := mux.Vars(request)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		h.log.Info("Failed to parse session id.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	resource, err := action(int64(id), principal.User_ID, ctx)
+	if err != nil {
+		status := http.StatusUnprocessableEntity
+
+		switch {
+		case errors.Is(err, internal.ErrValidationFailed):
+			status = http.StatusBadRequest
+		case errors.Is(err, internal.ErrWaitlisted):
+			status = http.StatusConflict
+		default:
+			response.WriteHeader(status)
+			return
+		}
+
+		response.WriteHeader(status)
+
+		_, err = response.Write([]byte(err.Error()))
+		if err != nil {
+			h.log.Info("Failed to write waitlist response.",
+				zap.Error(err),
+			)
+		}
+		return
+	}
+
+	entry, ok := resource.(*repo.Resource)
+	if !ok {
+		response.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	h.write(response, entry)
+}
+
+// write marshals waitlist response
+func (h *Handler) write(response http.ResponseWriter, resource interface{}) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		h.log.Info("Failed to marshall waitlist structure.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, err = response.Write(body)
+	if err != nil {
+		h.log.Info("Failed to write waitlist response.",
+			zap.Error(err),
+		)
+
+		response.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
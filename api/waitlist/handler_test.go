@@ -0,0 +1,173 @@
+package waitlist
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/api/auth"
+	"github.com/darkjedidj/cinema-service/internal"
+	repo "github.com/darkjedidj/cinema-service/internal/repository/waitlist"
+	"github.com/darkjedidj/cinema-service/test"
+)
+
+var entry = &repo.Resource{
+	ID:         1,
+	Session_ID: 2,
+	User_ID:    1,
+	Status:     repo.Waiting,
+	Position:   3,
+	Created_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC),
+}
+
+func TestHandle(t *testing.T) {
+	testHandleCases := []struct {
+		name           string
+		mockService    *test.MockService
+		method         string
+		id             string
+		anonymous      bool
+		expectedStatus int
+	}{
+		{
+			name: "success: join",
+			mockService: &test.MockService{
+				ExpectedResult: entry,
+			},
+			method:         http.MethodPost,
+			id:             "2",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: unauthenticated",
+			mockService: &test.MockService{
+				ExpectedResult: entry,
+			},
+			method:         http.MethodPost,
+			id:             "2",
+			anonymous:      true,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "failure: seats left",
+			mockService: &test.MockService{
+				ExpectedError: fmt.Errorf("%w: session still has free seats", internal.ErrValidationFailed),
+			},
+			method:         http.MethodPost,
+			id:             "2",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "failure: already waiting",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrWaitlisted,
+			},
+			method:         http.MethodPost,
+			id:             "2",
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "failure: wrong id",
+			mockService: &test.MockService{
+				ExpectedResult: entry,
+			},
+			method:         http.MethodPost,
+			id:             "two",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "success: position",
+			mockService: &test.MockService{
+				ExpectedResult: entry,
+			},
+			method:         http.MethodGet,
+			id:             "2",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "failure: not waiting",
+			mockService:    &test.MockService{},
+			method:         http.MethodGet,
+			id:             "2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "success: leave",
+			mockService: &test.MockService{
+				ExpectedResult: entry,
+			},
+			method:         http.MethodDelete,
+			id:             "2",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "failure: DB error",
+			mockService: &test.MockService{
+				ExpectedError: internal.ErrInternalFailure,
+			},
+			method:         http.MethodDelete,
+			id:             "2",
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "failure: wrong method",
+			mockService:    &test.MockService{},
+			method:         http.MethodPut,
+			id:             "2",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tc := range testHandleCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+
+			r := httptest.NewRequest(tc.method, "http://localhost:8085/v1/sessions/"+tc.id+"/waitlist", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": tc.id})
+
+			if !tc.anonymous {
+				r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+			}
+
+			(&Handler{s: tc.mockService, log: logger}).Handle(w, r)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMine(t *testing.T) {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+
+	r := httptest.NewRequest(http.MethodGet, "http://localhost:8085/v1/me/waitlist", nil)
+	r = r.WithContext(auth.NewContext(r.Context(), &auth.Principal{User_ID: 1}))
+
+	(&Handler{s: &test.MockService{ExpectedArray: []internal.Identifiable{entry}}, log: logger}).Mine(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"Position":3`)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"database/sql"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -34,6 +35,10 @@ const port = ":8085"
 // @in                          header
 // @name                        Authorization
 func main() {
+	superadmin := flag.String("superadmin", os.Getenv("SUPERADMIN_EMAIL"),
+		"email of the first superadmin, signed up with SUPERADMIN_PASSWORD when missing")
+	flag.Parse()
+
 	a := server.App{}
 
 	psqlconn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"))
@@ -55,6 +60,14 @@ func main() {
 		}
 	}()
 
-	a.New(db, logger)
+	err = a.New(db, logger)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *superadmin != "" {
+		a.Bootstrap(db, logger, *superadmin, os.Getenv("SUPERADMIN_PASSWORD"))
+	}
+
 	a.Run(port)
 }
@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE public.halls
+    ADD COLUMN rows integer NOT NULL DEFAULT 1;
+
+ALTER TABLE public.tickets
+    ADD COLUMN seat_row integer NOT NULL DEFAULT 1,
+    ADD COLUMN seat_number integer NOT NULL DEFAULT 1,
+    ADD CONSTRAINT tickets_session_seat_key UNIQUE (session_id, seat);
+
+UPDATE public.tickets SET seat_number = seat;
+
+-- +goose Down
+ALTER TABLE public.tickets
+    DROP CONSTRAINT tickets_session_seat_key,
+    DROP COLUMN seat_number,
+    DROP COLUMN seat_row;
+
+ALTER TABLE public.halls
+    DROP COLUMN rows;
@@ -0,0 +1,38 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.seat_categories
+(
+    name text NOT NULL,
+    description text,
+    CONSTRAINT seat_categories_pkey PRIMARY KEY (name)
+);
+
+INSERT INTO public.seat_categories (name, description) VALUES
+    ('standard', 'Regular seat'),
+    ('premium', 'Seat with extra legroom and better view'),
+    ('love_seat', 'Double seat for two people');
+
+CREATE TABLE IF NOT EXISTS public.hall_seats
+(
+    hall_id integer NOT NULL,
+    seat_row integer NOT NULL,
+    seat_number integer NOT NULL,
+    category text NOT NULL DEFAULT 'standard',
+    wheelchair boolean NOT NULL DEFAULT false,
+    blocked boolean NOT NULL DEFAULT false,
+    aisle boolean NOT NULL DEFAULT false,
+    id SERIAL,
+    CONSTRAINT hall_seats_pkey PRIMARY KEY (id),
+    CONSTRAINT hall_seats_position_key UNIQUE (hall_id, seat_row, seat_number),
+    CONSTRAINT "FK_hall_seats_to_halls" FOREIGN KEY (hall_id)
+        REFERENCES public.halls (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_hall_seats_to_seat_categories" FOREIGN KEY (category)
+        REFERENCES public.seat_categories (name) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+-- +goose Down
+DROP TABLE public.hall_seats;
+DROP TABLE public.seat_categories;
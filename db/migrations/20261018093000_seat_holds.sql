@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.seat_holds
+(
+    token text NOT NULL,
+    session_id integer NOT NULL,
+    seat integer NOT NULL,
+    seat_row integer NOT NULL,
+    seat_number integer NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    id SERIAL,
+    CONSTRAINT seat_holds_pkey PRIMARY KEY (id),
+    CONSTRAINT seat_holds_session_seat_key UNIQUE (session_id, seat),
+    CONSTRAINT "FK_seat_holds_to_sessions" FOREIGN KEY (session_id)
+        REFERENCES public.sessions (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX seat_holds_token_idx ON public.seat_holds (token);
+CREATE INDEX seat_holds_expires_at_idx ON public.seat_holds (expires_at);
+
+-- +goose Down
+DROP TABLE public.seat_holds;
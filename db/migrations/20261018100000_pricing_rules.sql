@@ -0,0 +1,45 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.pricing_rules
+(
+    kind text NOT NULL,
+    name text NOT NULL,
+    amount real NOT NULL,
+    movie_id integer,
+    category text,
+    weekday integer,
+    starts time without time zone,
+    ends time without time zone,
+    day date,
+    id SERIAL,
+    CONSTRAINT pricing_rules_pkey PRIMARY KEY (id),
+    CONSTRAINT pricing_rules_weekday_check CHECK (weekday BETWEEN 0 AND 6),
+    CONSTRAINT "FK_pricing_rules_to_movies" FOREIGN KEY (movie_id)
+        REFERENCES public.movies (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_pricing_rules_to_seat_categories" FOREIGN KEY (category)
+        REFERENCES public.seat_categories (name) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE NO ACTION
+);
+
+INSERT INTO public.pricing_rules (kind, name, amount, category, starts, ends) VALUES
+    ('base', 'Standard ticket', 10, NULL, NULL, NULL),
+    ('vip', 'VIP hall', 5, NULL, NULL, NULL),
+    ('category', 'Premium seat', 2, 'premium', NULL, NULL),
+    ('category', 'Love seat', 4, 'love_seat', NULL, NULL),
+    ('matinee', 'Matinee', 20, NULL, '00:00', '12:00');
+
+ALTER TABLE public.tickets ADD COLUMN price_breakdown jsonb;
+
+INSERT INTO public.privileges (name) VALUES ('pricing');
+
+-- +goose Down
+DELETE FROM public.user_privileges
+    WHERE privilege_id IN (SELECT id FROM public.privileges WHERE name = 'pricing');
+
+DELETE FROM public.privileges WHERE name = 'pricing';
+
+ALTER TABLE public.tickets DROP COLUMN price_breakdown;
+
+DROP TABLE public.pricing_rules;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE public.tickets
+    ADD COLUMN status text NOT NULL DEFAULT 'paid',
+    ADD COLUMN refund_amount real,
+    ADD COLUMN refund_policy text,
+    ADD COLUMN refund_reason text,
+    ADD COLUMN refunded_at timestamp without time zone;
+
+ALTER TABLE public.tickets DROP CONSTRAINT tickets_session_seat_key;
+
+CREATE UNIQUE INDEX tickets_session_seat_key ON public.tickets (session_id, seat) WHERE status <> 'refunded';
+
+-- +goose Down
+DROP INDEX public.tickets_session_seat_key;
+
+DELETE FROM public.tickets WHERE status = 'refunded';
+
+ALTER TABLE public.tickets ADD CONSTRAINT tickets_session_seat_key UNIQUE (session_id, seat);
+
+ALTER TABLE public.tickets
+    DROP COLUMN refunded_at,
+    DROP COLUMN refund_reason,
+    DROP COLUMN refund_policy,
+    DROP COLUMN refund_amount,
+    DROP COLUMN status;
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE public.tickets
+    ADD CONSTRAINT tickets_status_check CHECK (status IN ('reserved', 'paid', 'issued', 'checked-in', 'refunded', 'expired'));
+
+INSERT INTO public.privileges (name) VALUES ('checkin');
+
+-- +goose Down
+DELETE FROM public.user_privileges
+    WHERE privilege_id IN (SELECT id FROM public.privileges WHERE name = 'checkin');
+
+DELETE FROM public.privileges WHERE name = 'checkin';
+
+UPDATE public.tickets SET status = 'paid' WHERE status NOT IN ('paid', 'refunded');
+
+ALTER TABLE public.tickets DROP CONSTRAINT tickets_status_check;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.orders
+(
+    user_id integer NOT NULL,
+    total real NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    id SERIAL,
+    CONSTRAINT orders_pkey PRIMARY KEY (id),
+    CONSTRAINT "FK_orders_to_users" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+ALTER TABLE public.tickets
+    ADD COLUMN order_id integer,
+    ADD CONSTRAINT "FK_tickets_to_orders" FOREIGN KEY (order_id)
+        REFERENCES public.orders (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE public.tickets
+    DROP CONSTRAINT "FK_tickets_to_orders",
+    DROP COLUMN order_id;
+
+DROP TABLE public.orders;
@@ -0,0 +1,58 @@
+-- +goose Up
+ALTER TABLE public.orders ADD COLUMN status text NOT NULL DEFAULT 'paid';
+
+ALTER TABLE public.orders ALTER COLUMN status SET DEFAULT 'pending';
+
+CREATE TABLE IF NOT EXISTS public.payments
+(
+    order_id integer NOT NULL,
+    provider text NOT NULL,
+    reference text NOT NULL,
+    amount real NOT NULL,
+    refunded real NOT NULL DEFAULT 0,
+    status text NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    id SERIAL,
+    CONSTRAINT payments_pkey PRIMARY KEY (id),
+    CONSTRAINT payments_order_key UNIQUE (order_id),
+    CONSTRAINT payments_reference_key UNIQUE (provider, reference),
+    CONSTRAINT "FK_payments_to_orders" FOREIGN KEY (order_id)
+        REFERENCES public.orders (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.payment_events
+(
+    event_id text NOT NULL,
+    payment_id integer NOT NULL,
+    type text NOT NULL,
+    received_at timestamp without time zone NOT NULL,
+    CONSTRAINT payment_events_pkey PRIMARY KEY (event_id),
+    CONSTRAINT "FK_payment_events_to_payments" FOREIGN KEY (payment_id)
+        REFERENCES public.payments (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+DROP INDEX public.tickets_session_seat_key;
+
+CREATE UNIQUE INDEX tickets_session_seat_key ON public.tickets (session_id, seat) WHERE status NOT IN ('refunded', 'expired');
+
+-- +goose Down
+DROP INDEX public.tickets_session_seat_key;
+
+DELETE FROM public.tickets expired
+    WHERE expired.status = 'expired' AND EXISTS (
+        SELECT 1 FROM public.tickets other
+        WHERE other.session_id = expired.session_id AND other.seat = expired.seat
+            AND other.id <> expired.id AND other.status <> 'refunded'
+    );
+
+CREATE UNIQUE INDEX tickets_session_seat_key ON public.tickets (session_id, seat) WHERE status <> 'refunded';
+
+DROP TABLE public.payment_events;
+
+DROP TABLE public.payments;
+
+ALTER TABLE public.orders DROP COLUMN status;
@@ -0,0 +1,30 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.waitlist
+(
+    session_id integer NOT NULL,
+    user_id integer NOT NULL,
+    status text NOT NULL DEFAULT 'waiting',
+    hold text NOT NULL DEFAULT '',
+    seat_row integer NOT NULL DEFAULT 0,
+    seat_number integer NOT NULL DEFAULT 0,
+    expires_at timestamp without time zone,
+    created_at timestamp without time zone NOT NULL,
+    id SERIAL,
+    CONSTRAINT waitlist_pkey PRIMARY KEY (id),
+    CONSTRAINT waitlist_status_check CHECK (status IN ('waiting', 'offered', 'accepted', 'expired', 'left')),
+    CONSTRAINT "FK_waitlist_to_sessions" FOREIGN KEY (session_id)
+        REFERENCES public.sessions (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_waitlist_to_users" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX waitlist_session_user_key ON public.waitlist (session_id, user_id)
+    WHERE status IN ('waiting', 'offered');
+CREATE INDEX waitlist_hold_idx ON public.waitlist (hold);
+
+-- +goose Down
+DROP TABLE public.waitlist;
@@ -0,0 +1,90 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.promo_codes
+(
+    code text NOT NULL,
+    kind text NOT NULL,
+    amount real NOT NULL,
+    balance real NOT NULL DEFAULT 0,
+    starts_at timestamp without time zone,
+    ends_at timestamp without time zone,
+    max_uses integer NOT NULL DEFAULT 0,
+    per_user integer NOT NULL DEFAULT 0,
+    uses integer NOT NULL DEFAULT 0,
+    movie_id integer,
+    hall_id integer,
+    session_id integer,
+    id SERIAL,
+    CONSTRAINT promo_codes_pkey PRIMARY KEY (id),
+    CONSTRAINT promo_codes_code_key UNIQUE (code),
+    CONSTRAINT promo_codes_kind_check CHECK (kind IN ('percent', 'fixed', 'voucher')),
+    CONSTRAINT "FK_promo_codes_to_movies" FOREIGN KEY (movie_id)
+        REFERENCES public.movies (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_promo_codes_to_halls" FOREIGN KEY (hall_id)
+        REFERENCES public.halls (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_promo_codes_to_sessions" FOREIGN KEY (session_id)
+        REFERENCES public.sessions (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.promo_redemptions
+(
+    promo_id integer NOT NULL,
+    user_id integer NOT NULL,
+    order_id integer,
+    ticket_id integer,
+    amount real NOT NULL,
+    redeemed_at timestamp without time zone NOT NULL,
+    id SERIAL,
+    CONSTRAINT promo_redemptions_pkey PRIMARY KEY (id),
+    CONSTRAINT "FK_promo_redemptions_to_promo_codes" FOREIGN KEY (promo_id)
+        REFERENCES public.promo_codes (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_promo_redemptions_to_users" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_promo_redemptions_to_orders" FOREIGN KEY (order_id)
+        REFERENCES public.orders (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL,
+    CONSTRAINT "FK_promo_redemptions_to_tickets" FOREIGN KEY (ticket_id)
+        REFERENCES public.tickets (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE SET NULL
+);
+
+CREATE INDEX promo_redemptions_promo_user_idx ON public.promo_redemptions (promo_id, user_id);
+
+ALTER TABLE public.tickets
+    ADD COLUMN promo_code text,
+    ADD COLUMN discount real NOT NULL DEFAULT 0;
+
+ALTER TABLE public.orders
+    ADD COLUMN promo_code text,
+    ADD COLUMN discount real NOT NULL DEFAULT 0;
+
+INSERT INTO public.privileges (name) VALUES ('promos');
+
+-- +goose Down
+DELETE FROM public.user_privileges
+    WHERE privilege_id IN (SELECT id FROM public.privileges WHERE name = 'promos');
+
+DELETE FROM public.privileges WHERE name = 'promos';
+
+ALTER TABLE public.orders
+    DROP COLUMN promo_code,
+    DROP COLUMN discount;
+
+ALTER TABLE public.tickets
+    DROP COLUMN promo_code,
+    DROP COLUMN discount;
+
+DROP TABLE public.promo_redemptions;
+
+DROP TABLE public.promo_codes;
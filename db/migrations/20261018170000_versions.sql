@@ -0,0 +1,36 @@
+-- +goose Up
+ALTER TABLE public.halls ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE public.movies ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE public.sessions ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE public.tickets ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+-- +goose StatementBegin
+CREATE FUNCTION public.bump_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER halls_version BEFORE UPDATE ON public.halls
+    FOR EACH ROW EXECUTE PROCEDURE public.bump_version();
+CREATE TRIGGER movies_version BEFORE UPDATE ON public.movies
+    FOR EACH ROW EXECUTE PROCEDURE public.bump_version();
+CREATE TRIGGER sessions_version BEFORE UPDATE ON public.sessions
+    FOR EACH ROW EXECUTE PROCEDURE public.bump_version();
+CREATE TRIGGER tickets_version BEFORE UPDATE ON public.tickets
+    FOR EACH ROW EXECUTE PROCEDURE public.bump_version();
+
+-- +goose Down
+DROP TRIGGER tickets_version ON public.tickets;
+DROP TRIGGER sessions_version ON public.sessions;
+DROP TRIGGER movies_version ON public.movies;
+DROP TRIGGER halls_version ON public.halls;
+
+DROP FUNCTION public.bump_version();
+
+ALTER TABLE public.tickets DROP COLUMN version;
+ALTER TABLE public.sessions DROP COLUMN version;
+ALTER TABLE public.movies DROP COLUMN version;
+ALTER TABLE public.halls DROP COLUMN version;
@@ -0,0 +1,60 @@
+-- +goose Up
+ALTER TABLE public.movies
+    ADD COLUMN description text NOT NULL DEFAULT '',
+    ADD COLUMN release_date date,
+    ADD COLUMN age_rating text NOT NULL DEFAULT '',
+    ADD COLUMN language text NOT NULL DEFAULT '',
+    ADD COLUMN subtitles text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN dubbing text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN director text NOT NULL DEFAULT '',
+    ADD COLUMN cast_members text[] NOT NULL DEFAULT '{}',
+    ADD COLUMN poster text NOT NULL DEFAULT '',
+    ADD COLUMN trailer text NOT NULL DEFAULT '',
+    ADD CONSTRAINT movies_age_rating_check CHECK (age_rating IN ('', '0+', '6+', '12+', '16+', '18+'));
+
+CREATE INDEX movies_age_rating_idx ON public.movies (age_rating);
+
+CREATE TABLE IF NOT EXISTS public.genres
+(
+    name text NOT NULL,
+    id SERIAL,
+    CONSTRAINT genres_pkey PRIMARY KEY (id),
+    CONSTRAINT genres_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS public.movie_genres
+(
+    movie_id integer NOT NULL,
+    genre_id integer NOT NULL,
+    CONSTRAINT movie_genres_pkey PRIMARY KEY (movie_id, genre_id),
+    CONSTRAINT "FK_movie_genres_to_movies" FOREIGN KEY (movie_id)
+        REFERENCES public.movies (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_movie_genres_to_genres" FOREIGN KEY (genre_id)
+        REFERENCES public.genres (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX movie_genres_genre_idx ON public.movie_genres (genre_id);
+
+-- +goose Down
+DROP TABLE public.movie_genres;
+
+DROP TABLE public.genres;
+
+DROP INDEX public.movies_age_rating_idx;
+
+ALTER TABLE public.movies
+    DROP CONSTRAINT movies_age_rating_check,
+    DROP COLUMN description,
+    DROP COLUMN release_date,
+    DROP COLUMN age_rating,
+    DROP COLUMN language,
+    DROP COLUMN subtitles,
+    DROP COLUMN dubbing,
+    DROP COLUMN director,
+    DROP COLUMN cast_members,
+    DROP COLUMN poster,
+    DROP COLUMN trailer;
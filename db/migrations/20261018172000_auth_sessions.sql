@@ -0,0 +1,35 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.auth_sessions
+(
+    user_id integer NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    expires_at timestamp without time zone NOT NULL,
+    revoked_at timestamp without time zone,
+    id SERIAL,
+    CONSTRAINT auth_sessions_pkey PRIMARY KEY (id),
+    CONSTRAINT "FK_auth_sessions_to_users" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX auth_sessions_user_idx ON public.auth_sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS public.refresh_tokens
+(
+    token_hash text NOT NULL,
+    session_id integer NOT NULL,
+    created_at timestamp without time zone NOT NULL,
+    used_at timestamp without time zone,
+    CONSTRAINT refresh_tokens_pkey PRIMARY KEY (token_hash),
+    CONSTRAINT "FK_refresh_tokens_to_auth_sessions" FOREIGN KEY (session_id)
+        REFERENCES public.auth_sessions (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX refresh_tokens_session_idx ON public.refresh_tokens (session_id);
+
+-- +goose Down
+DROP TABLE public.refresh_tokens;
+DROP TABLE public.auth_sessions;
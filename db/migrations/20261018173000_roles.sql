@@ -0,0 +1,96 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS public.permissions
+(
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    privilege text, -- Legacy privilege granting the permission to users without roles
+    id SERIAL,
+    CONSTRAINT permissions_pkey PRIMARY KEY (id),
+    CONSTRAINT permissions_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS public.roles
+(
+    name text NOT NULL,
+    description text NOT NULL DEFAULT '',
+    system boolean NOT NULL DEFAULT false, -- Seeded roles can't be renamed or deleted
+    id SERIAL,
+    CONSTRAINT roles_pkey PRIMARY KEY (id),
+    CONSTRAINT roles_name_key UNIQUE (name)
+);
+
+CREATE TABLE IF NOT EXISTS public.role_permissions
+(
+    role_id integer NOT NULL,
+    permission_id integer NOT NULL,
+    CONSTRAINT role_permissions_pkey PRIMARY KEY (role_id, permission_id),
+    CONSTRAINT "FK_role_permissions_to_roles" FOREIGN KEY (role_id)
+        REFERENCES public.roles (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_role_permissions_to_permissions" FOREIGN KEY (permission_id)
+        REFERENCES public.permissions (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS public.user_roles
+(
+    user_id integer NOT NULL,
+    role_id integer NOT NULL,
+    CONSTRAINT user_roles_pkey PRIMARY KEY (user_id, role_id),
+    CONSTRAINT "FK_user_roles_to_users" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE,
+    CONSTRAINT "FK_user_roles_to_roles" FOREIGN KEY (role_id)
+        REFERENCES public.roles (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE
+);
+
+CREATE INDEX user_roles_role_idx ON public.user_roles (role_id);
+
+INSERT INTO public.permissions (name, description, privilege) VALUES
+    ('halls:read', 'View halls and their layouts', 'halls'),
+    ('halls:write', 'Create, change and delete halls and layouts', 'halls'),
+    ('movies:read', 'View movies', 'movies'),
+    ('movies:write', 'Create, change and delete movies', 'movies'),
+    ('sessions:read', 'View sessions and schedules', 'sessions'),
+    ('sessions:write', 'Create, change and delete sessions, generate schedules', 'sessions'),
+    ('tickets:read', 'View tickets of all customers', 'tickets'),
+    ('tickets:write', 'Sell, change and delete tickets', 'tickets'),
+    ('tickets:refund', 'Refund tickets of any customer', 'tickets'),
+    ('tickets:checkin', 'Verify ticket codes and admit holders to sessions', 'checkin'),
+    ('pricing:read', 'View pricing rules', 'pricing'),
+    ('pricing:write', 'Create, change and delete pricing rules', 'pricing'),
+    ('promos:read', 'View promo codes', 'promos'),
+    ('promos:write', 'Create and delete promo codes', 'promos'),
+    ('privileges:read', 'View privileges granted to users', 'privileges'),
+    ('privileges:write', 'Grant and revoke privileges', 'privileges'),
+    ('roles:read', 'View roles and roles of users', NULL),
+    ('roles:write', 'Create, change and delete roles, assign them to users', NULL);
+
+INSERT INTO public.roles (name, description, system) VALUES
+    ('customer', 'Buys and manages own tickets, needs no permissions', true),
+    ('cashier', 'Sells, refunds and checks in tickets at the box office', true),
+    ('manager', 'Runs cinema catalog, schedule, pricing and promotions', true),
+    ('superadmin', 'Holds every permission, including ones added later', true);
+
+INSERT INTO public.role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles, permissions
+    WHERE roles.name = 'cashier'
+        AND (permissions.name LIKE '%:read' OR permissions.name IN ('tickets:write', 'tickets:refund', 'tickets:checkin'))
+        AND permissions.name NOT IN ('privileges:read', 'roles:read');
+
+INSERT INTO public.role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles, permissions
+    WHERE roles.name = 'manager'
+        AND permissions.name NOT LIKE 'privileges:%'
+        AND permissions.name NOT LIKE 'roles:%';
+
+-- +goose Down
+DROP TABLE public.user_roles;
+DROP TABLE public.role_permissions;
+DROP TABLE public.roles;
+DROP TABLE public.permissions;
@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE public.privileges
+    ADD COLUMN description text NOT NULL DEFAULT '';
+
+-- Privileges inserted by hand twice are merged into the first one
+UPDATE public.user_privileges SET privilege_id = first.id
+    FROM public.privileges duplicate
+    JOIN (SELECT name, MIN(id) AS id FROM public.privileges GROUP BY name) first ON first.name = duplicate.name
+    WHERE user_privileges.privilege_id = duplicate.id AND duplicate.id <> first.id;
+
+DELETE FROM public.privileges duplicate
+    USING public.privileges first
+    WHERE first.name = duplicate.name AND first.id < duplicate.id;
+
+DELETE FROM public.user_privileges duplicate
+    USING public.user_privileges first
+    WHERE first.user_id = duplicate.user_id AND first.privilege_id = duplicate.privilege_id AND first.id < duplicate.id;
+
+ALTER TABLE public.privileges
+    ADD CONSTRAINT privileges_name_key UNIQUE (name);
+
+ALTER TABLE public.user_privileges
+    ADD CONSTRAINT user_privileges_user_privilege_key UNIQUE (user_id, privilege_id);
+
+-- Roles stay out of the catalog, holders of privileges:write could grant themselves any role otherwise
+INSERT INTO public.privileges (name, description) VALUES
+    ('halls', 'Manage halls and their layouts'),
+    ('movies', 'Manage movies'),
+    ('sessions', 'Manage sessions and generate schedules'),
+    ('tickets', 'Manage and refund tickets of all customers'),
+    ('checkin', 'Verify ticket codes and admit holders to sessions'),
+    ('pricing', 'Manage pricing rules'),
+    ('promos', 'Manage promo codes'),
+    ('privileges', 'Manage privilege catalog and grant privileges')
+    ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description
+    WHERE privileges.description = '';
+
+-- Renamed privileges keep granting their permissions, privileges permissions refer to can't be deleted
+ALTER TABLE public.permissions
+    ADD CONSTRAINT "FK_permissions_to_privileges" FOREIGN KEY (privilege)
+        REFERENCES public.privileges (name) MATCH SIMPLE
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION;
+
+-- +goose Down
+ALTER TABLE public.permissions
+    DROP CONSTRAINT "FK_permissions_to_privileges";
+
+ALTER TABLE public.user_privileges
+    DROP CONSTRAINT user_privileges_user_privilege_key;
+
+ALTER TABLE public.privileges
+    DROP CONSTRAINT privileges_name_key,
+    DROP COLUMN description;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE public.seat_holds ADD COLUMN user_id integer;
+
+-- Seats offered to waitlist belong to offered users, anonymous holds are short lived and dropped
+UPDATE public.seat_holds SET user_id = waitlist.user_id
+    FROM public.waitlist
+    WHERE waitlist.hold = seat_holds.token;
+
+DELETE FROM public.seat_holds WHERE user_id IS NULL;
+
+ALTER TABLE public.seat_holds
+    ALTER COLUMN user_id SET NOT NULL,
+    ADD CONSTRAINT "FK_seat_holds_to_users" FOREIGN KEY (user_id)
+        REFERENCES public.users (id) MATCH SIMPLE
+        ON UPDATE NO ACTION
+        ON DELETE CASCADE;
+
+CREATE INDEX seat_holds_user_idx ON public.seat_holds (user_id, expires_at);
+
+-- +goose Down
+DROP INDEX public.seat_holds_user_idx;
+
+ALTER TABLE public.seat_holds
+    DROP CONSTRAINT "FK_seat_holds_to_users",
+    DROP COLUMN user_id;
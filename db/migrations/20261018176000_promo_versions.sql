@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE public.pricing_rules ADD COLUMN version integer NOT NULL DEFAULT 1;
+ALTER TABLE public.promo_codes ADD COLUMN version integer NOT NULL DEFAULT 1;
+
+CREATE TRIGGER pricing_rules_version BEFORE UPDATE ON public.pricing_rules
+    FOR EACH ROW EXECUTE PROCEDURE public.bump_version();
+CREATE TRIGGER promo_codes_version BEFORE UPDATE ON public.promo_codes
+    FOR EACH ROW EXECUTE PROCEDURE public.bump_version();
+
+-- +goose Down
+DROP TRIGGER promo_codes_version ON public.promo_codes;
+DROP TRIGGER pricing_rules_version ON public.pricing_rules;
+
+ALTER TABLE public.promo_codes DROP COLUMN version;
+ALTER TABLE public.pricing_rules DROP COLUMN version;
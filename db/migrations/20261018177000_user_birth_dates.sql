@@ -0,0 +1,6 @@
+-- +goose Up
+-- Birth date is stored once staff checked document of user, age rated tickets aren't sold without it
+ALTER TABLE public.users ADD COLUMN birth_date date;
+
+-- +goose Down
+ALTER TABLE public.users DROP COLUMN birth_date;
@@ -0,0 +1,11 @@
+-- +goose Up
+INSERT INTO public.permissions (name, description) VALUES
+    ('users:verify', 'Record birth dates checked with documents of users');
+
+-- Box office checks documents of customers buying age rated tickets
+INSERT INTO public.role_permissions (role_id, permission_id)
+    SELECT roles.id, permissions.id FROM roles, permissions
+    WHERE roles.name IN ('cashier', 'manager') AND permissions.name = 'users:verify';
+
+-- +goose Down
+DELETE FROM public.permissions WHERE name = 'users:verify';
@@ -12,6 +12,93 @@ var (
 	// ErrNoSeats creates new seats error
 	ErrNoSeats = errors.New("no seats")
 
+	// ErrSeatTaken creates new seat conflict error
+	ErrSeatTaken = errors.New("seat is already taken")
+
+	// ErrHoldInvalid creates new seat hold error
+	ErrHoldInvalid = errors.New("seat hold is missing or expired")
+
+	// ErrHoldLimit creates new seat hold limit error
+	ErrHoldLimit = errors.New("too many seats held")
+
+	// ErrLayoutInUse creates new layout conflict error
+	ErrLayoutInUse = errors.New("hall has tickets sold for upcoming sessions")
+
+	// ErrPriceNotSet creates new pricing error
+	ErrPriceNotSet = errors.New("ticket price is not configured")
+
+	// ErrRefundClosed creates new refund cutoff error
+	ErrRefundClosed = errors.New("ticket can't be refunded this close to session start")
+
+	// ErrRefunded creates new refund conflict error
+	ErrRefunded = errors.New("ticket is already refunded")
+
+	// ErrTicketStatus creates new ticket status conflict error
+	ErrTicketStatus = errors.New("ticket status doesn't allow this action")
+
+	// ErrCheckedIn creates new double admission error
+	ErrCheckedIn = errors.New("ticket is already checked in")
+
+	// ErrWrongSession creates new admission session error
+	ErrWrongSession = errors.New("ticket is for another session")
+
+	// ErrAdmissionClosed creates new admission time error
+	ErrAdmissionClosed = errors.New("admission to session is not open")
+
+	// ErrCodeInvalid creates new ticket code error
+	ErrCodeInvalid = errors.New("invalid ticket code")
+
+	// ErrPaymentFailed creates new payment provider error
+	ErrPaymentFailed = errors.New("payment was declined")
+
+	// ErrWebhookInvalid creates new payment webhook error
+	ErrWebhookInvalid = errors.New("invalid payment webhook")
+
+	// ErrWaitlisted creates new waitlist conflict error
+	ErrWaitlisted = errors.New("user is already on session waitlist")
+
+	// ErrPromoExists creates new promo code conflict error
+	ErrPromoExists = errors.New("promo code already exists")
+
+	// ErrPromoInvalid creates new promo code error
+	ErrPromoInvalid = errors.New("promo code can't be applied")
+
+	// ErrSessionConflict creates new schedule conflict error
+	ErrSessionConflict = errors.New("hall is busy with other sessions at this time")
+
+	// ErrTicketsSold creates new update conflict error
+	ErrTicketsSold = errors.New("change would invalidate sold tickets")
+
+	// ErrVersionMismatch creates new optimistic concurrency error
+	ErrVersionMismatch = errors.New("resource was changed by another request")
+
+	// ErrAgeRestricted creates new age rating error
+	ErrAgeRestricted = errors.New("movie is restricted to older viewers")
+
+	// ErrRefreshInvalid creates new refresh token error
+	ErrRefreshInvalid = errors.New("refresh token is invalid, expired or revoked")
+
+	// ErrRefreshReused creates new refresh token reuse error
+	ErrRefreshReused = errors.New("refresh token was already used, session is revoked")
+
+	// ErrNotFound creates new missing reference error
+	ErrNotFound = errors.New("referenced resource does not exist")
+
+	// ErrRoleExists creates new role conflict error
+	ErrRoleExists = errors.New("role already exists")
+
+	// ErrSystemRole creates new built-in role conflict error
+	ErrSystemRole = errors.New("built-in role can't be changed this way")
+
+	// ErrPrivilegeExists creates new privilege conflict error
+	ErrPrivilegeExists = errors.New("privilege already exists")
+
+	// ErrPrivilegeGranted creates new duplicate grant error
+	ErrPrivilegeGranted = errors.New("user already has this privilege")
+
+	// ErrPrivilegeInUse creates new privilege delete conflict error
+	ErrPrivilegeInUse = errors.New("privilege is granted to users or permissions")
+
 	// ErrWrongEmail creates new email format error
 	ErrWrongEmail = errors.New("wrong email format")
 )
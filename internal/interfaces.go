@@ -10,12 +10,44 @@ type Deleter interface {
 	Delete(id int64, ctx context.Context) error
 }
 
+type VersionDeleter interface {
+	DeleteVersion(id int64, version int64, ctx context.Context) error
+}
+
 type Retriever interface {
 	Retrieve(id int64, ctx context.Context) (Identifiable, error)
 }
 
+type Updater interface {
+	Update(id int64, r Identifiable, ctx context.Context) (Identifiable, error)
+}
+
 type RetrieverAll interface {
-	RetrieveAll(ctx context.Context) ([]Identifiable, error)
+	RetrieveAll(q *Query, ctx context.Context) (*Page, error)
+}
+
+type SeatRetriever interface {
+	RetrieveSeats(id int64, ctx context.Context) (Identifiable, error)
+}
+
+type UserRetriever interface {
+	RetrieveByUser(id int64, q *Query, ctx context.Context) (*Page, error)
+}
+
+type Refunder interface {
+	Cancel(id int64, user int64, version int64, ctx context.Context) (Identifiable, error)
+	Refund(id int64, reason string, version int64, ctx context.Context) (Identifiable, error)
+}
+
+type Admitter interface {
+	CheckIn(id int64, session int64, version int64, ctx context.Context) (Identifiable, error)
+	Issue(id int64, ctx context.Context) error
+}
+
+type Verifier interface {
+	Code(id int64, user int64, ctx context.Context) (Identifiable, error)
+	Verify(code string, ctx context.Context) (Identifiable, error)
+	VerifyKey() (string, error)
 }
 
 type Service interface {
@@ -25,6 +57,68 @@ type Service interface {
 	RetrieverAll
 }
 
+type EditableService interface {
+	Service
+	Updater
+}
+
+type VersionedService interface {
+	EditableService
+	VersionDeleter
+}
+
+type SessionService interface {
+	VersionedService
+	Schedule(r Identifiable, ctx context.Context) (Identifiable, error)
+	Showtimes(q *Query, ctx context.Context) (*Page, error)
+}
+
+type LayoutService interface {
+	Creator
+	Deleter
+	Retriever
+}
+
+type HoldService interface {
+	Creator
+	RetrieveHold(token string, user int64, ctx context.Context) (Identifiable, error)
+	DeleteHold(token string, user int64, ctx context.Context) error
+}
+
+type OrderService interface {
+	Creator
+	Retriever
+}
+
+type WaitlistService interface {
+	Join(session int64, user int64, ctx context.Context) (Identifiable, error)
+	Position(session int64, user int64, ctx context.Context) (Identifiable, error)
+	Leave(session int64, user int64, ctx context.Context) (Identifiable, error)
+	Entries(user int64, ctx context.Context) ([]Identifiable, error)
+}
+
+type PaymentService interface {
+	Webhook(payload []byte, signature string, ctx context.Context) error
+}
+
+type RoleService interface {
+	EditableService
+	UserRoles(user int64, ctx context.Context) ([]Identifiable, error)
+	Assign(user int64, roles []string, ctx context.Context) ([]Identifiable, error)
+}
+
+type TicketService interface {
+	Deleter
+	VersionDeleter
+	Retriever
+	RetrieverAll
+	SeatRetriever
+	UserRetriever
+	Refunder
+	Admitter
+	Verifier
+}
+
 type Identifiable interface {
 	GID() int64
 }
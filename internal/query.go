@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page size limits of lists
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query is a struct to store list page requested by client
+type Query struct {
+	Limit   uint64
+	Cursor  string            // Next cursor of previous page, first page when empty
+	Sort    string            // Field list is sorted by, id when empty
+	Desc    bool              // Sort in descending order
+	Filters map[string]string // Fields list items must be equal to
+}
+
+// Meta is a struct to store list details returned with page
+type Meta struct {
+	Limit uint64 `json:"limit"`
+	Total int64  `json:"total"`                 // Items matching filters on all pages
+	Next  string `json:"next_cursor,omitempty"` // Empty on the last page
+}
+
+// Page is a struct to store single page of list
+type Page struct {
+	Data []Identifiable `json:"data"`
+	Meta Meta           `json:"meta"`
+}
+
+// ParseQuery reads list options from query parameters: limit, cursor and sort
+// with field name, prefixed by minus for descending order. Other parameters filter by field.
+func ParseQuery(values url.Values) (*Query, error) {
+	q := &Query{Limit: DefaultLimit, Filters: map[string]string{}}
+
+	for name := range values {
+		value := values.Get(name)
+
+		switch name {
+		case "limit":
+			limit, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || limit == 0 {
+				return nil, fmt.Errorf("%w: limit must be a positive number", ErrValidationFailed)
+			}
+
+			if limit > MaxLimit {
+				return nil, fmt.Errorf("%w: limit can't exceed %d", ErrValidationFailed, MaxLimit)
+			}
+
+			q.Limit = limit
+		case "cursor":
+			q.Cursor = value
+		case "sort":
+			q.Sort = strings.TrimPrefix(value, "-")
+			q.Desc = strings.HasPrefix(value, "-")
+		default:
+			q.Filters[name] = value
+		}
+	}
+
+	return q, nil
+}
+
+// Cursor is a struct to store position of last item on page
+type Cursor struct {
+	Sort string `json:"s,omitempty"` // Field list is sorted by, id when empty
+	Desc bool   `json:"d,omitempty"` // List is sorted in descending order
+	Key  string `json:"k,omitempty"` // Value of sort field in last item
+	ID   int64  `json:"i"`
+}
+
+// EncodeCursor makes opaque cursor pointing after item
+func EncodeCursor(c Cursor) string {
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor returns position of item cursor points after
+func DecodeCursor(cursor string) (Cursor, error) {
+	var c Cursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return c, fmt.Errorf("%w: invalid cursor", ErrValidationFailed)
+	}
+
+	err = json.Unmarshal(raw, &c)
+	if err != nil || c.ID == 0 {
+		return c, fmt.Errorf("%w: invalid cursor", ErrValidationFailed)
+	}
+
+	return c, nil
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQuery(t *testing.T) {
+	testParseQueryCases := []struct {
+		name           string
+		values         url.Values
+		expectedResult *Query
+		expectedError  error
+	}{
+		{
+			name:           "defaults",
+			values:         url.Values{},
+			expectedResult: &Query{Limit: DefaultLimit, Filters: map[string]string{}},
+		},
+		{
+			name:   "page, descending sort and filters",
+			values: url.Values{"limit": {"5"}, "cursor": {"Nw"}, "sort": {"-starts_at"}, "hall_id": {"2"}},
+			expectedResult: &Query{
+				Limit:   5,
+				Cursor:  "Nw",
+				Sort:    "starts_at",
+				Desc:    true,
+				Filters: map[string]string{"hall_id": "2"},
+			},
+		},
+		{
+			name:          "zero limit",
+			values:        url.Values{"limit": {"0"}},
+			expectedError: ErrValidationFailed,
+		},
+		{
+			name:          "limit too big",
+			values:        url.Values{"limit": {"101"}},
+			expectedError: ErrValidationFailed,
+		},
+	}
+
+	for _, tc := range testParseQueryCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := ParseQuery(tc.values)
+			assert.True(t, errors.Is(err, tc.expectedError))
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	c := Cursor{Sort: "starts_at", Desc: true, Key: "2022-04-01T10:00:00Z", ID: 42}
+
+	res, err := DecodeCursor(EncodeCursor(c))
+	assert.Nil(t, err)
+	assert.Equal(t, c, res)
+
+	_, err = DecodeCursor("not a cursor")
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+
+	_, err = DecodeCursor("Nw")
+	assert.True(t, errors.Is(err, ErrValidationFailed))
+}
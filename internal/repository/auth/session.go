@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Refresh is a struct to store refresh token with state of its session
+type Refresh struct {
+	Session_ID int64
+	User_ID    int64
+	Used       bool // Token was already exchanged, presenting it again means it leaked
+	Expires_at time.Time
+	Revoked    bool
+}
+
+// Open starts sign-in session of user within transaction and returns its id
+func (r *Repository) Open(user int64, now time.Time, expires time.Time, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	err := sq.
+		Insert("auth_sessions").
+		Columns("user_id", "created_at", "expires_at").
+		Values(user, now, expires).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err != nil {
+		r.Log.Info("Failed to run Open auth session query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
+// Issue stores hash of refresh token of session within transaction
+func (r *Repository) Issue(hash string, session int64, now time.Time, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Insert("refresh_tokens").
+		Columns("token_hash", "session_id", "created_at").
+		Values(hash, session, now).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Issue refresh token query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Lock returns refresh token by hash and locks it with its session till the end of transaction
+func (r *Repository) Lock(hash string, ctx context.Context, tx *sql.Tx) (*Refresh, error) {
+	var res Refresh
+
+	err := sq.
+		Select("refresh_tokens.session_id", "auth_sessions.user_id", "refresh_tokens.used_at IS NOT NULL",
+			"auth_sessions.expires_at", "auth_sessions.revoked_at IS NOT NULL").
+		From("refresh_tokens").
+		Join("auth_sessions ON refresh_tokens.session_id = auth_sessions.id").
+		Where(sq.Eq{
+			"refresh_tokens.token_hash": hash,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&res.Session_ID, &res.User_ID, &res.Used, &res.Expires_at, &res.Revoked)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock refresh token query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &res, nil
+}
+
+// Use marks refresh token as exchanged within transaction
+func (r *Repository) Use(hash string, now time.Time, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("refresh_tokens").
+		Set("used_at", now).
+		Where(sq.Eq{
+			"token_hash": hash,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Use refresh token query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Revoke ends session within transaction, its access and refresh tokens stop working
+func (r *Repository) Revoke(session int64, now time.Time, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("auth_sessions").
+		Set("revoked_at", now).
+		Where(sq.Eq{
+			"id":         session,
+			"revoked_at": nil,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Revoke auth session query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Active reports if session of user is neither revoked nor expired
+func (r *Repository) Active(session int64, user int64, now time.Time, ctx context.Context) (bool, error) {
+	var id int64
+
+	err := sq.
+		Select("id").
+		From("auth_sessions").
+		Where(sq.Eq{
+			"id":         session,
+			"revoked_at": nil,
+			"user_id":    user,
+		}).
+		Where(sq.Gt{
+			"expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err == sql.ErrNoRows {
+
+		return false, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Active auth session query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return true, nil
+}
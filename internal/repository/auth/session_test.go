@@ -0,0 +1,268 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var now = time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func NewLogger() *zap.Logger {
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	return logger
+}
+
+func TestOpen(t *testing.T) {
+	query := regexp.QuoteMeta("INSERT INTO auth_sessions (user_id,created_at,expires_at) VALUES ($1,$2,$3) RETURNING \"id\"")
+	expires := now.Add(30 * 24 * time.Hour)
+
+	testOpenCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult int64
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: 4,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(7, now, expires).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(4))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testOpenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger := NewLogger()
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			res, err := repo.Open(7, now, expires, ctx, tx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT refresh_tokens.session_id, auth_sessions.user_id, refresh_tokens.used_at IS NOT NULL, " +
+		"auth_sessions.expires_at, auth_sessions.revoked_at IS NOT NULL FROM refresh_tokens " +
+		"JOIN auth_sessions ON refresh_tokens.session_id = auth_sessions.id WHERE refresh_tokens.token_hash = $1 FOR UPDATE")
+	columns := []string{"session_id", "user_id", "used", "expires_at", "revoked"}
+
+	testLockCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *Refresh
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: &Refresh{Session_ID: 4, User_ID: 7, Used: true, Expires_at: now},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs("beef").
+					WillReturnRows(sqlm2.NewRows(columns).AddRow(4, 7, true, now, false))
+			},
+		},
+		{
+			name:           "success, unknown token",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs("beef").
+					WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testLockCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger := NewLogger()
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			res, err := repo.Lock("beef", ctx, tx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	logger := NewLogger()
+	repo := &Repository{DB: db, Log: logger}
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL")).
+		WithArgs(now, 4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Revoke(4, now, context.Background(), tx)
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestActive(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT id FROM auth_sessions WHERE id = $1 AND revoked_at IS NULL AND user_id = $2 AND expires_at > $3")
+
+	testActiveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult bool
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success, active session",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(4, 7, now).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(4))
+			},
+		},
+		{
+			name:           "success, revoked or expired session",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(4, 7, now).
+					WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testActiveCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger := NewLogger()
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Active(4, 7, now, context.Background())
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
@@ -3,11 +3,14 @@ package hall
 import (
 	"context"
 	"database/sql"
+	"strconv"
 
 	sq "github.com/Masterminds/squirrel"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
 )
 
 // Repository is a struct to store storage and logger connection
@@ -21,12 +24,26 @@ type Resource struct {
 	ID    int64 `json:"ID"`
 	VIP   bool  `json:"VIP"`
 	Seats int   `json:"seats"`
+	Rows  int   `json:"rows"`
+
+	Version int64 `json:"-"` // Grows with every change, expected version when updating
 }
 
 func (r *Resource) GID() int64 {
 	return r.ID
 }
 
+func (r *Resource) Revision() int64 {
+	return r.Version
+}
+
+// Place is a struct to store seat taken by sold ticket
+type Place struct {
+	Seat   int64 // Position counted from the first row
+	Row    int64
+	Number int64
+}
+
 // Create new entity in storage
 func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
 	var id int64
@@ -42,8 +59,8 @@ func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (inter
 
 	err := sq.
 		Insert("halls").
-		Columns("vip", "seats").
-		Values(hall.VIP, hall.Seats).
+		Columns("vip", "seats", "rows").
+		Values(hall.VIP, hall.Seats, hall.Rows).
 		Suffix("RETURNING \"id\"").
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
@@ -66,7 +83,7 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 	var res Resource
 
 	err := sq.
-		Select("vip", "id", "seats").
+		Select("vip", "id", "seats", "rows", "version").
 		From("halls").
 		Where(sq.Eq{
 			"id": id,
@@ -74,7 +91,7 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryRowContext(ctx).
-		Scan(&res.VIP, &res.ID, &res.Seats)
+		Scan(&res.VIP, &res.ID, &res.Seats, &res.Rows, &res.Version)
 
 	if err == sql.ErrNoRows {
 
@@ -92,14 +109,125 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 	return &res, nil
 }
 
-// Delete entity in storage
-func (r *Repository) Delete(id int64, ctx context.Context) error {
+// Lock selects hall for update within transaction, so its seats change one at a time
+// and tickets aren't sold meanwhile. Returns nil when there's no such hall.
+func (r *Repository) Lock(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+	var res Resource
 
-	_, err := sq.
-		Delete("halls").
+	err := sq.
+		Select("vip", "id", "seats", "rows", "version").
+		From("halls").
 		Where(sq.Eq{
 			"id": id,
 		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&res.VIP, &res.ID, &res.Seats, &res.Rows, &res.Version)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock hall query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &res, nil
+}
+
+// Update entity in storage within transaction
+func (r *Repository) Update(hall *Resource, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("halls").
+		Set("vip", hall.VIP).
+		Set("seats", hall.Seats).
+		Set("rows", hall.Rows).
+		Where(sq.Eq{
+			"id": hall.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Update hall query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Sold returns distinct places of tickets sold for upcoming sessions in hall
+func (r *Repository) Sold(id int64, ctx context.Context, tx *sql.Tx) ([]Place, error) {
+
+	rows, err := sq.
+		Select("tickets.seat", "tickets.seat_row", "tickets.seat_number").
+		Distinct().
+		From("tickets").
+		Join("sessions ON tickets.session_id = sessions.id").
+		Where(sq.Eq{
+			"sessions.hall_id": id,
+		}).
+		Where(sq.NotEq{
+			"tickets.status": []string{t.Refunded, t.Expired},
+		}).
+		Where("sessions.starts_at > NOW()").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Sold hall query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []Place
+
+	for rows.Next() {
+		var place Place
+
+		err = rows.Scan(&place.Seat, &place.Row, &place.Number)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into hall places.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, place)
+	}
+
+	return data, nil
+}
+
+// Delete entity in storage, version when set must match current one
+func (r *Repository) Delete(id int64, version int64, ctx context.Context) error {
+	where := sq.Eq{
+		"id": id,
+	}
+
+	if version > 0 {
+		where["version"] = version
+	}
+
+	result, err := sq.
+		Delete("halls").
+		Where(where).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		ExecContext(ctx)
@@ -112,15 +240,37 @@ func (r *Repository) Delete(id int64, ctx context.Context) error {
 		return internal.ErrInternalFailure
 	}
 
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count deleted halls.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if deleted == 0 && version > 0 {
+		return internal.ErrVersionMismatch
+	}
+
 	return nil
 }
 
-// RetrieveAll entity from storage
-func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
+// fields halls list can be sorted and filtered by
+var fields = list.Fields{
+	"vip":  {Column: "halls.vip", Kind: list.Bool, Key: func(i internal.Identifiable) string { return strconv.FormatBool(i.(*Resource).VIP) }},
+	"rows": {Column: "halls.rows", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.Itoa(i.(*Resource).Rows) }},
+}
 
-	rows, err := sq.
-		Select("vip", "id", "seats").
-		From("halls").
+// RetrieveAll returns page of halls matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.Select("vip", "id", "seats", "rows", "version").From("halls"), "halls", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).QueryContext(ctx)
 
@@ -132,16 +282,12 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		return nil, internal.ErrInternalFailure
 	}
 
-	var data []*Resource
+	var data []internal.Identifiable
 
 	for rows.Next() {
 		res := &Resource{}
 
-		err = rows.Scan(&res.VIP, &res.ID, &res.Seats)
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
+		err = rows.Scan(&res.VIP, &res.ID, &res.Seats, &res.Rows, &res.Version)
 		if err != nil {
 			r.Log.Info("Failed to scan rows into halls structures.",
 				zap.Error(err),
@@ -153,11 +299,14 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		data = append(data, res)
 	}
 
-	var dataSlice []*Resource = data
-	var interfaceSlice []internal.Identifiable = make([]internal.Identifiable, len(dataSlice))
-	for i, d := range dataSlice {
-		interfaceSlice[i] = d
+	total, err := list.Total(r.DB, "halls", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count halls.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
 	}
 
-	return interfaceSlice, nil
+	return list.Page(data, q, fields, total), nil
 }
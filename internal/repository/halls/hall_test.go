@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -18,6 +19,9 @@ var hall = &Resource{
 	ID:    15,
 	VIP:   true,
 	Seats: 15,
+	Rows:  3,
+
+	Version: 1,
 }
 
 func NewMock() (*sql.DB, sqlmock.Sqlmock) {
@@ -61,11 +65,11 @@ func TestCreate(t *testing.T) {
 					WillReturnRows(sqlm2.
 						NewRows([]string{"id"}).
 						AddRow(hall.ID))
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls WHERE id = \\$1").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls WHERE id = \\$1").
 					WithArgs(hall.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"vip", "id", "seats"}).
-						AddRow(hall.VIP, hall.ID, hall.Seats))
+						NewRows([]string{"vip", "id", "seats", "rows", "version"}).
+						AddRow(hall.VIP, hall.ID, hall.Seats, hall.Rows, hall.Version))
 			},
 			object: hall,
 		},
@@ -134,11 +138,11 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: hall,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls WHERE id = \\$1").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls WHERE id = \\$1").
 					WithArgs(hall.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"vip", "id", "seats"}).
-						AddRow(hall.VIP, hall.ID, hall.Seats))
+						NewRows([]string{"vip", "id", "seats", "rows", "version"}).
+						AddRow(hall.VIP, hall.ID, hall.Seats, hall.Rows, hall.Version))
 			},
 		},
 		{
@@ -146,7 +150,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls WHERE id = \\$1").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls WHERE id = \\$1").
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
@@ -155,7 +159,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls WHERE id = \\$1").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls WHERE id = \\$1").
 					WillReturnRows(sqlm2.
 						NewRows(nil))
 			},
@@ -197,18 +201,20 @@ func TestRetrieveAll(t *testing.T) {
 	testRetrieveAllCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult []internal.Identifiable
+		expectedResult *internal.Page
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{hall},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{hall}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 1}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls").
 					WillReturnRows(sqlm2.
-						NewRows([]string{"vip", "id", "seats"}).
-						AddRow(hall.VIP, hall.ID, hall.Seats))
+						NewRows([]string{"vip", "id", "seats", "rows", "version"}).
+						AddRow(hall.VIP, hall.ID, hall.Seats, hall.Rows, hall.Version))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM halls")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
 			},
 		},
 		{
@@ -216,17 +222,19 @@ func TestRetrieveAll(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls").
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
 		{
 			name:           "failed, sql no rows error",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: internal.DefaultLimit}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT vip, id, seats FROM halls").
+				sqlm2.ExpectQuery("SELECT vip, id, seats, rows, version FROM halls").
 					WillReturnRows(sqlm2.NewRows([]string{}))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM halls")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
 			},
 		},
 	}
@@ -249,13 +257,142 @@ func TestRetrieveAll(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			res, err := repo.RetrieveAll(ctx)
+			res, err := repo.RetrieveAll(&internal.Query{}, ctx)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
+func TestLock(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	query := regexp.QuoteMeta("SELECT vip, id, seats, rows, version FROM halls WHERE id = $1 FOR UPDATE")
+
+	mock.ExpectQuery(query).
+		WithArgs(hall.ID).
+		WillReturnRows(mock.NewRows([]string{"vip", "id", "seats", "rows", "version"}).
+			AddRow(hall.VIP, hall.ID, hall.Seats, hall.Rows, hall.Version))
+
+	res, err := repo.Lock(hall.ID, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, hall, res)
+
+	mock.ExpectQuery(query).
+		WithArgs(16).
+		WillReturnRows(mock.NewRows([]string{"vip", "id", "seats", "rows", "version"}))
+
+	res, err = repo.Lock(16, ctx, tx)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestUpdate(t *testing.T) {
+	update := regexp.QuoteMeta("UPDATE halls SET vip = $1, seats = $2, rows = $3 WHERE id = $4")
+
+	testUpdateCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WithArgs(hall.VIP, hall.Seats, hall.Rows, hall.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testUpdateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			err = repo.Update(hall, ctx, tx)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestSold(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT tickets.seat, tickets.seat_row, tickets.seat_number FROM tickets JOIN sessions ON tickets.session_id = sessions.id WHERE sessions.hall_id = $1 AND tickets.status NOT IN ($2,$3) AND sessions.starts_at > NOW()")).
+		WithArgs(hall.ID, "refunded", "expired").
+		WillReturnRows(mock.NewRows([]string{"seat", "seat_row", "seat_number"}).
+			AddRow(1, 1, 1).
+			AddRow(7, 2, 2))
+
+	sold, err := repo.Sold(hall.ID, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Place{{Seat: 1, Row: 1, Number: 1}, {Seat: 7, Row: 2, Number: 2}}, sold)
+}
+
 func TestDelete(t *testing.T) {
 	db, mock := NewMock()
 	defer func() {
@@ -268,6 +405,7 @@ func TestDelete(t *testing.T) {
 		expectedResult internal.Identifiable
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 		id             int64
+		version        int64
 	}{
 		{
 			name:           "success",
@@ -280,6 +418,30 @@ func TestDelete(t *testing.T) {
 			},
 			id: int64(hall.ID),
 		},
+		{
+			name:           "success, expected version",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM halls WHERE id = $1 AND version = $2")).
+					WithArgs(hall.ID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			id:      int64(hall.ID),
+			version: 3,
+		},
+		{
+			name:           "failed, version mismatch",
+			expectedError:  internal.ErrVersionMismatch,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM halls WHERE id = $1 AND version = $2")).
+					WithArgs(hall.ID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			id:      int64(hall.ID),
+			version: 3,
+		},
 		{
 			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
@@ -311,7 +473,7 @@ func TestDelete(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			err = repo.Delete(tc.id, ctx)
+			err = repo.Delete(tc.id, tc.version, ctx)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
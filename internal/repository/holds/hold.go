@@ -0,0 +1,350 @@
+package hold
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+// uniqueViolation is a postgres error code for unique constraint violation
+const uniqueViolation = "23505"
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	Token      string    `json:"token"`
+	Session_ID int64     `json:"session_id"`
+	User_ID    int64     `json:"-"` // Owner, taken from access token
+	Seats      []Seat    `json:"seats"`
+	Expires_at time.Time `json:"expires_at"`
+}
+
+// Seat is a struct to store held seat
+type Seat struct {
+	Row    int64 `json:"row"`
+	Number int64 `json:"number"`
+	Seat   int64 `json:"-"` // Position of seat in hall layout
+}
+
+func (r *Resource) GID() int64 {
+	return r.Session_ID
+}
+
+// Create new entity in storage
+func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sql.Tx) error {
+	hold, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create hold object.",
+			zap.Bool("ok", ok),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	insert := sq.
+		Insert("seat_holds").
+		Columns("token", "session_id", "user_id", "seat", "seat_row", "seat_number", "expires_at")
+
+	for _, seat := range hold.Seats {
+		insert = insert.Values(hold.Token, hold.Session_ID, hold.User_ID, seat.Seat, seat.Row, seat.Number, hold.Expires_at)
+	}
+
+	_, err := insert.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return internal.ErrSeatTaken
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Create hold query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Retrieve active hold of user from storage
+func (r *Repository) Retrieve(token string, user int64, now time.Time, ctx context.Context) (internal.Identifiable, error) {
+
+	rows, err := sq.
+		Select("session_id", "seat_row", "seat_number", "seat", "expires_at").
+		From("seat_holds").
+		Where(sq.Eq{
+			"token":   token,
+			"user_id": user,
+		}).
+		Where(sq.Gt{
+			"expires_at": now,
+		}).
+		OrderBy("seat").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve hold query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res := &Resource{Token: token, User_ID: user}
+
+	for rows.Next() {
+		var seat Seat
+
+		err = rows.Scan(&res.Session_ID, &seat.Row, &seat.Number, &seat.Seat, &res.Expires_at)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into hold structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		res.Seats = append(res.Seats, seat)
+	}
+
+	if len(res.Seats) == 0 {
+		return nil, nil
+	}
+
+	return res, nil
+}
+
+// Delete hold of user in storage
+func (r *Repository) Delete(token string, user int64, ctx context.Context) error {
+
+	_, err := sq.
+		Delete("seat_holds").
+		Where(sq.Eq{
+			"token":   token,
+			"user_id": user,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete hold query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// HeldSeats returns seats under active hold for session
+func (r *Repository) HeldSeats(id int64, now time.Time, ctx context.Context, tx *sql.Tx) ([]int64, error) {
+
+	rows, err := sq.
+		Select("seat").
+		From("seat_holds").
+		Where(sq.Eq{
+			"session_id": id,
+		}).
+		Where(sq.Gt{
+			"expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run HeldSeats query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []int64
+
+	for rows.Next() {
+		var seat int64
+
+		err = rows.Scan(&seat)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into held seats.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, seat)
+	}
+
+	return data, nil
+}
+
+// Consume removes active hold of seat held by user, reports if hold existed
+func (r *Repository) Consume(token string, user int64, id int64, seat int64, now time.Time, ctx context.Context, tx *sql.Tx) (bool, error) {
+
+	res, err := sq.
+		Delete("seat_holds").
+		Where(sq.Eq{
+			"token":      token,
+			"user_id":    user,
+			"session_id": id,
+			"seat":       seat,
+		}).
+		Where(sq.Gt{
+			"expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Consume hold query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count consumed holds.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return rows > 0, nil
+}
+
+// Active locks user against concurrent holds and counts holds of user which haven't expired
+func (r *Repository) Active(user int64, now time.Time, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	err := sq.
+		Select("id").
+		From("users").
+		Where(sq.Eq{
+			"id": user,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock hold owner query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	var count int64
+
+	err = sq.
+		Select("COUNT(DISTINCT token)").
+		From("seat_holds").
+		Where(sq.Eq{
+			"user_id": user,
+		}).
+		Where(sq.Gt{
+			"expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&count)
+
+	if err != nil {
+		r.Log.Info("Failed to run Active holds query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return count, nil
+}
+
+// Expire removes expired holds of session
+func (r *Repository) Expire(id int64, now time.Time, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Delete("seat_holds").
+		Where(sq.Eq{
+			"session_id": id,
+		}).
+		Where(sq.LtOrEq{
+			"expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Expire holds query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Release removes all expired holds and returns number of released seats
+func (r *Repository) Release(now time.Time, ctx context.Context) (int64, error) {
+
+	res, err := sq.
+		Delete("seat_holds").
+		Where(sq.LtOrEq{
+			"expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Release holds query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count released holds.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return rows, nil
+}
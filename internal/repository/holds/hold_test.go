@@ -0,0 +1,524 @@
+package hold
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var now = time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+var hold = &Resource{
+	Token:      "f00d",
+	Session_ID: 1,
+	User_ID:    7,
+	Seats: []Seat{
+		{Row: 1, Number: 2, Seat: 2},
+		{Row: 1, Number: 3, Seat: 3},
+	},
+	Expires_at: now.Add(10 * time.Minute),
+}
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name              string
+		expectedError     error
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+		object            internal.Identifiable
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("INSERT INTO seat_holds (token,session_id,user_id,seat,seat_row,seat_number,expires_at) VALUES ($1,$2,$3,$4,$5,$6,$7),($8,$9,$10,$11,$12,$13,$14)")).
+					WithArgs(hold.Token, hold.Session_ID, hold.User_ID, 2, 1, 2, hold.Expires_at, hold.Token, hold.Session_ID, hold.User_ID, 3, 1, 3, hold.Expires_at).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+			object: hold,
+		},
+		{
+			name:          "failed, seat taken",
+			expectedError: internal.ErrSeatTaken,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec("INSERT INTO seat_holds (.*)").
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+			object: hold,
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec("INSERT INTO seat_holds (.*)").
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+			object: hold,
+		},
+		{
+			name:              "failed, assertion error",
+			expectedError:     internal.ErrInternalFailure,
+			prepare:           func(sqlm2 sqlmock.Sqlmock) {},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {},
+			object:            nil,
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			err = repo.Create(ctx, tc.object, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	query := regexp.QuoteMeta("SELECT session_id, seat_row, seat_number, seat, expires_at FROM seat_holds WHERE token = $1 AND user_id = $2 AND expires_at > $3 ORDER BY seat")
+
+	testRetrieveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: hold,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(hold.Token, hold.User_ID, now).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"session_id", "seat_row", "seat_number", "seat", "expires_at"}).
+						AddRow(hold.Session_ID, 1, 2, 2, hold.Expires_at).
+						AddRow(hold.Session_ID, 1, 3, 3, hold.Expires_at))
+			},
+		},
+		{
+			name:           "failed, expired or missing",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(hold.Token, hold.User_ID, now).
+					WillReturnRows(sqlm2.NewRows([]string{"session_id", "seat_row", "seat_number", "seat", "expires_at"}))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(internal.ErrInternalFailure)
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.Retrieve(hold.Token, hold.User_ID, now, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testDeleteCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM seat_holds WHERE token = $1 AND user_id = $2")).
+					WithArgs(hold.Token, hold.User_ID).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM seat_holds WHERE token = $1 AND user_id = $2")).
+					WithArgs(hold.Token, hold.User_ID).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testDeleteCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			err = repo.Delete(hold.Token, hold.User_ID, ctx)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestHeldSeats(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT seat FROM seat_holds WHERE session_id = $1 AND expires_at > $2")
+
+	testHeldSeatsCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    []int64
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: []int64{2, 3},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(hold.Session_ID, now).
+					WillReturnRows(sqlm2.NewRows([]string{"seat"}).AddRow(2).AddRow(3))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testHeldSeatsCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			held, err := repo.HeldSeats(hold.Session_ID, now, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, held)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestConsume(t *testing.T) {
+	query := regexp.QuoteMeta("DELETE FROM seat_holds WHERE seat = $1 AND session_id = $2 AND token = $3 AND user_id = $4 AND expires_at > $5")
+
+	testConsumeCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    bool
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(2, hold.Session_ID, hold.Token, hold.User_ID, now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, hold expired",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(2, hold.Session_ID, hold.Token, hold.User_ID, now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testConsumeCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			consumed, err := repo.Consume(hold.Token, hold.User_ID, hold.Session_ID, 2, now, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, consumed)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE id = $1 FOR UPDATE")).
+		WithArgs(hold.User_ID).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(hold.User_ID))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(DISTINCT token) FROM seat_holds WHERE user_id = $1 AND expires_at > $2")).
+		WithArgs(hold.User_ID, now).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectCommit()
+
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	active, err := repo.Active(hold.User_ID, now, context.Background(), tx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), active)
+
+	assert.NoError(t, tx.Commit())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelease(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	query := regexp.QuoteMeta("DELETE FROM seat_holds WHERE expires_at <= $1")
+
+	testReleaseCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult int64
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: 3,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(now).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testReleaseCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			released, err := repo.Release(now, ctx)
+			assert.Equal(t, tc.expectedResult, released)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestGID(t *testing.T) {
+	res := &Resource{Session_ID: hold.Session_ID}
+	assert.Equal(t, hold.Session_ID, res.GID())
+}
@@ -0,0 +1,376 @@
+package layout
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+// Standard is a category of regular seat
+const Standard = "standard"
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	Hall_ID int64 `json:"hall_id"`
+	Rows    []Row `json:"rows"`
+}
+
+// Row is a struct to store seats of a single hall row
+type Row struct {
+	Row   int64  `json:"row"`
+	Seats []Seat `json:"seats"`
+}
+
+// Seat is a struct to store data about single hall seat
+type Seat struct {
+	Number     int64  `json:"number"`
+	Category   string `json:"category"`
+	Wheelchair bool   `json:"wheelchair"`
+	Blocked    bool   `json:"blocked"`
+	Aisle      bool   `json:"aisle"` // Aisle gap goes after this seat
+}
+
+func (r *Resource) GID() int64 {
+	return r.Hall_ID
+}
+
+// Grid builds layout of equal standard rows for halls without layout
+func Grid(hall, rows, seats int64) *Resource {
+	res := &Resource{Hall_ID: hall}
+
+	if rows < 1 {
+		rows = 1
+	}
+
+	length := (seats + rows - 1) / rows
+
+	for seat := int64(0); seat < seats; seat++ {
+		if seat%length == 0 {
+			res.Rows = append(res.Rows, Row{Row: seat/length + 1})
+		}
+
+		row := &res.Rows[len(res.Rows)-1]
+		row.Seats = append(row.Seats, Seat{Number: seat%length + 1, Category: Standard})
+	}
+
+	return res
+}
+
+// Capacity counts seats available for sale
+func (r *Resource) Capacity() int64 {
+	var capacity int64
+
+	for _, row := range r.Rows {
+		for _, seat := range row.Seats {
+			if !seat.Blocked {
+				capacity++
+			}
+		}
+	}
+
+	return capacity
+}
+
+// Seat finds seat in layout and returns its position counted from the first row
+func (r *Resource) Seat(row, number int64) (int64, *Seat) {
+	var position int64
+
+	for i := range r.Rows {
+		for j := range r.Rows[i].Seats {
+			position++
+
+			if r.Rows[i].Row == row && r.Rows[i].Seats[j].Number == number {
+				return position, &r.Rows[i].Seats[j]
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// Create replaces hall layout and capacity within transaction, caller commits or rolls it back
+func (r *Repository) Create(layout *Resource, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Delete("hall_seats").
+		Where(sq.Eq{
+			"hall_id": layout.Hall_ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete layout query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	insert := sq.
+		Insert("hall_seats").
+		Columns("hall_id", "seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle")
+
+	for _, row := range layout.Rows {
+		for _, seat := range row.Seats {
+			insert = insert.Values(layout.Hall_ID, row.Row, seat.Number, seat.Category, seat.Wheelchair, seat.Blocked, seat.Aisle)
+		}
+	}
+
+	_, err = insert.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Create layout query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	_, err = sq.
+		Update("halls").
+		Set("seats", layout.Capacity()).
+		Set("rows", len(layout.Rows)).
+		Where(sq.Eq{
+			"id": layout.Hall_ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Update hall capacity query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Retrieve entity from storage
+func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+
+	rows, err := sq.
+		Select("seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle").
+		From("hall_seats").
+		Where(sq.Eq{
+			"hall_id": id,
+		}).
+		OrderBy("seat_row", "seat_number").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve layout query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res, err := r.scan(id, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, nil
+	}
+
+	return res, nil
+}
+
+// RetrieveBySession gets layout of hall where session takes place,
+// hall stays locked for share so its seats don't change until transaction ends
+func (r *Repository) RetrieveBySession(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+	var hall, count, seats sql.NullInt64
+
+	err := sq.
+		Select("halls.id", "halls.rows", "halls.seats").
+		From("sessions").
+		Join("halls ON sessions.hall_id = halls.id").
+		Where(sq.Eq{
+			"sessions.id": id,
+		}).
+		Suffix("FOR SHARE OF halls").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&hall, &count, &seats)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve session hall query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	rows, err := sq.
+		Select("seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle").
+		From("hall_seats").
+		Where(sq.Eq{
+			"hall_id": hall.Int64,
+		}).
+		OrderBy("seat_row", "seat_number").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve layout query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res, err := r.scan(hall.Int64, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return Grid(hall.Int64, count.Int64, seats.Int64), nil
+	}
+
+	return res, nil
+}
+
+// Delete entity in storage within transaction
+func (r *Repository) Delete(id int64, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Delete("hall_seats").
+		Where(sq.Eq{
+			"hall_id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete layout query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// InUse checks if hall has tickets sold or seats held for upcoming sessions,
+// their seat positions are indexes of current layout
+func (r *Repository) InUse(id int64, now time.Time, ctx context.Context, tx *sql.Tx) (bool, error) {
+	var sold, held int64
+
+	err := sq.
+		Select("COUNT(tickets.id)").
+		From("tickets").
+		Join("sessions ON tickets.session_id = sessions.id").
+		Where(sq.Eq{
+			"sessions.hall_id": id,
+		}).
+		Where(sq.NotEq{
+			"tickets.status": t.Refunded,
+		}).
+		Where("sessions.starts_at > NOW()").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&sold)
+
+	if err != nil {
+		r.Log.Info("Failed to run layout InUse query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	if sold > 0 {
+		return true, nil
+	}
+
+	err = sq.
+		Select("COUNT(seat_holds.id)").
+		From("seat_holds").
+		Join("sessions ON seat_holds.session_id = sessions.id").
+		Where(sq.Eq{
+			"sessions.hall_id": id,
+		}).
+		Where(sq.Gt{
+			"seat_holds.expires_at": now,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&held)
+
+	if err != nil {
+		r.Log.Info("Failed to run layout held seats query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return held > 0, nil
+}
+
+// scan groups seat rows into layout, returns nil when hall has no seats
+func (r *Repository) scan(hall int64, rows *sql.Rows) (*Resource, error) {
+	res := &Resource{Hall_ID: hall}
+
+	for rows.Next() {
+		var row int64
+		var seat Seat
+
+		err := rows.Scan(&row, &seat.Number, &seat.Category, &seat.Wheelchair, &seat.Blocked, &seat.Aisle)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into layout structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		if len(res.Rows) == 0 || res.Rows[len(res.Rows)-1].Row != row {
+			res.Rows = append(res.Rows, Row{Row: row})
+		}
+
+		last := &res.Rows[len(res.Rows)-1]
+		last.Seats = append(last.Seats, seat)
+	}
+
+	if len(res.Rows) == 0 {
+		return nil, nil
+	}
+
+	return res, nil
+}
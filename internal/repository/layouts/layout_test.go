@@ -0,0 +1,513 @@
+package layout
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var layout = &Resource{
+	Hall_ID: 15,
+	Rows: []Row{
+		{
+			Row: 1,
+			Seats: []Seat{
+				{Number: 1, Category: Standard, Wheelchair: true},
+				{Number: 2, Category: Standard, Aisle: true},
+				{Number: 3, Category: "premium", Blocked: true},
+			},
+		},
+		{
+			Row: 2,
+			Seats: []Seat{
+				{Number: 1, Category: "love_seat"},
+			},
+		},
+	},
+}
+
+const selectSeats = "SELECT seat_row, seat_number, category, wheelchair, blocked, aisle FROM hall_seats WHERE hall_id = $1 ORDER BY seat_row, seat_number"
+
+func layoutRows(sqlm2 sqlmock.Sqlmock) *sqlmock.Rows {
+	rows := sqlm2.NewRows([]string{"seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle"})
+
+	for _, row := range layout.Rows {
+		for _, seat := range row.Seats {
+			rows.AddRow(row.Row, seat.Number, seat.Category, seat.Wheelchair, seat.Blocked, seat.Aisle)
+		}
+	}
+
+	return rows
+}
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	testCreateCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(layout.Hall_ID).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+				sqlm2.ExpectExec("INSERT INTO hall_seats (.*)").
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				sqlm2.ExpectExec(regexp.QuoteMeta("UPDATE halls SET seats = $1, rows = $2 WHERE id = $3")).
+					WithArgs(3, 2, layout.Hall_ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(layout.Hall_ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlm2.ExpectExec("INSERT INTO hall_seats (.*)").
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+		{
+			name:          "failed, capacity error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(layout.Hall_ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlm2.ExpectExec("INSERT INTO hall_seats (.*)").
+					WillReturnResult(sqlmock.NewResult(0, 4))
+				sqlm2.ExpectExec(regexp.QuoteMeta("UPDATE halls SET seats = $1, rows = $2 WHERE id = $3")).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+			err = repo.Create(layout, ctx, tx)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testRetrieveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: layout,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(selectSeats)).
+					WithArgs(layout.Hall_ID).
+					WillReturnRows(layoutRows(sqlm2))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(selectSeats)).
+					WillReturnError(internal.ErrInternalFailure)
+			},
+		},
+		{
+			name:           "failed, no layout",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(selectSeats)).
+					WillReturnRows(sqlm2.NewRows([]string{"seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle"}))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.Retrieve(layout.Hall_ID, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieveBySession(t *testing.T) {
+	testRetrieveBySessionCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    *Resource
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: layout,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.id, halls.rows, halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1 FOR SHARE OF halls")).
+					WithArgs(1).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"id", "rows", "seats"}).
+						AddRow(layout.Hall_ID, 2, 3))
+				sqlm2.ExpectQuery(regexp.QuoteMeta(selectSeats)).
+					WithArgs(layout.Hall_ID).
+					WillReturnRows(layoutRows(sqlm2))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "success, grid without layout",
+			expectedError:  nil,
+			expectedResult: Grid(layout.Hall_ID, 2, 3),
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.id, halls.rows, halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1 FOR SHARE OF halls")).
+					WithArgs(1).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"id", "rows", "seats"}).
+						AddRow(layout.Hall_ID, 2, 3))
+				sqlm2.ExpectQuery(regexp.QuoteMeta(selectSeats)).
+					WithArgs(layout.Hall_ID).
+					WillReturnRows(sqlm2.NewRows([]string{"seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle"}))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, no session",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.id, halls.rows, halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1 FOR SHARE OF halls")).
+					WithArgs(1).
+					WillReturnRows(sqlm2.NewRows(nil))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.id, halls.rows, halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1 FOR SHARE OF halls")).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveBySessionCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			res, err := repo.RetrieveBySession(1, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testDeleteCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(layout.Hall_ID).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(layout.Hall_ID).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testDeleteCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+			err = repo.Delete(layout.Hall_ID, ctx, tx)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestInUse(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	now := time.Date(2022, time.January, 1, 8, 0, 0, 0, time.UTC)
+	sold := regexp.QuoteMeta("SELECT COUNT(tickets.id) FROM tickets JOIN sessions ON tickets.session_id = sessions.id WHERE sessions.hall_id = $1 AND tickets.status <> $2 AND sessions.starts_at > NOW()")
+	held := regexp.QuoteMeta("SELECT COUNT(seat_holds.id) FROM seat_holds JOIN sessions ON seat_holds.session_id = sessions.id WHERE sessions.hall_id = $1 AND seat_holds.expires_at > $2")
+
+	testInUseCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult bool
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success, tickets sold",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(sold).
+					WithArgs(layout.Hall_ID, "refunded").
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(2))
+			},
+		},
+		{
+			name:           "success, seats held",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(sold).
+					WithArgs(layout.Hall_ID, "refunded").
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
+				sqlm2.ExpectQuery(held).
+					WithArgs(layout.Hall_ID, now).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
+			},
+		},
+		{
+			name:           "success, no tickets or holds",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(sold).
+					WithArgs(layout.Hall_ID, "refunded").
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
+				sqlm2.ExpectQuery(held).
+					WithArgs(layout.Hall_ID, now).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(sold).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+		{
+			name:           "failed, holds database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(sold).
+					WithArgs(layout.Hall_ID, "refunded").
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
+				sqlm2.ExpectQuery(held).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testInUseCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+			inUse, err := repo.InUse(layout.Hall_ID, now, ctx, tx)
+			assert.Equal(t, tc.expectedResult, inUse)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestGrid(t *testing.T) {
+	grid := Grid(1, 2, 5)
+
+	assert.Len(t, grid.Rows, 2)
+	assert.Len(t, grid.Rows[0].Seats, 3)
+	assert.Len(t, grid.Rows[1].Seats, 2)
+	assert.Equal(t, int64(5), grid.Capacity())
+}
+
+func TestSeat(t *testing.T) {
+	position, seat := layout.Seat(2, 1)
+	assert.Equal(t, int64(4), position)
+	assert.Equal(t, "love_seat", seat.Category)
+
+	position, seat = layout.Seat(3, 1)
+	assert.Equal(t, int64(0), position)
+	assert.Nil(t, seat)
+
+	assert.Equal(t, int64(3), layout.Capacity())
+}
+
+func TestGID(t *testing.T) {
+	res := &Resource{Hall_ID: layout.Hall_ID}
+	assert.Equal(t, layout.Hall_ID, res.GID())
+}
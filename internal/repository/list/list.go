@@ -0,0 +1,208 @@
+// Package list applies page, sort and filters requested by clients to storage selects.
+package list
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+// Kinds of field values
+const (
+	Int  = "int"
+	Bool = "bool"
+	Text = "text"
+	Time = "time"
+)
+
+// Field is a struct to store column clients sort or filter list by
+type Field struct {
+	Column string // Column of listed table, of joined ones only when list isn't counted by Total
+	Kind   string
+	Match  string                             // Condition with one placeholder filtering by related tables, field can't be sorted by
+	Key    func(internal.Identifiable) string // Field value of listed item in filter format, field can be sorted by when set
+}
+
+// Fields maps field names clients use to columns, list can always be sorted by id
+type Fields map[string]Field
+
+// Select applies query filters, order and cursor to select from table.
+// One more row than limit is selected to tell if there's next page,
+// cursor keeps sort value and id of last item, so next page doesn't depend on it still existing.
+// Cursor is accepted only by list sorted by the same field in the same direction.
+// Empty limit is set to default.
+func Select(b sq.SelectBuilder, table string, q *internal.Query, fields Fields) (sq.SelectBuilder, error) {
+	if q.Limit == 0 {
+		q.Limit = internal.DefaultLimit
+	}
+
+	b, err := Filter(b, q, fields)
+	if err != nil {
+		return b, err
+	}
+
+	id := table + ".id"
+	by := id
+
+	field, err := sortField(q, fields)
+	if err != nil {
+		return b, err
+	}
+
+	if field != nil {
+		by = field.Column
+	}
+
+	direction, after := " ASC", ">"
+	if q.Desc {
+		direction, after = " DESC", "<"
+	}
+
+	if q.Cursor != "" {
+		last, err := internal.DecodeCursor(q.Cursor)
+		if err != nil {
+			return b, err
+		}
+
+		if last.Sort != sortName(q) {
+			return b, fmt.Errorf("%w: cursor belongs to list sorted by other field", internal.ErrValidationFailed)
+		}
+
+		if last.Desc != q.Desc {
+			return b, fmt.Errorf("%w: cursor belongs to list sorted in other direction", internal.ErrValidationFailed)
+		}
+
+		if field == nil {
+			b = b.Where(id+" "+after+" ?", last.ID)
+		} else {
+			key, err := parse(field.Kind, last.Key)
+			if err != nil {
+				return b, fmt.Errorf("%w: invalid cursor", internal.ErrValidationFailed)
+			}
+
+			b = b.Where(fmt.Sprintf("(%s, %s) %s (?, ?)", by, id, after), key, last.ID)
+		}
+	}
+
+	if by != id {
+		b = b.OrderBy(by + direction)
+	}
+
+	return b.OrderBy(id + direction).Limit(q.Limit + 1), nil
+}
+
+// sortField returns field list is sorted by, nil when it's sorted by id
+func sortField(q *internal.Query, fields Fields) (*Field, error) {
+	if sortName(q) == "" {
+		return nil, nil
+	}
+
+	field, ok := fields[q.Sort]
+	if !ok || field.Column == "" || field.Key == nil {
+		return nil, fmt.Errorf("%w: list can't be sorted by %q", internal.ErrValidationFailed, q.Sort)
+	}
+
+	return &field, nil
+}
+
+// sortName returns name of field list is sorted by, empty for id
+func sortName(q *internal.Query) string {
+	if q.Sort == "id" {
+		return ""
+	}
+
+	return q.Sort
+}
+
+// Filter applies query filters to select
+func Filter(b sq.SelectBuilder, q *internal.Query, fields Fields) (sq.SelectBuilder, error) {
+	names := make([]string, 0, len(q.Filters))
+	for name := range q.Filters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			return b, fmt.Errorf("%w: list can't be filtered by %q", internal.ErrValidationFailed, name)
+		}
+
+		value, err := parse(field.Kind, q.Filters[name])
+		if err != nil {
+			return b, fmt.Errorf("%w: wrong %s filter value %q", internal.ErrValidationFailed, name, q.Filters[name])
+		}
+
+		if field.Match != "" {
+			b = b.Where(field.Match, value)
+			continue
+		}
+
+		b = b.Where(sq.Eq{field.Column: value})
+	}
+
+	return b, nil
+}
+
+// Total counts table rows matching query filters
+func Total(runner sq.BaseRunner, table string, q *internal.Query, fields Fields, ctx context.Context) (int64, error) {
+	var total int64
+
+	b, err := Filter(sq.Select("COUNT(*)").From(table), q, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	err = b.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(runner).
+		QueryRowContext(ctx).
+		Scan(&total)
+
+	return total, err
+}
+
+// Page cuts extra row selected to detect next page and fills page details
+func Page(data []internal.Identifiable, q *internal.Query, fields Fields, total int64) *internal.Page {
+	page := &internal.Page{Data: data, Meta: internal.Meta{Limit: q.Limit, Total: total}}
+
+	if uint64(len(data)) > q.Limit {
+		page.Data = data[:q.Limit]
+
+		last := page.Data[q.Limit-1]
+		c := internal.Cursor{Sort: sortName(q), Desc: q.Desc, ID: last.GID()}
+
+		if field, ok := fields[c.Sort]; ok && field.Key != nil {
+			c.Key = field.Key(last)
+		}
+
+		page.Meta.Next = internal.EncodeCursor(c)
+	}
+
+	if page.Data == nil {
+		page.Data = []internal.Identifiable{}
+	}
+
+	return page
+}
+
+// parse converts filter value to field kind
+func parse(kind string, value string) (interface{}, error) {
+	switch kind {
+	case Int:
+		return strconv.ParseInt(value, 10, 64)
+	case Bool:
+		return strconv.ParseBool(value)
+	case Time:
+		return time.Parse(time.RFC3339, value)
+	default:
+		return value, nil
+	}
+}
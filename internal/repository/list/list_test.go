@@ -0,0 +1,184 @@
+package list
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+type item struct {
+	id   int64
+	rows int64
+}
+
+func (i *item) GID() int64 {
+	return i.id
+}
+
+var fields = Fields{
+	"vip":     {Column: "halls.vip", Kind: Bool},
+	"rows":    {Column: "halls.rows", Kind: Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*item).rows, 10) }},
+	"session": {Kind: Int, Match: "EXISTS (SELECT 1 FROM sessions WHERE sessions.hall_id = halls.id AND sessions.id = ?)"},
+}
+
+func TestSelect(t *testing.T) {
+	testSelectCases := []struct {
+		name          string
+		query         *internal.Query
+		expectedSQL   string
+		expectedArgs  []interface{}
+		expectedError error
+	}{
+		{
+			name:         "default page",
+			query:        &internal.Query{},
+			expectedSQL:  "SELECT id FROM halls ORDER BY halls.id ASC LIMIT 21",
+			expectedArgs: nil,
+		},
+		{
+			name:         "filters and cursor",
+			query:        &internal.Query{Limit: 5, Cursor: internal.EncodeCursor(internal.Cursor{ID: 7}), Filters: map[string]string{"vip": "true", "rows": "3"}},
+			expectedSQL:  "SELECT id FROM halls WHERE halls.rows = $1 AND halls.vip = $2 AND halls.id > $3 ORDER BY halls.id ASC LIMIT 6",
+			expectedArgs: []interface{}{int64(3), true, int64(7)},
+		},
+		{
+			name:         "sorted descending after cursor",
+			query:        &internal.Query{Limit: 5, Cursor: internal.EncodeCursor(internal.Cursor{Sort: "rows", Desc: true, Key: "4", ID: 7}), Sort: "rows", Desc: true},
+			expectedSQL:  "SELECT id FROM halls WHERE (halls.rows, halls.id) < ($1, $2) ORDER BY halls.rows DESC, halls.id DESC LIMIT 6",
+			expectedArgs: []interface{}{int64(4), int64(7)},
+		},
+		{
+			name:          "cursor of list sorted by other field",
+			query:         &internal.Query{Cursor: internal.EncodeCursor(internal.Cursor{ID: 7}), Sort: "rows"},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "cursor of list sorted in other direction",
+			query:         &internal.Query{Cursor: internal.EncodeCursor(internal.Cursor{Sort: "rows", Key: "4", ID: 7}), Sort: "rows", Desc: true},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "wrong cursor sort value",
+			query:         &internal.Query{Cursor: internal.EncodeCursor(internal.Cursor{Sort: "rows", Key: "many", ID: 7}), Sort: "rows"},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "sort by field without key",
+			query:         &internal.Query{Sort: "vip"},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:         "filter by related table",
+			query:        &internal.Query{Limit: 5, Filters: map[string]string{"session": "4", "vip": "false"}},
+			expectedSQL:  "SELECT id FROM halls WHERE EXISTS (SELECT 1 FROM sessions WHERE sessions.hall_id = halls.id AND sessions.id = $1) AND halls.vip = $2 ORDER BY halls.id ASC LIMIT 6",
+			expectedArgs: []interface{}{int64(4), false},
+		},
+		{
+			name:          "sort by related table",
+			query:         &internal.Query{Sort: "session"},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "unknown sort field",
+			query:         &internal.Query{Sort: "seats"},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "unknown filter",
+			query:         &internal.Query{Filters: map[string]string{"seats": "10"}},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "wrong filter value",
+			query:         &internal.Query{Filters: map[string]string{"vip": "maybe"}},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "invalid cursor",
+			query:         &internal.Query{Cursor: "!"},
+			expectedError: internal.ErrValidationFailed,
+		},
+	}
+
+	for _, tc := range testSelectCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := Select(sq.Select("id").From("halls"), "halls", tc.query, fields)
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError))
+				return
+			}
+
+			assert.Nil(t, err)
+
+			query, args, err := b.PlaceholderFormat(sq.Dollar).ToSql()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedSQL, query)
+			assert.Equal(t, tc.expectedArgs, args)
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	testPageCases := []struct {
+		name           string
+		data           []internal.Identifiable
+		sort           string
+		desc           bool
+		expectedResult *internal.Page
+	}{
+		{
+			name: "next page exists",
+			data: []internal.Identifiable{&item{id: 1}, &item{id: 2}, &item{id: 3}},
+			expectedResult: &internal.Page{
+				Data: []internal.Identifiable{&item{id: 1}, &item{id: 2}},
+				Meta: internal.Meta{Limit: 2, Total: 3, Next: internal.EncodeCursor(internal.Cursor{ID: 2})},
+			},
+		},
+		{
+			name: "next page of sorted list",
+			data: []internal.Identifiable{&item{id: 3, rows: 1}, &item{id: 1, rows: 2}, &item{id: 2, rows: 2}},
+			sort: "rows",
+			expectedResult: &internal.Page{
+				Data: []internal.Identifiable{&item{id: 3, rows: 1}, &item{id: 1, rows: 2}},
+				Meta: internal.Meta{Limit: 2, Total: 3, Next: internal.EncodeCursor(internal.Cursor{Sort: "rows", Key: "2", ID: 1})},
+			},
+		},
+		{
+			name: "next page of list sorted descending",
+			data: []internal.Identifiable{&item{id: 2, rows: 2}, &item{id: 1, rows: 2}, &item{id: 3, rows: 1}},
+			sort: "rows",
+			desc: true,
+			expectedResult: &internal.Page{
+				Data: []internal.Identifiable{&item{id: 2, rows: 2}, &item{id: 1, rows: 2}},
+				Meta: internal.Meta{Limit: 2, Total: 3, Next: internal.EncodeCursor(internal.Cursor{Sort: "rows", Desc: true, Key: "2", ID: 1})},
+			},
+		},
+		{
+			name: "last page",
+			data: []internal.Identifiable{&item{id: 3}},
+			expectedResult: &internal.Page{
+				Data: []internal.Identifiable{&item{id: 3}},
+				Meta: internal.Meta{Limit: 2, Total: 3},
+			},
+		},
+		{
+			name: "empty list",
+			data: nil,
+			expectedResult: &internal.Page{
+				Data: []internal.Identifiable{},
+				Meta: internal.Meta{Limit: 2, Total: 3},
+			},
+		},
+	}
+
+	for _, tc := range testPageCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, Page(tc.data, &internal.Query{Limit: 2, Sort: tc.sort, Desc: tc.desc}, fields, 3))
+		})
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
 )
 
 // Repository is a struct to store DB and logger connection
@@ -18,63 +20,94 @@ type Repository struct {
 
 // Resource is a struct to store data about entity
 type Resource struct {
-	ID       int64  `json:"ID"`
-	Name     string `json:"Name"`
-	Duration string `json:"Duration"`
+	ID           int64    `json:"ID"`
+	Name         string   `json:"Name"`
+	Duration     string   `json:"Duration"`
+	Description  string   `json:"description,omitempty"`
+	Release_date string   `json:"release_date,omitempty"` // 2006-01-02
+	Age_rating   string   `json:"age_rating,omitempty"`   // One of Ratings, unrated when empty
+	Genres       []string `json:"genres,omitempty"`
+	Language     string   `json:"language,omitempty"`  // Original language
+	Subtitles    []string `json:"subtitles,omitempty"` // Languages of subtitled screenings
+	Dubbing      []string `json:"dubbing,omitempty"`   // Languages of dubbed screenings
+	Director     string   `json:"director,omitempty"`
+	Cast         []string `json:"cast,omitempty"`
+	Poster       string   `json:"poster,omitempty"`  // Poster image URL
+	Trailer      string   `json:"trailer,omitempty"` // Trailer video URL
+
+	Version int64 `json:"-"` // Grows with every change, expected version when updating
 }
 
 func (r *Resource) GID() int64 {
 	return r.ID
 }
 
-// Create new entity in storage
-func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
-	var id int
+func (r *Resource) Revision() int64 {
+	return r.Version
+}
 
-	movie, ok := i.(*Resource)
-	if !ok {
-		r.Log.Info("Failed to create movie object.",
-			zap.Bool("ok", ok),
-		)
+// Ratings maps age ratings to minimum age of viewers
+var Ratings = map[string]int{
+	"0+":  0,
+	"6+":  6,
+	"12+": 12,
+	"16+": 16,
+	"18+": 18,
+}
 
-		return nil, internal.ErrInternalFailure
-	}
+// MinimumAge returns age viewers of movie with rating must reach, zero for unrated movies
+func MinimumAge(rating string) int {
+	return Ratings[rating]
+}
+
+// columns of movie with its genres in name order
+var columns = []string{
+	"name", "duration", "id", "version", "description", "COALESCE(to_char(release_date, 'YYYY-MM-DD'), '')",
+	"age_rating", "language", "subtitles", "dubbing", "director", "cast_members", "poster", "trailer",
+	"ARRAY(SELECT genres.name FROM movie_genres JOIN genres ON movie_genres.genre_id = genres.id WHERE movie_genres.movie_id = movies.id ORDER BY genres.name)",
+}
+
+// Insert stores movie within transaction and returns its id, genres are stored with SetGenres
+func (r *Repository) Insert(movie *Resource, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
 
 	err := sq.
 		Insert("movies").
-		Columns("name", "duration").
-		Values(movie.Name, movie.Duration).
+		Columns("name", "duration", "description", "release_date", "age_rating", "language",
+			"subtitles", "dubbing", "director", "cast_members", "poster", "trailer").
+		Values(movie.Name, movie.Duration, movie.Description, nullString(movie.Release_date), movie.Age_rating, movie.Language,
+			array(movie.Subtitles), array(movie.Dubbing), movie.Director, array(movie.Cast), movie.Poster, movie.Trailer).
 		Suffix("RETURNING \"id\"").
 		PlaceholderFormat(sq.Dollar).
-		RunWith(r.DB).
+		RunWith(tx).
 		QueryRowContext(ctx).
 		Scan(&id)
 
 	if err != nil {
-		r.Log.Info("Failed to run Create movie query.",
+		r.Log.Info("Failed to run Insert movie query.",
 			zap.Error(err),
 		)
 
-		return nil, internal.ErrInternalFailure
+		return 0, internal.ErrInternalFailure
 	}
 
-	return r.Retrieve(int64(id), ctx)
+	return id, nil
 }
 
 // Retrieve entity from storage
 func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
-	var res Resource
 
-	err := sq.
-		Select("name", "duration", "id").
+	row := sq.
+		Select(columns...).
 		From("movies").
 		Where(sq.Eq{
 			"id": id,
 		}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
-		QueryRowContext(ctx).
-		Scan(&res.Name, &res.Duration, &res.ID)
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
 
 	if err == sql.ErrNoRows {
 
@@ -89,18 +122,142 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 		return nil, internal.ErrInternalFailure
 	}
 
-	return &res, nil
+	return res, nil
+}
+
+// Update stores movie details within transaction, reports false when there's no such movie.
+// Movie with version set is updated only while stored version is the same.
+func (r *Repository) Update(movie *Resource, ctx context.Context, tx *sql.Tx) (bool, error) {
+	where := sq.Eq{
+		"id": movie.ID,
+	}
+
+	if movie.Version > 0 {
+		where["version"] = movie.Version
+	}
+
+	result, err := sq.
+		Update("movies").
+		Set("name", movie.Name).
+		Set("duration", movie.Duration).
+		Set("description", movie.Description).
+		Set("release_date", nullString(movie.Release_date)).
+		Set("age_rating", movie.Age_rating).
+		Set("language", movie.Language).
+		Set("subtitles", array(movie.Subtitles)).
+		Set("dubbing", array(movie.Dubbing)).
+		Set("director", movie.Director).
+		Set("cast_members", array(movie.Cast)).
+		Set("poster", movie.Poster).
+		Set("trailer", movie.Trailer).
+		Where(where).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Update movie query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count updated movies.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return updated > 0, nil
 }
 
-// Delete entity in storage
-func (r *Repository) Delete(id int64, ctx context.Context) error {
+// SetGenres replaces genres of movie within transaction, genres seen first time are added to catalog
+func (r *Repository) SetGenres(id int64, genres []string, ctx context.Context, tx *sql.Tx) error {
 
 	_, err := sq.
-		Delete("movies").
+		Delete("movie_genres").
 		Where(sq.Eq{
-			"id": id,
+			"movie_id": id,
 		}).
 		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete movie genres query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if len(genres) == 0 {
+		return nil
+	}
+
+	insert := sq.Insert("genres").Columns("name")
+	for _, genre := range genres {
+		insert = insert.Values(genre)
+	}
+
+	_, err = insert.
+		Suffix("ON CONFLICT (name) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert genres query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	_, err = sq.
+		Insert("movie_genres").
+		Columns("movie_id", "genre_id").
+		Select(sq.
+			Select().
+			Column("?", id).
+			Column("id").
+			From("genres").
+			Where(sq.Eq{
+				"name": genres,
+			})).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert movie genres query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Delete entity in storage, version when set must match current one
+func (r *Repository) Delete(id int64, version int64, ctx context.Context) error {
+	where := sq.Eq{
+		"id": id,
+	}
+
+	if version > 0 {
+		where["version"] = version
+	}
+
+	result, err := sq.
+		Delete("movies").
+		Where(where).
+		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		ExecContext(ctx)
 
@@ -112,15 +269,40 @@ func (r *Repository) Delete(id int64, ctx context.Context) error {
 		return internal.ErrInternalFailure
 	}
 
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count deleted movies.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if deleted == 0 && version > 0 {
+		return internal.ErrVersionMismatch
+	}
+
 	return nil
 }
 
-// RetrieveAll entity from storage
-func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
+// fields movies list can be sorted and filtered by
+var fields = list.Fields{
+	"name":       {Column: "movies.name", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Name }},
+	"age_rating": {Column: "movies.age_rating", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Age_rating }},
+	"language":   {Column: "movies.language", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Language }},
+	"genre": {Kind: list.Text, Match: "EXISTS (SELECT 1 FROM movie_genres JOIN genres ON movie_genres.genre_id = genres.id " +
+		"WHERE movie_genres.movie_id = movies.id AND genres.name = lower(?))"},
+}
 
-	rows, err := sq.
-		Select("name", "duration", "id").
-		From("movies").
+// RetrieveAll returns page of movies matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.Select(columns...).From("movies"), "movies", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryContext(ctx)
@@ -133,16 +315,10 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		return nil, internal.ErrInternalFailure
 	}
 
-	var data []*Resource
+	var data []internal.Identifiable
 
 	for rows.Next() {
-		res := &Resource{}
-
-		err = rows.Scan(&res.Name, &res.Duration, &res.ID)
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
+		res, err := scan(rows)
 		if err != nil {
 			r.Log.Info("Failed to scan rows into movies structures.",
 				zap.Error(err),
@@ -154,11 +330,46 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		data = append(data, res)
 	}
 
-	var dataSlice []*Resource = data
-	var interfaceSlice []internal.Identifiable = make([]internal.Identifiable, len(dataSlice))
-	for i, d := range dataSlice {
-		interfaceSlice[i] = d
+	total, err := list.Total(r.DB, "movies", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count movies.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
+
+// scan reads movie with its array columns
+func scan(row sq.RowScanner) (*Resource, error) {
+	var res Resource
+
+	err := row.Scan(&res.Name, &res.Duration, &res.ID, &res.Version, &res.Description, &res.Release_date,
+		&res.Age_rating, &res.Language, pq.Array(&res.Subtitles), pq.Array(&res.Dubbing), &res.Director,
+		pq.Array(&res.Cast), &res.Poster, &res.Trailer, pq.Array(&res.Genres))
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// nullString stores empty text as NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
+// array stores missing list as empty array
+func array(values []string) interface{} {
+	if values == nil {
+		values = []string{}
 	}
 
-	return interfaceSlice, nil
+	return pq.Array(values)
 }
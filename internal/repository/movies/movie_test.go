@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -15,9 +16,39 @@ import (
 )
 
 var movie = &Resource{
-	ID:       15,
-	Name:     "Lord of the Rings",
-	Duration: "2h22m",
+	ID:           15,
+	Name:         "Lord of the Rings",
+	Duration:     "2h22m",
+	Description:  "Hobbit sets out to destroy the One Ring.",
+	Release_date: "2001-12-19",
+	Age_rating:   "12+",
+	Genres:       []string{"adventure", "fantasy"},
+	Language:     "en",
+	Subtitles:    []string{"ru"},
+	Dubbing:      []string{"ru", "uk"},
+	Director:     "Peter Jackson",
+	Cast:         []string{"Elijah Wood", "Ian McKellen"},
+	Poster:       "https://cdn.example.com/lotr.jpg",
+	Trailer:      "https://cdn.example.com/lotr.mp4",
+
+	Version: 1,
+}
+
+var (
+	selectMovie = regexp.QuoteMeta("SELECT name, duration, id, version, description, COALESCE(to_char(release_date, 'YYYY-MM-DD'), ''), " +
+		"age_rating, language, subtitles, dubbing, director, cast_members, poster, trailer, " +
+		"ARRAY(SELECT genres.name FROM movie_genres JOIN genres ON movie_genres.genre_id = genres.id WHERE movie_genres.movie_id = movies.id ORDER BY genres.name) " +
+		"FROM movies")
+
+	movieColumns = []string{"name", "duration", "id", "version", "description", "release_date", "age_rating", "language",
+		"subtitles", "dubbing", "director", "cast_members", "poster", "trailer", "genres"}
+)
+
+// movieRow returns movie the way storage does
+func movieRow(sqlm2 sqlmock.Sqlmock) *sqlmock.Rows {
+	return sqlm2.NewRows(movieColumns).
+		AddRow(movie.Name, movie.Duration, movie.ID, movie.Version, movie.Description, movie.Release_date, movie.Age_rating, movie.Language,
+			"{ru}", "{ru,uk}", movie.Director, `{"Elijah Wood","Ian McKellen"}`, movie.Poster, movie.Trailer, "{adventure,fantasy}")
 }
 
 func NewMock() (*sql.DB, sqlmock.Sqlmock) {
@@ -35,59 +66,70 @@ func TestCreate(t *testing.T) {
 		db.Close()
 	}()
 
+	insert := regexp.QuoteMeta("INSERT INTO movies (name,duration,description,release_date,age_rating,language,subtitles,dubbing,director,cast_members,poster,trailer) " +
+		"VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING \"id\"")
+
 	testCreateCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult internal.Identifiable
+		expectedResult int64
 		prepare        func(sqlm2 sqlmock.Sqlmock)
-		object         internal.Identifiable
+		object         *Resource
 	}{
 		{
-			name:           "failed, database error",
-			expectedError:  internal.ErrInternalFailure,
-			expectedResult: nil,
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: movie.ID,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("INSERT INTO movies (.*)").
-					WillReturnError(internal.ErrInternalFailure)
+				sqlm2.ExpectQuery(insert).
+					WithArgs(movie.Name, movie.Duration, movie.Description, movie.Release_date, movie.Age_rating, movie.Language,
+						"{\"ru\"}", "{\"ru\",\"uk\"}", movie.Director, "{\"Elijah Wood\",\"Ian McKellen\"}", movie.Poster, movie.Trailer).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(movie.ID))
 			},
 			object: movie,
 		},
 		{
-			name:           "success",
+			name:           "success, without details",
 			expectedError:  nil,
-			expectedResult: movie,
+			expectedResult: 16,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("INSERT INTO movies (.*)").
-					WillReturnRows(sqlm2.
-						NewRows([]string{"id"}).
-						AddRow(movie.ID))
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies WHERE id = \\$1").
-					WithArgs(movie.ID).
-					WillReturnRows(sqlm2.
-						NewRows([]string{"name", "duration", "id"}).
-						AddRow(movie.Name, movie.Duration, movie.ID))
+				sqlm2.ExpectQuery(insert).
+					WithArgs("Matrix", "2h16m", "", nil, "", "", "{}", "{}", "", "{}", "", "").
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(16))
 			},
-			object: movie,
+			object: &Resource{Name: "Matrix", Duration: "2h16m"},
 		},
 		{
-			name:           "failed, retrieve error",
-			expectedError:  internal.ErrInternalFailure,
-			expectedResult: nil,
+			name:           "success, rated for everyone",
+			expectedError:  nil,
+			expectedResult: 17,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("INSERT INTO movies (.*)").
-					WillReturnError(fmt.Errorf("unable to retrieve Resource"))
+				sqlm2.ExpectQuery(insert).
+					WithArgs("Up", "1h36m", "", nil, "0+", "", "{}", "{}", "", "{}", "", "").
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(17))
 			},
-			object: movie,
+			object: &Resource{Name: "Up", Duration: "1h36m", Age_rating: "0+"},
 		},
 		{
-			name:           "failed, assertion error",
+			name:           "success, rated for adults",
+			expectedError:  nil,
+			expectedResult: 18,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WithArgs("Alien", "1h57m", "", nil, "18+", "", "{}", "{}", "", "{}", "", "").
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(18))
+			},
+			object: &Resource{Name: "Alien", Duration: "1h57m", Age_rating: "18+"},
+		},
+		{
+			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
-			expectedResult: nil,
+			expectedResult: 0,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("INSERT INTO movies (.*)").
-					WillReturnError(fmt.Errorf("unable to retrieve Resource"))
+				sqlm2.ExpectQuery(insert).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
-			object: nil,
+			object: movie,
 		},
 	}
 
@@ -108,10 +150,16 @@ func TestCreate(t *testing.T) {
 			repo := &Repository{DB: db, Log: logger}
 			ctx := context.Background()
 
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
 			tc.prepare(mock)
-			res, err := repo.Create(tc.object, ctx)
+			id, err := repo.Insert(tc.object, ctx, tx)
 
-			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedResult, id)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
@@ -135,11 +183,9 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: movie,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies WHERE id = \\$1").
+				sqlm2.ExpectQuery(selectMovie + regexp.QuoteMeta(" WHERE id = $1")).
 					WithArgs(movie.ID).
-					WillReturnRows(sqlm2.
-						NewRows([]string{"name", "duration", "id"}).
-						AddRow(movie.Name, movie.Duration, movie.ID))
+					WillReturnRows(movieRow(sqlm2))
 			},
 			id: int64(movie.ID),
 		},
@@ -148,7 +194,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies WHERE id = \\$1").
+				sqlm2.ExpectQuery(selectMovie + regexp.QuoteMeta(" WHERE id = $1")).
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 			id: int64(movie.ID),
@@ -158,7 +204,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies WHERE id = \\$1").
+				sqlm2.ExpectQuery(selectMovie + regexp.QuoteMeta(" WHERE id = $1")).
 					WillReturnRows(sqlm2.
 						NewRows(nil))
 			},
@@ -201,18 +247,18 @@ func TestRetrieveAll(t *testing.T) {
 	testRetrieveAllCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult []internal.Identifiable
+		expectedResult *internal.Page
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{movie},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{movie}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 1}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies").
-					WillReturnRows(sqlm2.
-						NewRows([]string{"name", "duration", "id"}).
-						AddRow(movie.Name, movie.Duration, movie.ID))
+				sqlm2.ExpectQuery(selectMovie).
+					WillReturnRows(movieRow(sqlm2))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM movies")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
 			},
 		},
 		{
@@ -220,17 +266,19 @@ func TestRetrieveAll(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies").
+				sqlm2.ExpectQuery(selectMovie).
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
 		{
 			name:           "failed, sql no rows error",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: internal.DefaultLimit}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT name, duration, id FROM movies").
+				sqlm2.ExpectQuery(selectMovie).
 					WillReturnRows(sqlm2.NewRows([]string{}))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM movies")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
 			},
 		},
 	}
@@ -253,13 +301,136 @@ func TestRetrieveAll(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			res, err := repo.RetrieveAll(ctx)
+			res, err := repo.RetrieveAll(&internal.Query{}, ctx)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
+func TestRetrieveAllFiltered(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	where := regexp.QuoteMeta(" WHERE movies.age_rating = $1 AND EXISTS (SELECT 1 FROM movie_genres JOIN genres ON movie_genres.genre_id = genres.id " +
+		"WHERE movie_genres.movie_id = movies.id AND genres.name = lower($2))")
+
+	mock.ExpectQuery(selectMovie+where+regexp.QuoteMeta(" ORDER BY movies.id ASC LIMIT 21")).
+		WithArgs("12+", "fantasy").
+		WillReturnRows(movieRow(mock))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM movies")+where).
+		WithArgs("12+", "fantasy").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+
+	res, err := repo.RetrieveAll(&internal.Query{Filters: map[string]string{"genre": "fantasy", "age_rating": "12+"}}, ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &internal.Page{Data: []internal.Identifiable{movie}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 1}}, res)
+
+	_, err = repo.RetrieveAll(&internal.Query{Sort: "genre"}, ctx)
+	assert.ErrorIs(t, err, internal.ErrValidationFailed)
+}
+
+func TestUpdate(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	set := "UPDATE movies SET name = $1, duration = $2, description = $3, release_date = $4, age_rating = $5, language = $6, " +
+		"subtitles = $7, dubbing = $8, director = $9, cast_members = $10, poster = $11, trailer = $12 WHERE id = $13"
+	update := regexp.QuoteMeta(set + " AND version = $14")
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(update).
+		WithArgs(movie.Name, movie.Duration, movie.Description, movie.Release_date, movie.Age_rating, movie.Language,
+			"{\"ru\"}", "{\"ru\",\"uk\"}", movie.Director, "{\"Elijah Wood\",\"Ian McKellen\"}", movie.Poster, movie.Trailer, movie.ID, movie.Version).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	found, err := repo.Update(movie, ctx, tx)
+	assert.NoError(t, err)
+	assert.True(t, found)
+
+	mock.ExpectExec(update).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	found, err = repo.Update(movie, ctx, tx)
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	mock.ExpectExec(regexp.QuoteMeta(set)).
+		WithArgs(movie.Name, movie.Duration, "", nil, "", "", "{}", "{}", "", "{}", "", "", movie.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	found, err = repo.Update(&Resource{ID: movie.ID, Name: movie.Name, Duration: movie.Duration}, ctx, tx)
+	assert.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestSetGenres(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	clear := regexp.QuoteMeta("DELETE FROM movie_genres WHERE movie_id = $1")
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(clear).
+		WithArgs(movie.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO genres (name) VALUES ($1),($2) ON CONFLICT (name) DO NOTHING")).
+		WithArgs("adventure", "fantasy").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO movie_genres (movie_id,genre_id) SELECT $1, id FROM genres WHERE name IN ($2,$3)")).
+		WithArgs(movie.ID, "adventure", "fantasy").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.SetGenres(movie.ID, movie.Genres, ctx, tx)
+	assert.NoError(t, err)
+
+	mock.ExpectExec(clear).
+		WithArgs(movie.ID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.SetGenres(movie.ID, nil, ctx, tx)
+	assert.NoError(t, err)
+
+	mock.ExpectExec(clear).
+		WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+
+	err = repo.SetGenres(movie.ID, movie.Genres, ctx, tx)
+	assert.Equal(t, internal.ErrInternalFailure, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestDelete(t *testing.T) {
 	db, mock := NewMock()
 	defer func() {
@@ -272,6 +443,7 @@ func TestDelete(t *testing.T) {
 		expectedResult internal.Identifiable
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 		id             int64
+		version        int64
 	}{
 		{
 			name:           "success",
@@ -284,6 +456,30 @@ func TestDelete(t *testing.T) {
 			},
 			id: int64(movie.ID),
 		},
+		{
+			name:           "success, expected version",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM movies WHERE id = $1 AND version = $2")).
+					WithArgs(movie.ID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			id:      int64(movie.ID),
+			version: 3,
+		},
+		{
+			name:           "failed, version mismatch",
+			expectedError:  internal.ErrVersionMismatch,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM movies WHERE id = $1 AND version = $2")).
+					WithArgs(movie.ID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			id:      int64(movie.ID),
+			version: 3,
+		},
 		{
 			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
@@ -315,7 +511,7 @@ func TestDelete(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			err = repo.Delete(tc.id, ctx)
+			err = repo.Delete(tc.id, tc.version, ctx)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
@@ -325,3 +521,22 @@ func TestGID(t *testing.T) {
 	res := &Resource{ID: movie.ID}
 	assert.Equal(t, movie.ID, res.GID())
 }
+
+func TestMinimumAge(t *testing.T) {
+	testMinimumAgeCases := []struct {
+		name           string
+		rating         string
+		expectedResult int
+	}{
+		{name: "unrated", rating: "", expectedResult: 0},
+		{name: "everyone", rating: "0+", expectedResult: 0},
+		{name: "teenagers", rating: "16+", expectedResult: 16},
+		{name: "adults", rating: "18+", expectedResult: 18},
+	}
+
+	for _, tc := range testMinimumAgeCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, MinimumAge(tc.rating))
+		})
+	}
+}
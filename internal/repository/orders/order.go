@@ -0,0 +1,245 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/payments"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID         int64
+	User_ID    int64
+	Total      float64
+	Promo_Code string  `json:"Promo_Code,omitempty"`
+	Discount   float64 `json:"Discount,omitempty"` // Taken off total by promo code
+	Created_at time.Time
+	Status     string
+	Tickets    []*t.Resource
+	Payment    *p.Resource `json:"Payment,omitempty"`
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+// Order statuses
+const (
+	Pending = "pending" // Tickets are reserved until payment is captured
+	Paid    = "paid"
+	Failed  = "failed"  // Payment was declined, tickets are released
+	Expired = "expired" // Payment didn't arrive in time, tickets are released
+)
+
+// Create stores order and links its bought tickets to it
+func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	order, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create order object.",
+			zap.Bool("ok", ok),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	err := sq.
+		Insert("orders").
+		Columns("user_id", "total", "promo_code", "discount", "created_at", "status").
+		Values(order.User_ID, order.Total, nullString(order.Promo_Code), order.Discount, order.Created_at, order.Status).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err != nil {
+		r.Log.Info("Failed to run Create order query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	tickets := make([]int64, len(order.Tickets))
+	for n, ticket := range order.Tickets {
+		tickets[n] = ticket.ID
+	}
+
+	_, err = sq.
+		Update("tickets").
+		Set("order_id", id).
+		Where(sq.Eq{
+			"id": tickets,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to link tickets to order.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
+// Retrieve order without tickets from storage
+func (r *Repository) Retrieve(id int64, ctx context.Context) (*Resource, error) {
+	var res Resource
+	var promo sql.NullString
+
+	err := sq.
+		Select("id", "user_id", "total", "promo_code", "discount", "created_at", "status").
+		From("orders").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&res.ID, &res.User_ID, &res.Total, &promo, &res.Discount, &res.Created_at, &res.Status)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve order query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.Promo_Code = promo.String
+
+	return &res, nil
+}
+
+// Lock selects order for update within transaction
+func (r *Repository) Lock(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+	var res Resource
+	var promo sql.NullString
+
+	err := sq.
+		Select("id", "user_id", "total", "promo_code", "discount", "created_at", "status").
+		From("orders").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&res.ID, &res.User_ID, &res.Total, &promo, &res.Discount, &res.Created_at, &res.Status)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock order query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.Promo_Code = promo.String
+
+	return &res, nil
+}
+
+// SetStatus changes order status
+func (r *Repository) SetStatus(id int64, status string, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("orders").
+		Set("status", status).
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run SetStatus order query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Stale returns pending orders created before passed time
+func (r *Repository) Stale(before time.Time, ctx context.Context) ([]int64, error) {
+
+	rows, err := sq.
+		Select("id").
+		From("orders").
+		Where(sq.Eq{
+			"status": Pending,
+		}).
+		Where(sq.Lt{
+			"created_at": before,
+		}).
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Stale orders query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []int64
+
+	for rows.Next() {
+		var id int64
+
+		err = rows.Scan(&id)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into orders.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, id)
+	}
+
+	return data, nil
+}
+
+// nullString stores empty promo code as NULL
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
@@ -0,0 +1,281 @@
+package order
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+var order = &Resource{
+	ID:         1,
+	User_ID:    1,
+	Total:      24.4,
+	Created_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC),
+	Status:     Pending,
+	Tickets: []*t.Resource{
+		{ID: 3, Session_ID: 1, Price: 12.2},
+		{ID: 4, Session_ID: 2, Price: 12.2},
+	},
+}
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(tt *testing.T) {
+	insert := regexp.QuoteMeta(`INSERT INTO orders (user_id,total,promo_code,discount,created_at,status) VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)
+	link := regexp.QuoteMeta("UPDATE tickets SET order_id = $1 WHERE id IN ($2,$3)")
+
+	testCreateCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    int64
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: order.ID,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WithArgs(order.User_ID, order.Total, sql.NullString{}, order.Discount, order.Created_at, order.Status).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(order.ID))
+				sqlm2.ExpectExec(link).
+					WithArgs(order.ID, 3, 4).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, order insert",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:           "failed, tickets link",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WithArgs(order.User_ID, order.Total, sql.NullString{}, order.Discount, order.Created_at, order.Status).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(order.ID))
+				sqlm2.ExpectExec(link).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		tt.Run(tc.name, func(tt *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			id, err := repo.Create(ctx, order, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(tt, tc.expectedResult, id)
+			assert.Equal(tt, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieve(tt *testing.T) {
+	query := regexp.QuoteMeta("SELECT id, user_id, total, promo_code, discount, created_at, status FROM orders WHERE id = $1")
+
+	testRetrieveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *Resource
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: &Resource{ID: order.ID, User_ID: order.User_ID, Total: order.Total, Promo_Code: "SPRING", Discount: 2.4, Created_at: order.Created_at, Status: order.Status},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(order.ID).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"id", "user_id", "total", "promo_code", "discount", "created_at", "status"}).
+						AddRow(order.ID, order.User_ID, order.Total, "SPRING", 2.4, order.Created_at, order.Status))
+			},
+		},
+		{
+			name:           "failed, no order",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(order.ID).
+					WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveCases {
+		tt.Run(tc.name, func(tt *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Retrieve(order.ID, context.Background())
+
+			assert.Equal(tt, tc.expectedResult, res)
+			assert.Equal(tt, tc.expectedError, err)
+		})
+	}
+}
+
+func TestStale(tt *testing.T) {
+	query := regexp.QuoteMeta("SELECT id FROM orders WHERE status = $1 AND created_at < $2 ORDER BY id")
+	before := order.Created_at.Add(time.Hour)
+
+	testStaleCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult []int64
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: []int64{1, 2},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(Pending, before).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+			},
+		},
+		{
+			name:           "success, nothing stale",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(Pending, before).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testStaleCases {
+		tt.Run(tc.name, func(tt *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Stale(before, context.Background())
+
+			assert.Equal(tt, tc.expectedResult, res)
+			assert.Equal(tt, tc.expectedError, err)
+		})
+	}
+}
+
+func TestGID(tt *testing.T) {
+	assert.Equal(tt, order.ID, order.GID())
+}
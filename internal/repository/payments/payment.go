@@ -0,0 +1,224 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID         int64
+	Order_ID   int64
+	Provider   string
+	Reference  string // Payment id on provider side
+	Amount     float64
+	Refunded   float64 // Part of amount returned to customer
+	Status     string
+	Created_at time.Time
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+var columns = []string{"payments.id", "payments.order_id", "payments.provider", "payments.reference", "payments.amount",
+	"payments.refunded", "payments.status", "payments.created_at"}
+
+// Create new entity in storage
+func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	payment, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create payment object.",
+			zap.Bool("ok", ok),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	err := sq.
+		Insert("payments").
+		Columns("order_id", "provider", "reference", "amount", "status", "created_at").
+		Values(payment.Order_ID, payment.Provider, payment.Reference, payment.Amount, payment.Status, payment.Created_at).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err != nil {
+		r.Log.Info("Failed to run Create payment query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
+// RetrieveByOrder returns payment of order
+func (r *Repository) RetrieveByOrder(id int64, ctx context.Context) (*Resource, error) {
+
+	row := sq.
+		Select(columns...).
+		From("payments").
+		Where(sq.Eq{
+			"payments.order_id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx)
+
+	return r.one(row, "RetrieveByOrder")
+}
+
+// Lock selects payment by provider reference for update within transaction
+func (r *Repository) Lock(provider string, reference string, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+
+	row := sq.
+		Select(columns...).
+		From("payments").
+		Where(sq.Eq{
+			"payments.provider":  provider,
+			"payments.reference": reference,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx)
+
+	return r.one(row, "Lock")
+}
+
+// LockByTicket selects payment of order ticket was bought in for update within transaction
+func (r *Repository) LockByTicket(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+
+	row := sq.
+		Select(columns...).
+		From("payments").
+		Join("tickets ON tickets.order_id = payments.order_id").
+		Where(sq.Eq{
+			"tickets.id": id,
+		}).
+		Suffix("FOR UPDATE OF payments").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx)
+
+	return r.one(row, "LockByTicket")
+}
+
+// SetStatus changes payment status
+func (r *Repository) SetStatus(id int64, status string, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("payments").
+		Set("status", status).
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run SetStatus payment query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// AddRefund adds amount returned to customer to payment
+func (r *Repository) AddRefund(id int64, amount float64, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("payments").
+		Set("refunded", sq.Expr("refunded + ?", amount)).
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run AddRefund payment query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Record stores webhook event of payment, reports false when event was already received
+func (r *Repository) Record(event string, id int64, kind string, at time.Time, ctx context.Context, tx *sql.Tx) (bool, error) {
+
+	res, err := sq.
+		Insert("payment_events").
+		Columns("event_id", "payment_id", "type", "received_at").
+		Values(event, id, kind, at).
+		Suffix("ON CONFLICT (event_id) DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Record payment event query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count recorded payment events.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return rows > 0, nil
+}
+
+// one scans single payment, nil when there's no such payment
+func (r *Repository) one(row sq.RowScanner, query string) (*Resource, error) {
+	var res Resource
+
+	err := row.Scan(&res.ID, &res.Order_ID, &res.Provider, &res.Reference, &res.Amount, &res.Refunded, &res.Status, &res.Created_at)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run "+query+" payment query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &res, nil
+}
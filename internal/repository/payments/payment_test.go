@@ -0,0 +1,308 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var payment = &Resource{
+	ID:         1,
+	Order_ID:   1,
+	Provider:   "fake",
+	Reference:  "fake_1",
+	Amount:     24.4,
+	Status:     "authorized",
+	Created_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC),
+}
+
+var rows = []string{"id", "order_id", "provider", "reference", "amount", "refunded", "status", "created_at"}
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	query := regexp.QuoteMeta(`INSERT INTO payments (order_id,provider,reference,amount,status,created_at) VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)
+
+	testCreateCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    int64
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: payment.ID,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(payment.Order_ID, payment.Provider, payment.Reference, payment.Amount, payment.Status, payment.Created_at).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(payment.ID))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			id, err := repo.Create(ctx, payment, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, id)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT payments.id, payments.order_id, payments.provider, payments.reference, payments.amount, " +
+		"payments.refunded, payments.status, payments.created_at FROM payments " +
+		"WHERE payments.provider = $1 AND payments.reference = $2 FOR UPDATE")
+
+	testLockCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *Resource
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: payment,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(payment.Provider, payment.Reference).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(payment.ID, payment.Order_ID, payment.Provider, payment.Reference, payment.Amount,
+							payment.Refunded, payment.Status, payment.Created_at))
+			},
+		},
+		{
+			name:           "success, unknown payment",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(payment.Provider, payment.Reference).
+					WillReturnError(sql.ErrNoRows)
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testLockCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			res, err := repo.Lock(payment.Provider, payment.Reference, context.Background(), tx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRecord(t *testing.T) {
+	query := regexp.QuoteMeta("INSERT INTO payment_events (event_id,payment_id,type,received_at) VALUES ($1,$2,$3,$4) ON CONFLICT (event_id) DO NOTHING")
+	at := payment.Created_at.Add(time.Minute)
+
+	testRecordCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult bool
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs("evt_1", payment.ID, "payment.captured", at).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:           "success, repeated delivery",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs("evt_1", payment.ID, "payment.captured", at).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testRecordCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			fresh, err := repo.Record("evt_1", payment.ID, "payment.captured", at, context.Background(), tx)
+
+			assert.Equal(t, tc.expectedResult, fresh)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestAddRefund(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE payments SET refunded = refunded + $1 WHERE id = $2")
+
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(query).
+		WithArgs(12.2, payment.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.AddRefund(payment.ID, 12.2, context.Background(), tx))
+
+	mock.ExpectExec(query).
+		WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+
+	assert.Equal(t, internal.ErrInternalFailure, repo.AddRefund(payment.ID, 12.2, context.Background(), tx))
+}
+
+func TestGID(t *testing.T) {
+	assert.Equal(t, payment.ID, payment.GID())
+}
@@ -0,0 +1,360 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+)
+
+// Kinds of pricing rules
+const (
+	Base     = "base"     // Amount is a ticket price, per movie or default
+	VIP      = "vip"      // Amount is added in VIP halls
+	Category = "category" // Amount is added for seat category
+	Matinee  = "matinee"  // Amount is a percent off for sessions starting in time window
+	Weekday  = "weekday"  // Amount is a percent off for sessions on weekday
+	Holiday  = "holiday"  // Amount is added on date, discounts are not applied
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID       int64   `json:"id"`
+	Kind     string  `json:"kind"`
+	Name     string  `json:"name"`
+	Amount   float64 `json:"amount"`
+	Movie_ID int64   `json:"movie_id,omitempty"` // Rule applies to all movies when empty
+	Category string  `json:"category,omitempty"`
+	Weekday  *int64  `json:"weekday,omitempty"` // 0 is Sunday
+	Starts   string  `json:"starts,omitempty"`  // HH:MM
+	Ends     string  `json:"ends,omitempty"`    // HH:MM
+	Day      string  `json:"day,omitempty"`     // YYYY-MM-DD
+
+	Version int64 `json:"-"` // Grows with every change
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+func (r *Resource) Revision() int64 {
+	return r.Version
+}
+
+// Show is a struct to store session details affecting price
+type Show struct {
+	Movie_ID   int64
+	Hall_ID    int64
+	VIP        bool
+	Starts_at  time.Time
+	Age_rating string // Age rating of the movie, see movie.Ratings
+}
+
+// Line is a single step of price calculation
+type Line struct {
+	Kind   string  `json:"kind"`
+	Name   string  `json:"name"`
+	Amount float64 `json:"amount"`
+}
+
+// Breakdown explains how ticket price was computed
+type Breakdown struct {
+	Lines []Line  `json:"lines"`
+	Total float64 `json:"total"`
+}
+
+// Value stores breakdown as json
+func (b Breakdown) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan reads breakdown from json
+func (b *Breakdown) Scan(src interface{}) error {
+	switch data := src.(type) {
+	case []byte:
+		return json.Unmarshal(data, b)
+	case string:
+		return json.Unmarshal([]byte(data), b)
+	default:
+		return fmt.Errorf("unsupported price breakdown type %T", src)
+	}
+}
+
+var columns = []string{
+	"id", "kind", "name", "amount", "movie_id", "category", "weekday",
+	"to_char(starts, 'HH24:MI')", "to_char(ends, 'HH24:MI')", "to_char(day, 'YYYY-MM-DD')", "version",
+}
+
+// Create new entity in storage
+func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	var id int64
+
+	rule, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create pricing rule object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := sq.
+		Insert("pricing_rules").
+		Columns("kind", "name", "amount", "movie_id", "category", "weekday", "starts", "ends", "day").
+		Values(rule.Kind, rule.Name, rule.Amount, nullID(rule.Movie_ID), nullString(rule.Category),
+			rule.Weekday, nullString(rule.Starts), nullString(rule.Ends), nullString(rule.Day)).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err != nil {
+		r.Log.Info("Failed to run Create pricing rule query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return r.Retrieve(id, ctx)
+}
+
+// Retrieve entity from storage
+func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+
+	row := sq.
+		Select(columns...).
+		From("pricing_rules").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve pricing rule query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// Delete entity in storage
+func (r *Repository) Delete(id int64, ctx context.Context) error {
+
+	_, err := sq.
+		Delete("pricing_rules").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete pricing rule query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// fields pricing rules list can be sorted and filtered by
+var fields = list.Fields{
+	"kind": {Column: "pricing_rules.kind", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Kind }},
+	"name": {Column: "pricing_rules.name", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Name }},
+}
+
+// RetrieveAll returns page of pricing rules matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.Select(columns...).From("pricing_rules"), "pricing_rules", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveAll pricing rules query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into pricing rule structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	total, err := list.Total(r.DB, "pricing_rules", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count pricing rules.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
+
+// Show returns session details affecting price
+func (r *Repository) Show(id int64, ctx context.Context, tx *sql.Tx) (*Show, error) {
+	var res Show
+
+	err := sq.
+		Select("sessions.movie_id", "sessions.hall_id", "halls.vip", "sessions.starts_at", "movies.age_rating").
+		From("sessions").
+		Join("halls ON sessions.hall_id = halls.id").
+		Join("movies ON sessions.movie_id = movies.id").
+		Where(sq.Eq{
+			"sessions.id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&res.Movie_ID, &res.Hall_ID, &res.VIP, &res.Starts_at, &res.Age_rating)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Show pricing query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &res, nil
+}
+
+// Rules returns all pricing rules inside transaction
+func (r *Repository) Rules(ctx context.Context, tx *sql.Tx) ([]*Resource, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		From("pricing_rules").
+		OrderBy("id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Rules pricing query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []*Resource
+
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into pricing rule structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	return data, nil
+}
+
+// scan reads pricing rule with nullable columns
+func scan(row sq.RowScanner) (*Resource, error) {
+	var (
+		res      Resource
+		movie    sql.NullInt64
+		category sql.NullString
+		weekday  sql.NullInt64
+		starts   sql.NullString
+		ends     sql.NullString
+		day      sql.NullString
+	)
+
+	err := row.Scan(&res.ID, &res.Kind, &res.Name, &res.Amount, &movie, &category, &weekday, &starts, &ends, &day, &res.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Movie_ID = movie.Int64
+	res.Category = category.String
+	res.Starts = starts.String
+	res.Ends = ends.String
+	res.Day = day.String
+
+	if weekday.Valid {
+		res.Weekday = &weekday.Int64
+	}
+
+	return &res, nil
+}
+
+// nullID stores empty reference as NULL
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
+
+// nullString stores empty text as NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
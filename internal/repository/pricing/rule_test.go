@@ -0,0 +1,426 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var tuesday int64 = 2
+
+var rule = &Resource{
+	ID:       1,
+	Kind:     Weekday,
+	Name:     "Cheap Tuesday",
+	Amount:   25,
+	Movie_ID: 3,
+	Weekday:  &tuesday,
+
+	Version: 1,
+}
+
+var (
+	selectRule  = regexp.QuoteMeta("SELECT id, kind, name, amount, movie_id, category, weekday, to_char(starts, 'HH24:MI'), to_char(ends, 'HH24:MI'), to_char(day, 'YYYY-MM-DD'), version FROM pricing_rules WHERE id = $1")
+	selectRules = regexp.QuoteMeta("SELECT id, kind, name, amount, movie_id, category, weekday, to_char(starts, 'HH24:MI'), to_char(ends, 'HH24:MI'), to_char(day, 'YYYY-MM-DD'), version FROM pricing_rules ORDER BY id")
+	pageRules   = regexp.QuoteMeta("SELECT id, kind, name, amount, movie_id, category, weekday, to_char(starts, 'HH24:MI'), to_char(ends, 'HH24:MI'), to_char(day, 'YYYY-MM-DD'), version FROM pricing_rules ORDER BY pricing_rules.id ASC LIMIT 21")
+	ruleColumns = []string{"id", "kind", "name", "amount", "movie_id", "category", "weekday", "starts", "ends", "day", "version"}
+)
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testCreateCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+		object         internal.Identifiable
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: rule,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("INSERT INTO pricing_rules (kind,name,amount,movie_id,category,weekday,starts,ends,day) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9) RETURNING \"id\"")).
+					WithArgs(rule.Kind, rule.Name, rule.Amount, rule.Movie_ID, nil, tuesday, nil, nil, nil).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(rule.ID))
+				sqlm2.ExpectQuery(selectRule).
+					WithArgs(rule.ID).
+					WillReturnRows(sqlm2.NewRows(ruleColumns).
+						AddRow(rule.ID, rule.Kind, rule.Name, rule.Amount, rule.Movie_ID, nil, tuesday, nil, nil, nil, rule.Version))
+			},
+			object: rule,
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("INSERT INTO pricing_rules (.*)").
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			object: rule,
+		},
+		{
+			name:           "failed, assertion error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare:        func(sqlm2 sqlmock.Sqlmock) {},
+			object:         nil,
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.Create(tc.object, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testRetrieveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: rule,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(selectRule).
+					WithArgs(rule.ID).
+					WillReturnRows(sqlm2.NewRows(ruleColumns).
+						AddRow(rule.ID, rule.Kind, rule.Name, rule.Amount, rule.Movie_ID, nil, tuesday, nil, nil, nil, rule.Version))
+			},
+		},
+		{
+			name:           "failed, sql no rows error",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(selectRule).
+					WithArgs(rule.ID).
+					WillReturnRows(sqlm2.NewRows(ruleColumns))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(selectRule).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.Retrieve(rule.ID, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testDeleteCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM pricing_rules WHERE id = $1")).
+					WithArgs(rule.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM pricing_rules WHERE id = $1")).
+					WithArgs(rule.ID).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testDeleteCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			err = repo.Delete(rule.ID, ctx)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieveAll(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	matinee := &Resource{ID: 2, Kind: Matinee, Name: "Matinee", Amount: 20, Starts: "00:00", Ends: "12:00", Version: 1}
+
+	testRetrieveAllCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *internal.Page
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: &internal.Page{Data: []internal.Identifiable{rule, matinee}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 2}},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(pageRules).
+					WillReturnRows(sqlm2.NewRows(ruleColumns).
+						AddRow(rule.ID, rule.Kind, rule.Name, rule.Amount, rule.Movie_ID, nil, tuesday, nil, nil, nil, rule.Version).
+						AddRow(matinee.ID, matinee.Kind, matinee.Name, matinee.Amount, nil, nil, nil, "00:00", "12:00", nil, matinee.Version))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM pricing_rules")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(2))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(pageRules).
+					WillReturnError(internal.ErrInternalFailure)
+			},
+		},
+		{
+			name:           "success, no rules",
+			expectedError:  nil,
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: internal.DefaultLimit}},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(pageRules).
+					WillReturnRows(sqlm2.NewRows(ruleColumns))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM pricing_rules")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveAllCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.RetrieveAll(&internal.Query{}, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestShow(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT sessions.movie_id, sessions.hall_id, halls.vip, sessions.starts_at, movies.age_rating FROM sessions JOIN halls ON sessions.hall_id = halls.id JOIN movies ON sessions.movie_id = movies.id WHERE sessions.id = $1")
+	starts := time.Date(2022, time.April, 5, 11, 30, 0, 0, time.UTC)
+
+	testShowCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    *Show
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: &Show{Movie_ID: 3, Hall_ID: 2, VIP: true, Starts_at: starts, Age_rating: "16+"},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(7).
+					WillReturnRows(sqlm2.NewRows([]string{"movie_id", "hall_id", "vip", "starts_at", "age_rating"}).AddRow(3, 2, true, starts, "16+"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, sql no rows error",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(7).
+					WillReturnRows(sqlm2.NewRows([]string{"movie_id", "hall_id", "vip", "starts_at"}))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testShowCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			res, err := repo.Show(7, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	b := Breakdown{Lines: []Line{{Kind: Base, Name: "Standard ticket", Amount: 10}}, Total: 10}
+
+	value, err := b.Value()
+	assert.NoError(t, err)
+
+	var res Breakdown
+	assert.NoError(t, res.Scan(value))
+	assert.Equal(t, b, res)
+
+	assert.Error(t, res.Scan(42))
+}
+
+func TestGID(t *testing.T) {
+	assert.Equal(t, rule.ID, rule.GID())
+}
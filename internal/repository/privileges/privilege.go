@@ -0,0 +1,238 @@
+package privilege
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+)
+
+// Postgres error codes of constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+// Create new entity in storage
+func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	var id int64
+
+	privilege, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := sq.
+		Insert("privileges").
+		Columns("name", "description").
+		Values(privilege.Name, privilege.Description).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return nil, internal.ErrPrivilegeExists
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Create privilege query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return r.Retrieve(id, ctx)
+}
+
+// Retrieve entity from storage
+func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	var res Resource
+
+	err := sq.
+		Select("id", "name", "description").
+		From("privileges").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&res.ID, &res.Name, &res.Description)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve privilege query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &res, nil
+}
+
+// Update entity in storage, returns nil when there's no such privilege
+func (r *Repository) Update(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+
+	privilege, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to update privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	result, err := sq.
+		Update("privileges").
+		Set("name", privilege.Name).
+		Set("description", privilege.Description).
+		Where(sq.Eq{
+			"id": privilege.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return nil, internal.ErrPrivilegeExists
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Update privilege query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count updated privileges.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	if updated == 0 {
+		return nil, nil
+	}
+
+	return r.Retrieve(privilege.ID, ctx)
+}
+
+// Delete entity in storage, privileges granted to users or permissions are kept
+func (r *Repository) Delete(id int64, ctx context.Context) error {
+
+	_, err := sq.
+		Delete("privileges").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return internal.ErrPrivilegeInUse
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete privilege query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// fields privileges list can be sorted and filtered by
+var fields = list.Fields{
+	"name": {Column: "privileges.name", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Name }},
+}
+
+// RetrieveAll returns page of privileges matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.Select("id", "name", "description").From("privileges"), "privileges", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveAll privileges query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		res := &Resource{}
+
+		err = rows.Scan(&res.ID, &res.Name, &res.Description)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into privilege structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	total, err := list.Total(r.DB, "privileges", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count privileges.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
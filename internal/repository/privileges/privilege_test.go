@@ -0,0 +1,242 @@
+package privilege
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var privilege = &Resource{
+	ID:          5,
+	Name:        "checkin",
+	Description: "Verify ticket codes and admit holders to sessions",
+}
+
+var rows = []string{"id", "name", "description"}
+
+var selectPrivilege = regexp.QuoteMeta("SELECT id, name, description FROM privileges WHERE id = $1")
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	insert := regexp.QuoteMeta(`INSERT INTO privileges (name,description) VALUES ($1,$2) RETURNING "id"`)
+
+	testCreateCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: privilege,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WithArgs(privilege.Name, privilege.Description).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(privilege.ID))
+				sqlm2.ExpectQuery(selectPrivilege).
+					WithArgs(privilege.ID).
+					WillReturnRows(sqlm2.NewRows(rows).AddRow(privilege.ID, privilege.Name, privilege.Description))
+			},
+		},
+		{
+			name:           "failed, name exists",
+			expectedError:  internal.ErrPrivilegeExists,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WillReturnError(&pq.Error{Code: uniqueViolation})
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Create(privilege, context.Background())
+
+			if tc.expectedResult == nil {
+				assert.Nil(t, res)
+			} else {
+				assert.Equal(t, tc.expectedResult, res)
+			}
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	update := regexp.QuoteMeta("UPDATE privileges SET name = $1, description = $2 WHERE id = $3")
+
+	testUpdateCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success, no such privilege",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WithArgs(privilege.Name, privilege.Description, privilege.ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:          "failed, name exists",
+			expectedError: internal.ErrPrivilegeExists,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WillReturnError(&pq.Error{Code: uniqueViolation})
+			},
+		},
+	}
+
+	for _, tc := range testUpdateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Update(privilege, context.Background())
+
+			assert.Nil(t, res)
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	query := regexp.QuoteMeta("DELETE FROM privileges WHERE id = $1")
+
+	testDeleteCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(privilege.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:          "failed, privilege in use",
+			expectedError: internal.ErrPrivilegeInUse,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(privilege.ID).
+					WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(privilege.ID).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testDeleteCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			err = repo.Delete(privilege.ID, context.Background())
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+
+	mock.ExpectQuery(selectPrivilege).
+		WithArgs(int64(9)).
+		WillReturnError(sql.ErrNoRows)
+
+	res, err := repo.Retrieve(9, context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
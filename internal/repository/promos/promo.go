@@ -0,0 +1,408 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+)
+
+// uniqueViolation is a postgres error code for unique constraint violation
+const uniqueViolation = "23505"
+
+// Kinds of promo codes
+const (
+	Percent = "percent" // Amount is a percent off every eligible ticket
+	Fixed   = "fixed"   // Amount is taken off eligible tickets once per purchase
+	Voucher = "voucher" // Single-use gift voucher, pays for eligible tickets up to its balance
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID         int64      `json:"id"`
+	Code       string     `json:"code"`
+	Kind       string     `json:"kind"`
+	Amount     float64    `json:"amount"`
+	Balance    float64    `json:"balance,omitempty"` // Voucher value left
+	Starts_at  *time.Time `json:"starts_at,omitempty"`
+	Ends_at    *time.Time `json:"ends_at,omitempty"`
+	Max_uses   int64      `json:"max_uses,omitempty"` // Unlimited when empty
+	Per_user   int64      `json:"per_user,omitempty"` // Unlimited when empty
+	Uses       int64      `json:"uses"`
+	Movie_ID   int64      `json:"movie_id,omitempty"` // Code applies to all movies when empty
+	Hall_ID    int64      `json:"hall_id,omitempty"`
+	Session_ID int64      `json:"session_id,omitempty"`
+
+	Version int64 `json:"-"` // Grows with every change
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+func (r *Resource) Revision() int64 {
+	return r.Version
+}
+
+// Redemption is a struct to store discount promo code gave on purchase
+type Redemption struct {
+	Promo_ID    int64
+	Code        string
+	User_ID     int64
+	Order_ID    int64
+	Ticket_ID   int64
+	Amount      float64
+	Redeemed_at time.Time
+}
+
+var columns = []string{
+	"id", "code", "kind", "amount", "balance", "starts_at", "ends_at", "max_uses", "per_user", "uses",
+	"movie_id", "hall_id", "session_id", "version",
+}
+
+// Create new entity in storage
+func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	var id int64
+
+	promo, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create promo code object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := sq.
+		Insert("promo_codes").
+		Columns("code", "kind", "amount", "balance", "starts_at", "ends_at", "max_uses", "per_user",
+			"movie_id", "hall_id", "session_id").
+		Values(promo.Code, promo.Kind, promo.Amount, promo.Balance, promo.Starts_at, promo.Ends_at, promo.Max_uses,
+			promo.Per_user, nullID(promo.Movie_ID), nullID(promo.Hall_ID), nullID(promo.Session_ID)).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return nil, internal.ErrPromoExists
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Create promo code query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return r.Retrieve(id, ctx)
+}
+
+// Retrieve entity from storage
+func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+
+	row := sq.
+		Select(columns...).
+		From("promo_codes").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve promo code query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// fields promo codes list can be sorted and filtered by
+var fields = list.Fields{
+	"code": {Column: "promo_codes.code", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Code }},
+	"kind": {Column: "promo_codes.kind", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Kind }},
+}
+
+// RetrieveAll returns page of promo codes matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.Select(columns...).From("promo_codes"), "promo_codes", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveAll promo codes query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into promo code structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	total, err := list.Total(r.DB, "promo_codes", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count promo codes.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
+
+// Delete entity in storage
+func (r *Repository) Delete(id int64, ctx context.Context) error {
+
+	_, err := sq.
+		Delete("promo_codes").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete promo code query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Lock selects promo code for update within transaction
+func (r *Repository) Lock(code string, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+
+	row := sq.
+		Select(columns...).
+		From("promo_codes").
+		Where(sq.Eq{
+			"code": code,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock promo code query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// UsedBy counts purchases user made with promo code
+func (r *Repository) UsedBy(id int64, user int64, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var used int64
+
+	err := sq.
+		Select("COUNT(*)").
+		From("promo_redemptions").
+		Where(sq.Eq{
+			"promo_id": id,
+			"user_id":  user,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&used)
+
+	if err != nil {
+		r.Log.Info("Failed to run UsedBy promo code query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return used, nil
+}
+
+// Redeem stores redemption, counts promo code use and takes discount off voucher balance
+func (r *Repository) Redeem(red *Redemption, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Insert("promo_redemptions").
+		Columns("promo_id", "user_id", "order_id", "ticket_id", "amount", "redeemed_at").
+		Values(red.Promo_ID, red.User_ID, nullID(red.Order_ID), nullID(red.Ticket_ID), red.Amount, red.Redeemed_at).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Redeem promo code query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return r.use(red.Promo_ID, 1, -red.Amount, ctx, tx)
+}
+
+// Release returns promo code redeemed by order which was never paid
+func (r *Repository) Release(order int64, ctx context.Context, tx *sql.Tx) error {
+	var (
+		id     int64
+		promo  int64
+		amount float64
+	)
+
+	err := sq.
+		Select("id", "promo_id", "amount").
+		From("promo_redemptions").
+		Where(sq.Eq{
+			"order_id": order,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id, &promo, &amount)
+
+	if err == sql.ErrNoRows {
+
+		return nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to find promo code redemption of order.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	_, err = sq.
+		Delete("promo_redemptions").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Release promo code query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return r.use(promo, -1, amount, ctx, tx)
+}
+
+// use changes promo code use counter and voucher balance
+func (r *Repository) use(id int64, uses int64, balance float64, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("promo_codes").
+		Set("uses", sq.Expr("uses + ?", uses)).
+		Set("balance", sq.Expr("CASE WHEN kind = ? THEN balance + ? ELSE balance END", Voucher, balance)).
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to update promo code usage.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// scan reads promo code with nullable columns
+func scan(row sq.RowScanner) (*Resource, error) {
+	var (
+		res     Resource
+		movie   sql.NullInt64
+		hall    sql.NullInt64
+		session sql.NullInt64
+	)
+
+	err := row.Scan(&res.ID, &res.Code, &res.Kind, &res.Amount, &res.Balance, &res.Starts_at, &res.Ends_at,
+		&res.Max_uses, &res.Per_user, &res.Uses, &movie, &hall, &session, &res.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Movie_ID = movie.Int64
+	res.Hall_ID = hall.Int64
+	res.Session_ID = session.Int64
+
+	return &res, nil
+}
+
+// nullID stores empty reference as NULL
+func nullID(id int64) interface{} {
+	if id == 0 {
+		return nil
+	}
+
+	return id
+}
@@ -0,0 +1,302 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var promo = &Resource{
+	ID:       1,
+	Code:     "SPRING",
+	Kind:     Percent,
+	Amount:   15,
+	Per_user: 1,
+	Movie_ID: 3,
+
+	Version: 1,
+}
+
+var rows = []string{"id", "code", "kind", "amount", "balance", "starts_at", "ends_at", "max_uses", "per_user", "uses",
+	"movie_id", "hall_id", "session_id", "version"}
+
+var selectPromo = "SELECT id, code, kind, amount, balance, starts_at, ends_at, max_uses, per_user, uses, " +
+	"movie_id, hall_id, session_id, version FROM promo_codes"
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	insert := regexp.QuoteMeta("INSERT INTO promo_codes (code,kind,amount,balance,starts_at,ends_at,max_uses,per_user," +
+		`movie_id,hall_id,session_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) RETURNING "id"`)
+	query := regexp.QuoteMeta(selectPromo + " WHERE id = $1")
+
+	testCreateCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: promo,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WithArgs(promo.Code, promo.Kind, promo.Amount, promo.Balance, nil, nil, promo.Max_uses, promo.Per_user,
+						promo.Movie_ID, nil, nil).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(promo.ID))
+				sqlm2.ExpectQuery(query).
+					WithArgs(promo.ID).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(promo.ID, promo.Code, promo.Kind, promo.Amount, 0, nil, nil, 0, 1, 0, 3, nil, nil, promo.Version))
+			},
+		},
+		{
+			name:           "failed, code exists",
+			expectedError:  internal.ErrPromoExists,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WillReturnError(&pq.Error{Code: uniqueViolation})
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(insert).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Create(promo, context.Background())
+
+			if tc.expectedResult == nil {
+				assert.Nil(t, res)
+			} else {
+				assert.Equal(t, tc.expectedResult, res)
+			}
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	query := regexp.QuoteMeta(selectPromo + " WHERE code = $1 FOR UPDATE")
+	ends := time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC)
+	voucher := &Resource{ID: 2, Code: "GIFT50", Kind: Voucher, Amount: 50, Balance: 12.5, Ends_at: &ends, Max_uses: 1, Uses: 0, Version: 4}
+
+	testLockCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *Resource
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: voucher,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(voucher.Code).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(voucher.ID, voucher.Code, Voucher, 50, 12.5, nil, ends, 1, 0, 0, nil, nil, nil, voucher.Version))
+			},
+		},
+		{
+			name:           "failed, no code",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnRows(sqlm2.NewRows(rows))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testLockCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			res, err := repo.Lock(voucher.Code, ctx, tx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRedeem(t *testing.T) {
+	insert := regexp.QuoteMeta("INSERT INTO promo_redemptions (promo_id,user_id,order_id,ticket_id,amount,redeemed_at) " +
+		"VALUES ($1,$2,$3,$4,$5,$6)")
+	use := regexp.QuoteMeta("UPDATE promo_codes SET uses = uses + $1, " +
+		"balance = CASE WHEN kind = $2 THEN balance + $3 ELSE balance END WHERE id = $4")
+	red := &Redemption{Promo_ID: 2, Code: "GIFT50", User_ID: 3, Order_ID: 4, Amount: 12.5,
+		Redeemed_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)}
+
+	testRedeemCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(insert).
+					WithArgs(red.Promo_ID, red.User_ID, red.Order_ID, nil, red.Amount, red.Redeemed_at).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectExec(use).
+					WithArgs(1, Voucher, -red.Amount, red.Promo_ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(insert).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testRedeemCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			err = repo.Redeem(red, ctx, tx)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUsedBy(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM promo_redemptions WHERE promo_id = $1 AND user_id = $2")).
+		WithArgs(promo.ID, 3).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+
+	used, err := repo.UsedBy(promo.ID, 3, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), used)
+}
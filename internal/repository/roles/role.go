@@ -0,0 +1,556 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+)
+
+// Postgres error codes of constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Built-in roles
+const (
+	Customer   = "customer"
+	Cashier    = "cashier"
+	Manager    = "manager"
+	Superadmin = "superadmin" // Holds every permission, including ones added later
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	System      bool     `json:"system"`      // Built-in role, can't be renamed or deleted
+	Permissions []string `json:"permissions"` // Permission names in name order
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+// columns of role with its permissions in name order
+var columns = []string{
+	"roles.id", "roles.name", "roles.description", "roles.system",
+	"ARRAY(SELECT permissions.name FROM permissions WHERE roles.name = '" + Superadmin + "' OR permissions.id IN " +
+		"(SELECT role_permissions.permission_id FROM role_permissions WHERE role_permissions.role_id = roles.id) ORDER BY permissions.name)",
+}
+
+// Insert stores role within transaction and returns its id, permissions are stored with SetPermissions
+func (r *Repository) Insert(role *Resource, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	err := sq.
+		Insert("roles").
+		Columns("name", "description").
+		Values(role.Name, role.Description).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return 0, internal.ErrRoleExists
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert role query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
+// Retrieve entity from storage
+func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+
+	row := sq.
+		Select(columns...).
+		From("roles").
+		Where(sq.Eq{
+			"roles.id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve role query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// Lock selects role for update within transaction
+func (r *Repository) Lock(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+
+	row := sq.
+		Select(columns...).
+		From("roles").
+		Where(sq.Eq{
+			"roles.id": id,
+		}).
+		Suffix("FOR UPDATE OF roles").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock role query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// fields roles list can be sorted and filtered by
+var fields = list.Fields{
+	"name":   {Column: "roles.name", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Name }},
+	"system": {Column: "roles.system", Kind: list.Bool, Key: func(i internal.Identifiable) string { return strconv.FormatBool(i.(*Resource).System) }},
+	"permission": {Kind: list.Text, Match: "(roles.name = '" + Superadmin + "' OR EXISTS (SELECT 1 FROM role_permissions " +
+		"JOIN permissions ON role_permissions.permission_id = permissions.id " +
+		"WHERE role_permissions.role_id = roles.id AND permissions.name = ?))"},
+}
+
+// RetrieveAll returns page of roles matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.Select(columns...).From("roles"), "roles", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveAll roles query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into role structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	total, err := list.Total(r.DB, "roles", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count roles.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
+
+// Update stores role name and description within transaction
+func (r *Repository) Update(role *Resource, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("roles").
+		Set("name", role.Name).
+		Set("description", role.Description).
+		Where(sq.Eq{
+			"id": role.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return internal.ErrRoleExists
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Update role query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// SetPermissions replaces permissions of role within transaction
+func (r *Repository) SetPermissions(id int64, permissions []string, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Delete("role_permissions").
+		Where(sq.Eq{
+			"role_id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete role permissions query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	_, err = sq.
+		Insert("role_permissions").
+		Columns("role_id", "permission_id").
+		Select(sq.
+			Select().
+			Column("?", id).
+			Column("id").
+			From("permissions").
+			Where(sq.Eq{
+				"name": permissions,
+			})).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert role permissions query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Delete role within transaction, users holding it lose the role
+func (r *Repository) Delete(id int64, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Delete("roles").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete role query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Permissions returns names of all permissions roles can be composed of
+func (r *Repository) Permissions(ctx context.Context) ([]string, error) {
+
+	rows, err := sq.
+		Select("name").
+		From("permissions").
+		OrderBy("name").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Permissions query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into permissions.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, name)
+	}
+
+	return data, nil
+}
+
+// Find selects roles with passed names within transaction
+func (r *Repository) Find(names []string, ctx context.Context, tx *sql.Tx) ([]*Resource, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		From("roles").
+		Where(sq.Eq{
+			"roles.name": names,
+		}).
+		OrderBy("roles.id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Find roles query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []*Resource
+
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into role structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	return data, nil
+}
+
+// RetrieveByUser returns roles assigned to user
+func (r *Repository) RetrieveByUser(user int64, ctx context.Context) ([]internal.Identifiable, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		From("roles").
+		Join("user_roles ON user_roles.role_id = roles.id").
+		Where(sq.Eq{
+			"user_roles.user_id": user,
+		}).
+		OrderBy("roles.id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveByUser roles query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	data := []internal.Identifiable{}
+
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into role structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	return data, nil
+}
+
+// Holders selects users holding role for update within transaction, so concurrent
+// changes can't take the role from all of them at once
+func (r *Repository) Holders(name string, ctx context.Context, tx *sql.Tx) ([]int64, error) {
+
+	rows, err := sq.
+		Select("user_roles.user_id").
+		From("user_roles").
+		Join("roles ON user_roles.role_id = roles.id").
+		Where(sq.Eq{
+			"roles.name": name,
+		}).
+		OrderBy("user_roles.user_id").
+		Suffix("FOR UPDATE OF user_roles").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Holders role query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []int64
+
+	for rows.Next() {
+		var id int64
+
+		err = rows.Scan(&id)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into role holders.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, id)
+	}
+
+	return data, nil
+}
+
+// Assign replaces roles of user within transaction
+func (r *Repository) Assign(user int64, roles []int64, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Delete("user_roles").
+		Where(sq.Eq{
+			"user_id": user,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Delete user roles query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if len(roles) == 0 {
+		return nil
+	}
+
+	insert := sq.Insert("user_roles").Columns("user_id", "role_id")
+	for _, role := range roles {
+		insert = insert.Values(user, role)
+	}
+
+	_, err = insert.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return internal.ErrNotFound
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert user roles query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Grant adds role to roles of user within transaction
+func (r *Repository) Grant(user int64, role int64, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Insert("user_roles").
+		Columns("user_id", "role_id").
+		Values(user, role).
+		Suffix("ON CONFLICT DO NOTHING").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return internal.ErrNotFound
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Grant role query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// scan reads role with its permissions
+func scan(row sq.RowScanner) (*Resource, error) {
+	var res Resource
+
+	err := row.Scan(&res.ID, &res.Name, &res.Description, &res.System, pq.Array(&res.Permissions))
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
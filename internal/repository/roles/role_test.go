@@ -0,0 +1,310 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var cashier = &Resource{
+	ID:          2,
+	Name:        Cashier,
+	Description: "Box office",
+	System:      true,
+	Permissions: []string{"tickets:checkin", "tickets:read"},
+}
+
+var selectRole = regexp.QuoteMeta("SELECT roles.id, roles.name, roles.description, roles.system, " +
+	"ARRAY(SELECT permissions.name FROM permissions WHERE roles.name = 'superadmin' OR permissions.id IN " +
+	"(SELECT role_permissions.permission_id FROM role_permissions WHERE role_permissions.role_id = roles.id) ORDER BY permissions.name) " +
+	"FROM roles")
+
+// roleRow returns cashier the way storage does
+func roleRow(sqlm2 sqlmock.Sqlmock) *sqlmock.Rows {
+	return sqlm2.NewRows([]string{"id", "name", "description", "system", "permissions"}).
+		AddRow(cashier.ID, cashier.Name, cashier.Description, cashier.System, "{tickets:checkin,tickets:read}")
+}
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestInsert(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	insert := regexp.QuoteMeta("INSERT INTO roles (name,description) VALUES ($1,$2) RETURNING \"id\"")
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(insert).
+		WithArgs("usher", "Seats customers").
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(5))
+
+	id, err := repo.Insert(&Resource{Name: "usher", Description: "Seats customers"}, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), id)
+
+	mock.ExpectQuery(insert).
+		WillReturnError(&pq.Error{Code: uniqueViolation})
+
+	_, err = repo.Insert(cashier, ctx, tx)
+	assert.Equal(t, internal.ErrRoleExists, err)
+
+	mock.ExpectQuery(insert).
+		WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+
+	_, err = repo.Insert(cashier, ctx, tx)
+	assert.Equal(t, internal.ErrInternalFailure, err)
+}
+
+func TestRetrieve(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	testRetrieveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult internal.Identifiable
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: cashier,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(selectRole + regexp.QuoteMeta(" WHERE roles.id = $1")).
+					WithArgs(cashier.ID).
+					WillReturnRows(roleRow(sqlm2))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(selectRole + regexp.QuoteMeta(" WHERE roles.id = $1")).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+		{
+			name:           "failed, sql no rows error",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(selectRole + regexp.QuoteMeta(" WHERE roles.id = $1")).
+					WillReturnRows(sqlm2.
+						NewRows(nil))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.Retrieve(cashier.ID, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieveAllFiltered(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	match := "(roles.name = 'superadmin' OR EXISTS (SELECT 1 FROM role_permissions " +
+		"JOIN permissions ON role_permissions.permission_id = permissions.id " +
+		"WHERE role_permissions.role_id = roles.id AND permissions.name = $1))"
+
+	mock.ExpectQuery(selectRole + regexp.QuoteMeta(" WHERE "+match+" ORDER BY roles.id ASC LIMIT 21")).
+		WithArgs("tickets:checkin").
+		WillReturnRows(roleRow(mock))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM roles WHERE " + match)).
+		WithArgs("tickets:checkin").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(1))
+
+	q := &internal.Query{Limit: 20, Filters: map[string]string{"permission": "tickets:checkin"}}
+
+	res, err := repo.RetrieveAll(q, context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []internal.Identifiable{cashier}, res.Data)
+	assert.Equal(t, int64(1), res.Meta.Total)
+}
+
+func TestSetPermissions(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	clear := regexp.QuoteMeta("DELETE FROM role_permissions WHERE role_id = $1")
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(clear).
+		WithArgs(cashier.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO role_permissions (role_id,permission_id) SELECT $1, id FROM permissions WHERE name IN ($2,$3)")).
+		WithArgs(cashier.ID, "tickets:checkin", "tickets:read").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.SetPermissions(cashier.ID, cashier.Permissions, ctx, tx)
+	assert.NoError(t, err)
+
+	mock.ExpectExec(clear).
+		WithArgs(cashier.ID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.SetPermissions(cashier.ID, nil, ctx, tx)
+	assert.NoError(t, err)
+
+	mock.ExpectExec(clear).
+		WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+
+	err = repo.SetPermissions(cashier.ID, cashier.Permissions, ctx, tx)
+	assert.Equal(t, internal.ErrInternalFailure, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHolders(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT user_roles.user_id FROM user_roles JOIN roles ON user_roles.role_id = roles.id " +
+		"WHERE roles.name = $1 ORDER BY user_roles.user_id FOR UPDATE OF user_roles")).
+		WithArgs(Superadmin).
+		WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow(1).AddRow(7))
+
+	res, err := repo.Holders(Superadmin, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 7}, res)
+}
+
+func TestAssign(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	clear := regexp.QuoteMeta("DELETE FROM user_roles WHERE user_id = $1")
+	insert := regexp.QuoteMeta("INSERT INTO user_roles (user_id,role_id) VALUES ($1,$2),($3,$4)")
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(clear).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insert).
+		WithArgs(7, 2, 7, 3).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.Assign(7, []int64{2, 3}, ctx, tx)
+	assert.NoError(t, err)
+
+	mock.ExpectExec(clear).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	err = repo.Assign(7, nil, ctx, tx)
+	assert.NoError(t, err)
+
+	mock.ExpectExec(clear).
+		WithArgs(99).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(insert).
+		WillReturnError(&pq.Error{Code: foreignKeyViolation})
+
+	err = repo.Assign(99, []int64{2, 3}, ctx, tx)
+	assert.Equal(t, internal.ErrNotFound, err)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGID(t *testing.T) {
+	res := &Resource{ID: cashier.ID}
+	assert.Equal(t, cashier.ID, res.GID())
+}
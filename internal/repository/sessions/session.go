@@ -3,11 +3,15 @@ package session
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
 )
 
 // Repository is a struct to store DB and logger connection
@@ -22,14 +26,55 @@ type Resource struct {
 	Hall_id   int64  `json:"hall_id,omitempty"`
 	Movie_id  int64  `json:"movie_id,omitempty"`
 	Starts_at string `json:"Starts_at"`
+	Ends_at   string `json:"Ends_at,omitempty"` // Movie end, filled for schedule conflicts
 	VIP       bool   `json:"VIP"`
 	Name      string `json:"Movie name"`
+
+	Version int64 `json:"-"` // Grows with every change, expected version when updating
 }
 
 func (r *Resource) GID() int64 {
 	return r.ID
 }
 
+func (r *Resource) Revision() int64 {
+	return r.Version
+}
+
+// Showtime is a struct to store public session details with seats left for sale
+type Showtime struct {
+	ID        int64      `json:"id"`
+	Movie_ID  int64      `json:"movie_id"`
+	Movie     string     `json:"movie"`
+	Hall_ID   int64      `json:"hall_id"`
+	VIP       bool       `json:"vip"`
+	Starts_at time.Time  `json:"starts_at"`
+	Ends_at   *time.Time `json:"ends_at,omitempty"`
+	Capacity  int64      `json:"capacity"`
+	Remaining int64      `json:"remaining"` // Seats neither sold nor held
+}
+
+func (s *Showtime) GID() int64 {
+	return s.ID
+}
+
+// ShowtimeFilter is a struct to store showtimes search, empty fields don't filter
+type ShowtimeFilter struct {
+	From      time.Time
+	To        time.Time
+	Available bool // Only sessions with seats left
+}
+
+// capacity counts hall seats for sale, halls without layout have plain seat count
+const capacity = "CASE WHEN EXISTS (SELECT 1 FROM hall_seats WHERE hall_seats.hall_id = halls.id) " +
+	"THEN (SELECT COUNT(*) FROM hall_seats WHERE hall_seats.hall_id = halls.id AND NOT hall_seats.blocked) " +
+	"ELSE COALESCE(halls.seats, 0) END"
+
+// remaining subtracts sold tickets and active holds from capacity
+const remaining = capacity +
+	" - (SELECT COUNT(*) FROM tickets WHERE tickets.session_id = sessions.id AND tickets.status NOT IN (?,?))" +
+	" - (SELECT COUNT(*) FROM seat_holds WHERE seat_holds.session_id = sessions.id AND seat_holds.expires_at > ?)"
+
 // Create new entity in storage
 func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
 	var id int64
@@ -64,12 +109,231 @@ func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (inter
 	return r.Retrieve(id, ctx)
 }
 
+// Insert stores session within transaction
+func (r *Repository) Insert(session *Resource, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	err := sq.
+		Insert("sessions").
+		Columns("hall_id", "movie_id", "starts_at").
+		Values(session.Hall_id, session.Movie_id, session.Starts_at).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert session query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
+// LockHall selects hall for update so its schedule changes one at a time,
+// reports false when there's no such hall
+func (r *Repository) LockHall(id int64, ctx context.Context, tx *sql.Tx) (bool, error) {
+	var locked int64
+
+	err := sq.
+		Select("id").
+		From("halls").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&locked)
+
+	if err == sql.ErrNoRows {
+
+		return false, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run LockHall session query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return true, nil
+}
+
+// Runtime returns movie duration, zero when there's no such movie
+func (r *Repository) Runtime(movie int64, ctx context.Context, tx *sql.Tx) (time.Duration, error) {
+	var seconds sql.NullFloat64
+
+	err := sq.
+		Select("EXTRACT(EPOCH FROM duration)").
+		From("movies").
+		Where(sq.Eq{
+			"id": movie,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&seconds)
+
+	if err == sql.ErrNoRows {
+
+		return 0, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Runtime session query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return time.Duration(seconds.Float64) * time.Second, nil
+}
+
+// Lock selects session for update within transaction
+func (r *Repository) Lock(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+	var res Resource
+
+	err := sq.
+		Select("id", "hall_id", "movie_id", "starts_at", "version").
+		From("sessions").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&res.ID, &res.Hall_id, &res.Movie_id, &res.Starts_at, &res.Version)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock session query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &res, nil
+}
+
+// Update stores session hall, movie and start within transaction
+func (r *Repository) Update(session *Resource, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("sessions").
+		Set("hall_id", session.Hall_id).
+		Set("movie_id", session.Movie_id).
+		Set("starts_at", session.Starts_at).
+		Where(sq.Eq{
+			"id": session.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Update session query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Sold counts tickets of session that are neither refunded nor expired
+func (r *Repository) Sold(id int64, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var sold int64
+
+	err := sq.
+		Select("COUNT(*)").
+		From("tickets").
+		Where(sq.Eq{
+			"session_id": id,
+		}).
+		Where(sq.NotEq{
+			"status": []string{t.Refunded, t.Expired},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&sold)
+
+	if err != nil {
+		r.Log.Info("Failed to run Sold session query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return sold, nil
+}
+
+// Upcoming returns sessions of movie which haven't started yet
+func (r *Repository) Upcoming(movie int64, ctx context.Context, tx *sql.Tx) ([]*Resource, error) {
+
+	rows, err := sq.
+		Select("id", "hall_id", "movie_id", "starts_at").
+		From("sessions").
+		Where(sq.Eq{
+			"movie_id": movie,
+		}).
+		Where("starts_at > NOW()").
+		OrderBy("starts_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Upcoming sessions query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []*Resource
+
+	for rows.Next() {
+		res := &Resource{}
+
+		err = rows.Scan(&res.ID, &res.Hall_id, &res.Movie_id, &res.Starts_at)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into session structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	return data, nil
+}
+
 // Retrieve entity from storage
 func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
 	var res Resource
 
 	err := sq.
-		Select("sessions.id", "halls.vip", "movies.name", "starts_at").
+		Select("sessions.id", "sessions.hall_id", "sessions.movie_id", "halls.vip", "movies.name", "starts_at", "sessions.version").
 		From("sessions").
 		Join("movies ON sessions.movie_id = movies.id").
 		Join("halls ON sessions.hall_id = halls.id").
@@ -79,7 +343,7 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryRowContext(ctx).
-		Scan(&res.ID, &res.VIP, &res.Name, &res.Starts_at)
+		Scan(&res.ID, &res.Hall_id, &res.Movie_id, &res.VIP, &res.Name, &res.Starts_at, &res.Version)
 
 	if err == sql.ErrNoRows {
 
@@ -97,14 +361,19 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 	return &res, nil
 }
 
-// Delete entity in storage
-func (r *Repository) Delete(id int64, ctx context.Context) error {
+// Delete entity in storage, version when set must match current one
+func (r *Repository) Delete(id int64, version int64, ctx context.Context) error {
+	where := sq.Eq{
+		"id": id,
+	}
 
-	_, err := sq.
+	if version > 0 {
+		where["version"] = version
+	}
+
+	result, err := sq.
 		Delete("sessions").
-		Where(sq.Eq{
-			"id": id,
-		}).
+		Where(where).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		ExecContext(ctx)
@@ -117,28 +386,108 @@ func (r *Repository) Delete(id int64, ctx context.Context) error {
 		return internal.ErrInternalFailure
 	}
 
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count deleted sessions.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if deleted == 0 && version > 0 {
+		return internal.ErrVersionMismatch
+	}
+
 	return nil
 }
 
-// RetrieveAll entity from storage
-func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
+// fields sessions list can be sorted and filtered by
+var fields = list.Fields{
+	"starts_at": {Column: "sessions.starts_at", Kind: list.Time, Key: func(i internal.Identifiable) string { return i.(*Resource).Starts_at }},
+	"hall_id":   {Column: "sessions.hall_id", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*Resource).Hall_id, 10) }},
+	"movie_id":  {Column: "sessions.movie_id", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*Resource).Movie_id, 10) }},
+}
 
-	rows, err := sq.
-		Select("sessions.id", "halls.vip", "movies.name", "starts_at").
+// RetrieveAll returns page of sessions matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.
+		Select("sessions.id", "halls.vip", "movies.name", "starts_at", "sessions.hall_id", "sessions.movie_id").
 		From("sessions").
 		Join("movies ON sessions.movie_id = movies.id").
-		Join("halls ON sessions.hall_id = halls.id").
+		Join("halls ON sessions.hall_id = halls.id"), "sessions", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryContext(ctx)
 
-	if err == sql.ErrNoRows {
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveAll sessions query.",
+			zap.Error(err),
+		)
 
-		return nil, nil
+		return nil, internal.ErrInternalFailure
 	}
 
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		res := &Resource{}
+
+		err = rows.Scan(&res.ID, &res.VIP, &res.Name, &res.Starts_at, &res.Hall_id, &res.Movie_id)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into session structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, res)
+	}
+
+	total, err := list.Total(r.DB, "sessions", q, fields, ctx)
 	if err != nil {
-		r.Log.Info("Failed to run RetrieveAll sessions query.",
+		r.Log.Info("Failed to count sessions.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
+
+// Conflicts returns sessions of the same hall which screenings overlap with session,
+// each screening keeps hall busy for turnover after movie ends
+func (r *Repository) Conflicts(session *Resource, turnover time.Duration, ctx context.Context, tx *sql.Tx) ([]*Resource, error) {
+
+	rows, err := sq.
+		Select("sessions.id", "sessions.hall_id", "sessions.movie_id", "movies.name", "sessions.starts_at",
+			"sessions.starts_at + movies.duration").
+		From("sessions").
+		Join("movies ON sessions.movie_id = movies.id").
+		Where(sq.Eq{
+			"sessions.hall_id": session.Hall_id,
+		}).
+		Where(sq.NotEq{
+			"sessions.id": session.ID,
+		}).
+		Where("(CAST(? AS timestamp), (SELECT duration FROM movies WHERE id = ?) + INTERVAL '1 second' * ?) "+
+			"OVERLAPS (sessions.starts_at, movies.duration + INTERVAL '1 second' * ?)",
+			session.Starts_at, session.Movie_id, int64(turnover.Seconds()), int64(turnover.Seconds())).
+		OrderBy("sessions.starts_at").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Conflicts sessions query.",
 			zap.Error(err),
 		)
 
@@ -150,8 +499,7 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 	for rows.Next() {
 		res := &Resource{}
 
-		err = rows.Scan(&res.ID, &res.VIP, &res.Name, &res.Starts_at)
-
+		err = rows.Scan(&res.ID, &res.Hall_id, &res.Movie_id, &res.Name, &res.Starts_at, &res.Ends_at)
 		if err != nil {
 			r.Log.Info("Failed to scan rows into session structures.",
 				zap.Error(err),
@@ -163,52 +511,110 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		data = append(data, res)
 	}
 
-	var dataSlice []*Resource = data
-	var interfaceSlice []internal.Identifiable = make([]internal.Identifiable, len(dataSlice))
-	for i, d := range dataSlice {
-		interfaceSlice[i] = d
-	}
+	return data, nil
+}
 
-	return interfaceSlice, nil
+// showtimeFields are fields showtimes list can be sorted and filtered by
+var showtimeFields = list.Fields{
+	"starts_at": {Column: "showtimes.starts_at", Kind: list.Time, Key: func(i internal.Identifiable) string { return i.(*Showtime).Starts_at.Format(time.RFC3339Nano) }},
+	"movie_id":  {Column: "showtimes.movie_id", Kind: list.Int},
+	"hall_id":   {Column: "showtimes.hall_id", Kind: list.Int},
+	"vip":       {Column: "showtimes.vip", Kind: list.Bool},
 }
 
-func (r *Repository) TimeValid(i internal.Identifiable, ctx context.Context) (bool, error) {
-	session, ok := i.(*Resource)
-	if !ok {
-		r.Log.Info("Failed to create session object.",
-			zap.Bool("ok", ok),
-		)
+// Showtimes returns page of sessions matching filter and query
+func (r *Repository) Showtimes(filter *ShowtimeFilter, q *internal.Query, now time.Time, ctx context.Context) (*internal.Page, error) {
 
-		return false, internal.ErrValidationFailed
+	query, err := list.Select(upcoming(sq.Select("*"), filter, now), "showtimes", q, showtimeFields)
+	if err != nil {
+		return nil, err
 	}
 
-	res, err := sq.Select("movies.duration, sessions.starts_at").
-		From("sessions").
-		Join("movies ON sessions.movie_id = movies.id").
-		Where("(?, movies.duration) OVERLAPS (sessions.starts_at , movies.duration) AND sessions.hall_id = ? AND sessions.movie_id = ?", session.Starts_at, session.Hall_id, session.Movie_id).
-		RunWith(r.DB).
+	rows, err := query.
 		PlaceholderFormat(sq.Dollar).
-		ExecContext(ctx)
+		RunWith(r.DB).
+		QueryContext(ctx)
+
 	if err != nil {
-		r.Log.Info("Failed to run query.",
+		r.Log.Info("Failed to run Showtimes query.",
 			zap.Error(err),
 		)
 
-		return false, internal.ErrInternalFailure
+		return nil, internal.ErrInternalFailure
 	}
 
-	rows, err := res.RowsAffected()
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		var (
+			res  Showtime
+			ends sql.NullTime
+		)
+
+		err = rows.Scan(&res.ID, &res.Movie_ID, &res.Movie, &res.Hall_ID, &res.VIP, &res.Starts_at, &ends,
+			&res.Capacity, &res.Remaining)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into showtime structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		if ends.Valid {
+			res.Ends_at = &ends.Time
+		}
+
+		data = append(data, &res)
+	}
+
+	var total int64
+
+	count, err := list.Filter(upcoming(sq.Select("COUNT(*)"), filter, now), q, showtimeFields)
+	if err != nil {
+		return nil, err
+	}
+
+	err = count.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&total)
 	if err != nil {
-		r.Log.Info("Failed to count rows query.",
+		r.Log.Info("Failed to count showtimes.",
 			zap.Error(err),
 		)
 
-		return false, internal.ErrInternalFailure
+		return nil, internal.ErrInternalFailure
 	}
 
-	if rows == 0 {
-		return true, nil
+	return list.Page(data, q, showtimeFields, total), nil
+}
+
+// upcoming selects from sessions starting within filter dates with seats left for sale
+func upcoming(b sq.SelectBuilder, filter *ShowtimeFilter, now time.Time) sq.SelectBuilder {
+
+	sessions := sq.
+		Select("sessions.id", "sessions.movie_id", "movies.name", "sessions.hall_id", "halls.vip", "sessions.starts_at").
+		Column("sessions.starts_at + movies.duration AS ends_at").
+		Column(capacity + " AS capacity").
+		Column(sq.Expr(remaining+" AS remaining", t.Refunded, t.Expired, now)).
+		From("sessions").
+		Join("movies ON sessions.movie_id = movies.id").
+		Join("halls ON sessions.hall_id = halls.id").
+		Where(sq.GtOrEq{
+			"sessions.starts_at": filter.From,
+		})
+
+	if !filter.To.IsZero() {
+		sessions = sessions.Where(sq.Lt{"sessions.starts_at": filter.To})
+	}
+
+	b = b.FromSelect(sessions, "showtimes")
+
+	if filter.Available {
+		b = b.Where(sq.Gt{"remaining": 0})
 	}
 
-	return false, internal.ErrValidationFailed
+	return b
 }
@@ -7,6 +7,7 @@ import (
 	"log"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -65,11 +66,11 @@ func TestCreate(t *testing.T) {
 					WillReturnRows(sqlm2.
 						NewRows([]string{"id"}).
 						AddRow(session.ID))
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
+				sqlm2.ExpectQuery("SELECT sessions.id, sessions.hall_id, sessions.movie_id, halls.vip, movies.name, starts_at, sessions.version FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
 					WithArgs(session.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"id", "vip", "name", "starts_at"}).
-						AddRow(session.ID, session.VIP, session.Name, session.Starts_at))
+						NewRows([]string{"id", "hall_id", "movie_id", "vip", "name", "starts_at", "version"}).
+						AddRow(session.ID, session.Hall_id, session.Movie_id, session.VIP, session.Name, session.Starts_at, session.Version))
 			},
 			object: session,
 		},
@@ -138,11 +139,11 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: session,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
+				sqlm2.ExpectQuery("SELECT sessions.id, sessions.hall_id, sessions.movie_id, halls.vip, movies.name, starts_at, sessions.version FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
 					WithArgs(session.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"id", "vip", "name", "starts_at"}).
-						AddRow(session.ID, session.VIP, session.Name, session.Starts_at))
+						NewRows([]string{"id", "hall_id", "movie_id", "vip", "name", "starts_at", "version"}).
+						AddRow(session.ID, session.Hall_id, session.Movie_id, session.VIP, session.Name, session.Starts_at, session.Version))
 			},
 		},
 		{
@@ -150,7 +151,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
+				sqlm2.ExpectQuery("SELECT sessions.id, sessions.hall_id, sessions.movie_id, halls.vip, movies.name, starts_at, sessions.version FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 		},
@@ -159,7 +160,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
+				sqlm2.ExpectQuery("SELECT sessions.id, sessions.hall_id, sessions.movie_id, halls.vip, movies.name, starts_at, sessions.version FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = \\$1").
 					WillReturnRows(sqlm2.
 						NewRows(nil))
 			},
@@ -201,18 +202,20 @@ func TestRetrieveAll(t *testing.T) {
 	testRetrieveAllCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult []internal.Identifiable
+		expectedResult *internal.Page
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{session},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{session}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 1}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id").
+				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at, sessions.hall_id, sessions.movie_id FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id").
 					WillReturnRows(sqlm2.
-						NewRows([]string{"id", "vip", "name", "starts_at"}).
-						AddRow(session.ID, session.VIP, session.Name, session.Starts_at))
+						NewRows([]string{"id", "vip", "name", "starts_at", "hall_id", "movie_id"}).
+						AddRow(session.ID, session.VIP, session.Name, session.Starts_at, session.Hall_id, session.Movie_id))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM sessions")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
 			},
 		},
 		{
@@ -220,17 +223,19 @@ func TestRetrieveAll(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id").
+				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at, sessions.hall_id, sessions.movie_id FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id").
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
 		{
 			name:           "failed, sql no rows error",
 			expectedError:  nil,
-			expectedResult: nil,
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: internal.DefaultLimit}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id").
-					WillReturnError(sql.ErrNoRows)
+				sqlm2.ExpectQuery("SELECT sessions.id, halls.vip, movies.name, starts_at, sessions.hall_id, sessions.movie_id FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id").
+					WillReturnRows(sqlm2.NewRows([]string{}))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM sessions")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
 			},
 		},
 	}
@@ -253,7 +258,7 @@ func TestRetrieveAll(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			res, err := repo.RetrieveAll(ctx)
+			res, err := repo.RetrieveAll(&internal.Query{}, ctx)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
 		})
@@ -272,6 +277,7 @@ func TestDelete(t *testing.T) {
 		expectedResult internal.Identifiable
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 		id             int64
+		version        int64
 	}{
 		{
 			name:           "success",
@@ -284,6 +290,30 @@ func TestDelete(t *testing.T) {
 			},
 			id: int64(session.ID),
 		},
+		{
+			name:           "success, expected version",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM sessions WHERE id = $1 AND version = $2")).
+					WithArgs(session.ID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			id:      int64(session.ID),
+			version: 3,
+		},
+		{
+			name:           "failed, version mismatch",
+			expectedError:  internal.ErrVersionMismatch,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM sessions WHERE id = $1 AND version = $2")).
+					WithArgs(session.ID, 3).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			id:      int64(session.ID),
+			version: 3,
+		},
 		{
 			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
@@ -315,61 +345,142 @@ func TestDelete(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			err = repo.Delete(tc.id, ctx)
+			err = repo.Delete(tc.id, tc.version, ctx)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
-func TestTimeValid(t *testing.T) {
-	db, mock := NewMock()
-	defer func() {
-		db.Close()
-	}()
+func TestConflicts(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT sessions.id, sessions.hall_id, sessions.movie_id, movies.name, sessions.starts_at, " +
+		"sessions.starts_at + movies.duration FROM sessions JOIN movies ON sessions.movie_id = movies.id " +
+		"WHERE sessions.hall_id = $1 AND sessions.id <> $2 " +
+		"AND (CAST($3 AS timestamp), (SELECT duration FROM movies WHERE id = $4) + INTERVAL '1 second' * $5) " +
+		"OVERLAPS (sessions.starts_at, movies.duration + INTERVAL '1 second' * $6) ORDER BY sessions.starts_at")
+	planned := &Resource{Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 08:00:00"}
+	busy := &Resource{ID: 3, Hall_id: 4, Movie_id: 1, Name: "Matrix", Starts_at: "2022-01-01 06:30:00", Ends_at: "2022-01-01 07:50:00"}
+	rows := []string{"id", "hall_id", "movie_id", "name", "starts_at", "ends_at"}
+
+	testConflictsCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult []*Resource
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success, hall is free",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(planned.Hall_id, planned.ID, planned.Starts_at, planned.Movie_id, 900, 900).
+					WillReturnRows(sqlm2.NewRows(rows))
+			},
+		},
+		{
+			name:           "success, turnover overlaps",
+			expectedError:  nil,
+			expectedResult: []*Resource{busy},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(planned.Hall_id, planned.ID, planned.Starts_at, planned.Movie_id, 900, 900).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(busy.ID, busy.Hall_id, busy.Movie_id, busy.Name, busy.Starts_at, busy.Ends_at))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testConflictsCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			conflicts, err := repo.Conflicts(planned, 15*time.Minute, ctx, tx)
+			assert.Equal(t, tc.expectedResult, conflicts)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
 
-	testTimeValidCases := []struct {
+func TestLockHall(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT id FROM halls WHERE id = $1 FOR UPDATE")
+
+	testLockHallCases := []struct {
 		name           string
 		expectedError  error
 		expectedResult bool
 		prepare        func(sqlm2 sqlmock.Sqlmock)
-		object         internal.Identifiable
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
 			expectedResult: true,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectExec(regexp.QuoteMeta("SELECT movies.duration, sessions.starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id WHERE ($1, movies.duration) OVERLAPS (sessions.starts_at , movies.duration) AND sessions.hall_id = $2 AND sessions.movie_id = $3")).
-					WithArgs(session.Starts_at, session.Hall_id, session.Movie_id).
-					WillReturnResult(sqlmock.NewResult(1, 0))
+				sqlm2.ExpectQuery(query).
+					WithArgs(4).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(4))
 			},
-			object: session,
 		},
 		{
-			name:           "failed, validation error",
-			expectedError:  internal.ErrInternalFailure,
+			name:           "failed, no hall",
+			expectedError:  nil,
 			expectedResult: false,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectExec(regexp.QuoteMeta("SELECT movies.duration, sessions.starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id WHERE ($1, movies.duration) OVERLAPS (sessions.starts_at , movies.duration) AND sessions.hall_id = $2 AND sessions.movie_id = $3")).
-					WithArgs(session.Starts_at, session.Hall_id, session.Movie_id).
-					WillReturnError(internal.ErrInternalFailure)
+				sqlm2.ExpectQuery(query).
+					WithArgs(4).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}))
 			},
-			object: session,
 		},
 		{
-			name:           "failed, assertion error",
-			expectedError:  internal.ErrValidationFailed,
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
 			expectedResult: false,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectExec(regexp.QuoteMeta("SELECT movies.duration, sessions.starts_at FROM sessions JOIN movies ON sessions.movie_id = movies.id WHERE ($1, movies.duration) OVERLAPS (sessions.starts_at , movies.duration) AND sessions.hall_id = $2 AND sessions.movie_id = $3")).
-					WillReturnError(internal.ErrValidationFailed)
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
-			object: nil,
 		},
 	}
 
-	for _, tc := range testTimeValidCases {
+	for _, tc := range testLockHallCases {
 		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
 
 			logger, err := zap.NewProduction()
 			if err != nil {
@@ -385,9 +496,286 @@ func TestTimeValid(t *testing.T) {
 			repo := &Repository{DB: db, Log: logger}
 			ctx := context.Background()
 
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
 			tc.prepare(mock)
-			valid, err := repo.TimeValid(tc.object, ctx)
-			assert.Equal(t, tc.expectedResult, valid)
+
+			found, err := repo.LockHall(4, ctx, tx)
+			assert.Equal(t, tc.expectedResult, found)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT EXTRACT(EPOCH FROM duration) FROM movies WHERE id = $1")).
+		WithArgs(2).
+		WillReturnRows(mock.NewRows([]string{"date_part"}).AddRow(8100.0))
+
+	runtime, err := repo.Runtime(2, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Hour+15*time.Minute, runtime)
+}
+
+func TestInsert(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	planned := &Resource{Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 08:00:00"}
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO sessions (hall_id,movie_id,starts_at) VALUES ($1,$2,$3) RETURNING "id"`)).
+		WithArgs(planned.Hall_id, planned.Movie_id, planned.Starts_at).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(16))
+
+	id, err := repo.Insert(planned, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), id)
+}
+
+func TestLock(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	query := regexp.QuoteMeta("SELECT id, hall_id, movie_id, starts_at, version FROM sessions WHERE id = $1 FOR UPDATE")
+
+	mock.ExpectQuery(query).
+		WithArgs(15).
+		WillReturnRows(mock.NewRows([]string{"id", "hall_id", "movie_id", "starts_at", "version"}).AddRow(15, 4, 2, "2022-01-01 08:00:00", 3))
+
+	res, err := repo.Lock(15, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, &Resource{ID: 15, Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 08:00:00", Version: 3}, res)
+
+	mock.ExpectQuery(query).
+		WithArgs(16).
+		WillReturnRows(mock.NewRows([]string{"id", "hall_id", "movie_id", "starts_at", "version"}))
+
+	res, err = repo.Lock(16, ctx, tx)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestUpdate(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+	moved := &Resource{ID: 15, Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 10:00:00"}
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE sessions SET hall_id = $1, movie_id = $2, starts_at = $3 WHERE id = $4")).
+		WithArgs(moved.Hall_id, moved.Movie_id, moved.Starts_at, moved.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = repo.Update(moved, ctx, tx)
+	assert.NoError(t, err)
+}
+
+func TestSold(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM tickets WHERE session_id = $1 AND status NOT IN ($2,$3)")).
+		WithArgs(15, "refunded", "expired").
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(3))
+
+	sold, err := repo.Sold(15, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), sold)
+}
+
+func TestUpcoming(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	tx, err := repo.DB.Begin()
+	if err != nil {
+		log.Fatalf("can't start transaction : %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, hall_id, movie_id, starts_at FROM sessions WHERE movie_id = $1 AND starts_at > NOW() ORDER BY starts_at")).
+		WithArgs(2).
+		WillReturnRows(mock.NewRows([]string{"id", "hall_id", "movie_id", "starts_at"}).
+			AddRow(15, 4, 2, "2022-01-01 08:00:00").
+			AddRow(16, 5, 2, "2022-01-01 12:00:00"))
+
+	upcoming, err := repo.Upcoming(2, ctx, tx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Resource{
+		{ID: 15, Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 08:00:00"},
+		{ID: 16, Hall_id: 5, Movie_id: 2, Starts_at: "2022-01-01 12:00:00"},
+	}, upcoming)
+}
+
+func TestShowtimes(t *testing.T) {
+	capacity := "CASE WHEN EXISTS (SELECT 1 FROM hall_seats WHERE hall_seats.hall_id = halls.id) " +
+		"THEN (SELECT COUNT(*) FROM hall_seats WHERE hall_seats.hall_id = halls.id AND NOT hall_seats.blocked) " +
+		"ELSE COALESCE(halls.seats, 0) END"
+	showtimes := "(SELECT sessions.id, sessions.movie_id, movies.name, sessions.hall_id, halls.vip, " +
+		"sessions.starts_at, sessions.starts_at + movies.duration AS ends_at, " + capacity + " AS capacity, " + capacity +
+		" - (SELECT COUNT(*) FROM tickets WHERE tickets.session_id = sessions.id AND tickets.status NOT IN ($1,$2))" +
+		" - (SELECT COUNT(*) FROM seat_holds WHERE seat_holds.session_id = sessions.id AND seat_holds.expires_at > $3) AS remaining " +
+		"FROM sessions JOIN movies ON sessions.movie_id = movies.id JOIN halls ON sessions.hall_id = halls.id " +
+		"WHERE sessions.starts_at >= $4 AND sessions.starts_at < $5) AS showtimes WHERE remaining > $6 AND showtimes.vip = $7"
+	query := regexp.QuoteMeta("SELECT * FROM " + showtimes +
+		" AND (showtimes.starts_at, showtimes.id) > ($8, $9) ORDER BY showtimes.starts_at ASC, showtimes.id ASC LIMIT 3")
+	count := regexp.QuoteMeta("SELECT COUNT(*) FROM " + showtimes)
+
+	now := time.Date(2022, time.April, 1, 12, 0, 0, 0, time.UTC)
+	to := time.Date(2022, time.April, 8, 0, 0, 0, 0, time.UTC)
+	after := time.Date(2022, time.April, 1, 16, 0, 0, 0, time.UTC)
+	starts := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	ends := starts.Add(2 * time.Hour)
+	filter := &ShowtimeFilter{From: now, To: to, Available: true}
+	cursor := internal.EncodeCursor(internal.Cursor{Sort: "starts_at", Key: after.Format(time.RFC3339), ID: 14})
+	rows := []string{"id", "movie_id", "name", "hall_id", "vip", "starts_at", "ends_at", "capacity", "remaining"}
+
+	testShowtimesCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *internal.Page
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			expectedResult: &internal.Page{
+				Data: []internal.Identifiable{
+					&Showtime{ID: 15, Movie_ID: 2, Movie: "Matrix", Hall_ID: 4, VIP: true, Starts_at: starts, Ends_at: &ends, Capacity: 50, Remaining: 12},
+					&Showtime{ID: 16, Movie_ID: 3, Movie: "Dune", Hall_ID: 4, VIP: true, Starts_at: ends, Capacity: 50, Remaining: 50},
+				},
+				Meta: internal.Meta{Limit: 2, Total: 5, Next: internal.EncodeCursor(internal.Cursor{Sort: "starts_at", Key: ends.Format(time.RFC3339Nano), ID: 16})},
+			},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs("refunded", "expired", now, now, to, 0, true, after, 14).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(15, 2, "Matrix", 4, true, starts, ends, 50, 12).
+						AddRow(16, 3, "Dune", 4, true, ends, nil, 50, 50).
+						AddRow(17, 3, "Dune", 4, true, ends, nil, 50, 50))
+				sqlm2.ExpectQuery(count).
+					WithArgs("refunded", "expired", now, now, to, 0, true).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(5))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testShowtimesCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			q := &internal.Query{Limit: 2, Cursor: cursor, Sort: "starts_at", Filters: map[string]string{"vip": "true"}}
+
+			tc.prepare(mock)
+
+			showtimes, err := repo.Showtimes(filter, q, now, context.Background())
+			assert.Equal(t, tc.expectedResult, showtimes)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
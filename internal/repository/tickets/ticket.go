@@ -3,11 +3,16 @@ package tickets
 import (
 	"context"
 	"database/sql"
+	"strconv"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+	"github.com/darkjedidj/cinema-service/internal/repository/pricing"
 )
 
 // Repository is a struct to store DB and logger connection
@@ -21,16 +26,114 @@ type Resource struct {
 	Starts_at  string `json:"Starts_at"`
 	Price      float64
 	Seat       int64
+	Row        int64
+	Number     int64
 	ID         int64
 	Title      string
 	User_ID    int64
 	Session_ID int64
+	Hall_ID    int64
+	Status     string
+	Hold       string             `json:"Hold,omitempty"`
+	Promo_Code string             `json:"Promo_Code,omitempty"`
+	Discount   float64            `json:"Discount,omitempty"` // Part of price taken off by promo code
+	Breakdown  *pricing.Breakdown `json:"Breakdown,omitempty"`
+	Refund     *Refund            `json:"Refund,omitempty"`
+
+	Version int64 `json:"-"` // Grows with every change
 }
 
 func (r *Resource) GID() int64 {
 	return r.ID
 }
 
+func (r *Resource) Revision() int64 {
+	return r.Version
+}
+
+// Ticket statuses
+const (
+	Reserved  = "reserved"   // Seat is kept for customer until payment
+	Paid      = "paid"       // Ticket is paid but not downloaded yet
+	Issued    = "issued"     // Ticket file was handed to customer
+	CheckedIn = "checked-in" // Customer was admitted to session
+	Refunded  = "refunded"
+	Expired   = "expired" // Session admission closed before ticket was used
+)
+
+// Refund policies
+const (
+	Full    = "full"
+	Partial = "partial"
+	None    = "none"
+	Forced  = "forced" // Refunded by administrator regardless of policy
+)
+
+// Refund is a struct to store refund of cancelled ticket
+type Refund struct {
+	Amount      float64   `json:"amount"`
+	Policy      string    `json:"policy"`
+	Reason      string    `json:"reason,omitempty"`
+	Refunded_at time.Time `json:"refunded_at"`
+}
+
+// Code is a struct to store signed validation code of ticket
+type Code struct {
+	Ticket_ID  int64     `json:"ticket_id"`
+	Code       string    `json:"code"`
+	Expires_at time.Time `json:"expires_at"`
+}
+
+func (c *Code) GID() int64 {
+	return c.Ticket_ID
+}
+
+// Seat is a struct to store availability of a single seat
+type Seat struct {
+	Row        int64  `json:"row"`
+	Number     int64  `json:"number"`
+	Category   string `json:"category"`
+	Wheelchair bool   `json:"wheelchair"`
+	Blocked    bool   `json:"blocked"`
+	Aisle      bool   `json:"aisle"`
+	Taken      bool   `json:"taken"`
+	Held       bool   `json:"held"`
+}
+
+// SeatMap is a struct to store availability of all seats in session
+type SeatMap struct {
+	Session_ID int64  `json:"session_id"`
+	Free       int64  `json:"free"`
+	Seats      []Seat `json:"seats"`
+}
+
+func (r *SeatMap) GID() int64 {
+	return r.Session_ID
+}
+
+// columns are selected for ticket with session details
+var columns = []string{
+	"tickets.id", "user_id", "price", "session_id", "movies.name", "tickets.seat", "tickets.seat_row",
+	"tickets.seat_number", "sessions.starts_at", "sessions.hall_id", "tickets.price_breakdown", "tickets.status",
+	"tickets.refund_amount", "tickets.refund_policy", "tickets.refund_reason", "tickets.refunded_at",
+	"tickets.promo_code", "tickets.discount", "tickets.version",
+}
+
+// Filters of user tickets by session start
+const (
+	Upcoming = "upcoming"
+	Past     = "past"
+)
+
+// Filter is a struct to store user tickets query
+type Filter struct {
+	User_ID int64
+	When    string // Upcoming, Past or empty for all tickets
+}
+
+// uniqueViolation is a postgres error code for unique constraint violation
+const uniqueViolation = "23505"
+
 // Create new entity in storage
 func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sql.Tx) (int64, error) {
 	var id int
@@ -46,14 +149,18 @@ func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sq
 
 	err := sq.
 		Insert("tickets").
-		Columns("user_id", "price", "session_id", "seat").
-		Values(ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Seat).
+		Columns("user_id", "price", "session_id", "seat", "seat_row", "seat_number", "price_breakdown", "status").
+		Values(ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Seat, ticket.Row, ticket.Number, ticket.Breakdown, ticket.Status).
 		Suffix("RETURNING \"id\"").
 		PlaceholderFormat(sq.Dollar).
 		RunWith(tx).
 		QueryRow().
 		Scan(&id)
 
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return 0, internal.ErrSeatTaken
+	}
+
 	if err != nil {
 		r.Log.Info("Failed to run Create tickets query.",
 			zap.Error(err),
@@ -65,12 +172,40 @@ func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sq
 	return int64(id), nil
 }
 
+// Discount stores promo code discount of bought ticket with its reduced price and breakdown
+func (r *Repository) Discount(ticket *Resource, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("tickets").
+		SetMap(map[string]interface{}{
+			"price":           ticket.Price,
+			"price_breakdown": ticket.Breakdown,
+			"promo_code":      ticket.Promo_Code,
+			"discount":        ticket.Discount,
+		}).
+		Where(sq.Eq{
+			"id": ticket.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Discount ticket query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
 // Retrieve entity from storage
 func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
-	var res Resource
 
-	err := sq.
-		Select("tickets.id", "user_id", "price", "session_id", "movies.name", "tickets.seat", "sessions.starts_at").
+	row := sq.
+		Select(columns...).
 		From("tickets").
 		Join("sessions ON tickets.session_id = sessions.id").
 		Join("movies ON sessions.movie_id = movies.id").
@@ -79,9 +214,9 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 		}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
-		QueryRowContext(ctx).
-		Scan(&res.ID, &res.User_ID, &res.Price, &res.Session_ID, &res.Title, &res.Seat, &res.Starts_at)
+		QueryRowContext(ctx)
 
+	res, err := scan(row)
 	if err == sql.ErrNoRows {
 
 		return nil, nil
@@ -95,63 +230,276 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 		return nil, internal.ErrInternalFailure
 	}
 
-	return &res, nil
+	return res, nil
 }
 
-// Delete entity in storage
-func (r *Repository) Delete(id int64, ctx context.Context) error {
+// Lock selects ticket and locks it until transaction ends
+func (r *Repository) Lock(id int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
 
-	_, err := sq.
-		Delete("tickets").
+	row := sq.
+		Select(columns...).
+		From("tickets").
+		Join("sessions ON tickets.session_id = sessions.id").
+		Join("movies ON sessions.movie_id = movies.id").
+		Where(sq.Eq{
+			"tickets.id": id,
+		}).
+		Suffix("FOR UPDATE OF tickets").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx)
+
+	res, err := scan(row)
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock ticket query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// Refund marks ticket as refunded releasing its seat, reports if ticket was in one of from statuses
+func (r *Repository) Refund(id int64, refund *Refund, from []string, ctx context.Context, tx *sql.Tx) (bool, error) {
+
+	res, err := sq.
+		Update("tickets").
+		Set("status", Refunded).
+		Set("refund_amount", refund.Amount).
+		Set("refund_policy", refund.Policy).
+		Set("refund_reason", nullString(refund.Reason)).
+		Set("refunded_at", refund.Refunded_at).
 		Where(sq.Eq{
 			"id": id,
 		}).
+		Where(sq.Eq{
+			"status": from,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Refund ticket query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count refunded tickets.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return rows > 0, nil
+}
+
+// Move changes ticket status, reports if ticket was in one of from statuses
+func (r *Repository) Move(id int64, from []string, to string, ctx context.Context, tx *sql.Tx) (bool, error) {
+
+	res, err := sq.
+		Update("tickets").
+		Set("status", to).
+		Where(sq.Eq{
+			"id":     id,
+			"status": from,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Move ticket query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count moved tickets.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return rows > 0, nil
+}
+
+// MoveByOrder changes status of order tickets which are in one of from statuses
+func (r *Repository) MoveByOrder(id int64, from []string, to string, ctx context.Context, tx *sql.Tx) (int64, error) {
+
+	res, err := sq.
+		Update("tickets").
+		Set("status", to).
+		Where(sq.Eq{
+			"order_id": id,
+			"status":   from,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run MoveByOrder tickets query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count moved tickets.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return rows, nil
+}
+
+// Expire marks tickets in one of from statuses for sessions started before passed time as expired
+func (r *Repository) Expire(before time.Time, from []string, ctx context.Context) (int64, error) {
+
+	res, err := sq.
+		Update("tickets").
+		Set("status", Expired).
+		Where(sq.Eq{
+			"status": from,
+		}).
+		Where("session_id IN (SELECT id FROM sessions WHERE starts_at < ?)", before).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		ExecContext(ctx)
 
 	if err != nil {
-		r.Log.Info("Failed to run Delete session query.",
+		r.Log.Info("Failed to run Expire tickets query.",
 			zap.Error(err),
 		)
 
-		return internal.ErrInternalFailure
+		return 0, internal.ErrInternalFailure
 	}
 
-	return nil
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count expired tickets.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return rows, nil
 }
 
-// RetrieveAll entity from storage
-func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
+// fields tickets list can be sorted and filtered by
+var fields = list.Fields{
+	"user_id":    {Column: "tickets.user_id", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*Resource).User_ID, 10) }},
+	"session_id": {Column: "tickets.session_id", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*Resource).Session_ID, 10) }},
+	"status":     {Column: "tickets.status", Kind: list.Text, Key: func(i internal.Identifiable) string { return i.(*Resource).Status }},
+}
 
-	rows, err := sq.
-		Select("tickets.id", "user_id", "price", "session_id", "movies.name", "tickets.seat", "sessions.starts_at").
+// RetrieveAll returns page of tickets matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(sq.
+		Select(columns...).
 		From("tickets").
 		Join("sessions ON tickets.session_id = sessions.id").
-		Join("movies ON sessions.movie_id = movies.id").
+		Join("movies ON sessions.movie_id = movies.id"), "tickets", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryContext(ctx)
+
 	if err != nil {
-		r.Log.Info("Failed to run RetrieveAll sessions query.",
+		r.Log.Info("Failed to run RetrieveAll tickets query.",
 			zap.Error(err),
 		)
 
 		return nil, internal.ErrInternalFailure
 	}
 
-	var data []*Resource
+	var data []internal.Identifiable
 
 	for rows.Next() {
-		res := &Resource{}
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into ticket structures.",
+				zap.Error(err),
+			)
 
-		err = rows.Scan(&res.ID, &res.User_ID, &res.Price, &res.Session_ID, &res.Title, &res.Seat, &res.Starts_at)
-		if err == sql.ErrNoRows {
-			return nil, nil
+			return nil, internal.ErrInternalFailure
 		}
 
+		data = append(data, res)
+	}
+
+	total, err := list.Total(r.DB, "tickets", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count tickets.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, fields, total), nil
+}
+
+// mine are fields user tickets list can be sorted and filtered by
+var mine = list.Fields{
+	"starts_at":  {Column: "sessions.starts_at", Kind: list.Time, Key: func(i internal.Identifiable) string { return i.(*Resource).Starts_at }},
+	"session_id": fields["session_id"],
+	"status":     fields["status"],
+}
+
+// RetrieveByUser returns page of user tickets matching query
+func (r *Repository) RetrieveByUser(f Filter, q *internal.Query, now time.Time, ctx context.Context) (*internal.Page, error) {
+
+	query, err := list.Select(owned(sq.Select(columns...), f, now), "tickets", q, mine)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveByUser tickets query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []internal.Identifiable
+
+	for rows.Next() {
+		res, err := scan(rows)
 		if err != nil {
-			r.Log.Info("Failed to scan rows into session structures.",
+			r.Log.Info("Failed to scan rows into ticket structures.",
 				zap.Error(err),
 			)
 
@@ -161,72 +509,169 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		data = append(data, res)
 	}
 
-	var dataSlice []*Resource = data
-	var interfaceSlice []internal.Identifiable = make([]internal.Identifiable, len(dataSlice))
-	for i, d := range dataSlice {
-		interfaceSlice[i] = d
+	var total int64
+
+	count, err := list.Filter(owned(sq.Select("COUNT(*)"), f, now), q, mine)
+	if err != nil {
+		return nil, err
 	}
 
-	return interfaceSlice, nil
+	err = count.
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&total)
+	if err != nil {
+		r.Log.Info("Failed to count user tickets.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return list.Page(data, q, mine, total), nil
 }
 
-func (r *Repository) SeatNumber(id int64, ctx context.Context, tx *sql.Tx) (internal.Identifiable, error) {
-	var seat sql.NullInt64
+// owned selects tickets of user with their sessions, filtered by session start
+func owned(b sq.SelectBuilder, f Filter, now time.Time) sq.SelectBuilder {
+	b = b.
+		From("tickets").
+		Join("sessions ON tickets.session_id = sessions.id").
+		Join("movies ON sessions.movie_id = movies.id").
+		Where(sq.Eq{
+			"tickets.user_id": f.User_ID,
+		})
+
+	switch f.When {
+	case Upcoming:
+		b = b.Where(sq.GtOrEq{"sessions.starts_at": now})
+	case Past:
+		b = b.Where(sq.Lt{"sessions.starts_at": now})
+	}
+
+	return b
+}
 
-	var res Resource
+// RetrieveByOrder returns tickets bought in order
+func (r *Repository) RetrieveByOrder(id int64, ctx context.Context) ([]*Resource, error) {
 
-	err := sq.
-		Select("MAX(tickets.seat)").
+	rows, err := sq.
+		Select(columns...).
 		From("tickets").
 		Join("sessions ON tickets.session_id = sessions.id").
-		Join("halls ON sessions.hall_id = halls.id").
+		Join("movies ON sessions.movie_id = movies.id").
 		Where(sq.Eq{
-			"sessions.id": id,
+			"tickets.order_id": id,
 		}).
+		OrderBy("tickets.id").
 		PlaceholderFormat(sq.Dollar).
-		RunWith(tx).
-		QueryRowContext(ctx).
-		Scan(&seat)
-
+		RunWith(r.DB).
+		QueryContext(ctx)
 	if err != nil {
-		r.Log.Info("Failed to run Retrieve session query.",
+		r.Log.Info("Failed to run RetrieveByOrder tickets query.",
 			zap.Error(err),
 		)
 
 		return nil, internal.ErrInternalFailure
 	}
 
-	res.Seat = seat.Int64
+	data := []*Resource{}
 
-	return &res, nil
-}
+	for rows.Next() {
+		res, err := scan(rows)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into ticket structures.",
+				zap.Error(err),
+			)
 
-func (r *Repository) HallSeatNumber(id int64, ctx context.Context, tx *sql.Tx) (internal.Identifiable, error) {
-	var seat sql.NullInt64
+			return nil, internal.ErrInternalFailure
+		}
 
-	var res Resource
+		data = append(data, res)
+	}
 
-	err := sq.
-		Select("halls.seats").
-		From("sessions").
-		Join("halls ON sessions.hall_id = halls.id").
+	return data, nil
+}
+
+// TakenSeats returns seats already sold for session
+func (r *Repository) TakenSeats(id int64, ctx context.Context, tx *sql.Tx) ([]int64, error) {
+
+	rows, err := sq.
+		Select("tickets.seat").
+		From("tickets").
 		Where(sq.Eq{
-			"sessions.id": id,
+			"tickets.session_id": id,
+		}).
+		Where(sq.NotEq{
+			"tickets.status": []string{Refunded, Expired},
 		}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(tx).
-		QueryRowContext(ctx).
-		Scan(&seat)
-
+		QueryContext(ctx)
 	if err != nil {
-		r.Log.Info("Failed to run Retrieve session query.",
+		r.Log.Info("Failed to run TakenSeats query.",
 			zap.Error(err),
 		)
 
 		return nil, internal.ErrInternalFailure
 	}
 
-	res.Seat = seat.Int64
+	var data []int64
+
+	for rows.Next() {
+		var seat int64
+
+		err = rows.Scan(&seat)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into seats.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, seat)
+	}
+
+	return data, nil
+}
+
+// scan reads ticket with nullable refund columns
+func scan(row sq.RowScanner) (*Resource, error) {
+	var (
+		res    Resource
+		amount sql.NullFloat64
+		policy sql.NullString
+		reason sql.NullString
+		at     sql.NullTime
+		promo  sql.NullString
+	)
+
+	err := row.Scan(&res.ID, &res.User_ID, &res.Price, &res.Session_ID, &res.Title, &res.Seat, &res.Row, &res.Number,
+		&res.Starts_at, &res.Hall_ID, &res.Breakdown, &res.Status, &amount, &policy, &reason, &at, &promo, &res.Discount, &res.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Promo_Code = promo.String
+
+	if res.Status == Refunded {
+		res.Refund = &Refund{
+			Amount:      amount.Float64,
+			Policy:      policy.String,
+			Reason:      reason.String,
+			Refunded_at: at.Time,
+		}
+	}
 
 	return &res, nil
 }
+
+// nullString stores empty text as NULL
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
@@ -3,28 +3,43 @@ package tickets
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/pricing"
 )
 
 var ticket = &Resource{
 	Starts_at:  "13:25",
 	Price:      12.2,
 	Seat:       1,
+	Row:        1,
+	Number:     1,
 	ID:         1,
 	Title:      "Matrix",
 	User_ID:    1,
 	Session_ID: 1,
+	Hall_ID:    2,
+	Status:     Paid,
+	Breakdown: &pricing.Breakdown{
+		Lines: []pricing.Line{{Kind: pricing.Base, Name: "Standard ticket", Amount: 12.2}},
+		Total: 12.2,
+	},
+	Version: 1,
 }
 
+var breakdown = []byte(`{"lines":[{"kind":"base","name":"Standard ticket","amount":12.2}],"total":12.2}`)
+
 func NewMock() (*sql.DB, sqlmock.Sqlmock) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -65,17 +80,30 @@ func TestCreate(t *testing.T) {
 					WillReturnRows(sqlm2.
 						NewRows([]string{"id"}).
 						AddRow(ticket.ID))
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
 					WithArgs(ticket.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "starts_at"}).
-						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Starts_at))
+						NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
 			},
 			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
 				sqlm2.ExpectCommit()
 			},
 			object: ticket,
 		},
+		{
+			name:           "failed, seat taken",
+			expectedError:  internal.ErrSeatTaken,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("INSERT INTO tickets (.*)").
+					WillReturnError(&pq.Error{Code: "23505"})
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+			object: ticket,
+		},
 		{
 			name:           "failed, retrieve error",
 			expectedError:  internal.ErrInternalFailure,
@@ -161,11 +189,11 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: ticket,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
 					WithArgs(ticket.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "starts_at"}).
-						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Starts_at))
+						NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
 			},
 			id: int64(ticket.ID),
 		},
@@ -174,7 +202,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 			id: int64(ticket.ID),
@@ -184,7 +212,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = \\$1").
 					WillReturnRows(sqlm2.
 						NewRows(nil))
 			},
@@ -227,18 +255,20 @@ func TestRetrieveAll(t *testing.T) {
 	testRetrieveAllCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult []internal.Identifiable
+		expectedResult *internal.Page
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{ticket},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{ticket}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 1}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id").
 					WillReturnRows(sqlm2.
-						NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "starts_at"}).
-						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Starts_at))
+						NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM tickets")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
 			},
 		},
 		{
@@ -246,17 +276,19 @@ func TestRetrieveAll(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id").
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
 		{
 			name:           "failed, sql no rows error",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: internal.DefaultLimit}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, sessions.starts_at FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id").
+				sqlm2.ExpectQuery("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id").
 					WillReturnRows(sqlm2.NewRows([]string{}))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM tickets")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
 			},
 		},
 	}
@@ -279,52 +311,298 @@ func TestRetrieveAll(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			res, err := repo.RetrieveAll(ctx)
+			res, err := repo.RetrieveAll(&internal.Query{}, ctx)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
-func TestDelete(t *testing.T) {
+func TestTakenSeats(t *testing.T) {
+	testTakenSeatsCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    []int64
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: []int64{1, 4},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT tickets.seat FROM tickets WHERE tickets.session_id = $1 AND tickets.status NOT IN ($2,$3)")).
+					WithArgs(ticket.Session_ID, Refunded, Expired).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"seat"}).
+						AddRow(1).
+						AddRow(4))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "success, no tickets sold",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT tickets.seat FROM tickets WHERE tickets.session_id = $1 AND tickets.status NOT IN ($2,$3)")).
+					WithArgs(ticket.Session_ID, Refunded, Expired).
+					WillReturnRows(sqlm2.NewRows([]string{"seat"}))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT tickets.seat FROM tickets WHERE tickets.session_id = $1 AND tickets.status NOT IN ($2,$3)")).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testTakenSeatsCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			taken, err := repo.TakenSeats(ticket.Session_ID, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, taken)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieveByUser(t *testing.T) {
 	db, mock := NewMock()
 	defer func() {
 		db.Close()
 	}()
 
-	testDeleteCases := []struct {
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	after := time.Date(2022, time.April, 1, 10, 0, 0, 0, time.UTC)
+	owned := "FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.user_id = $1"
+	query := "SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version " + owned
+	columns := []string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}
+
+	testRetrieveByUserCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult internal.Identifiable
+		expectedResult *internal.Page
+		filter         Filter
+		query          *internal.Query
 		prepare        func(sqlm2 sqlmock.Sqlmock)
-		id             int64
+	}{
+		{
+			name:           "success, upcoming",
+			expectedError:  nil,
+			expectedResult: &internal.Page{Data: []internal.Identifiable{ticket}, Meta: internal.Meta{Limit: 20, Total: 1}},
+			filter:         Filter{User_ID: ticket.User_ID, When: Upcoming},
+			query:          &internal.Query{Limit: 20, Sort: "starts_at"},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(query+" AND sessions.starts_at >= $2 ORDER BY sessions.starts_at ASC, tickets.id ASC LIMIT 21")).
+					WithArgs(ticket.User_ID, now).
+					WillReturnRows(sqlm2.
+						NewRows(columns).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) "+owned+" AND sessions.starts_at >= $2")).
+					WithArgs(ticket.User_ID, now).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
+			},
+		},
+		{
+			name:           "success, past after cursor",
+			expectedError:  nil,
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: 20, Total: 3}},
+			filter:         Filter{User_ID: ticket.User_ID, When: Past},
+			query: &internal.Query{Limit: 20, Sort: "starts_at", Desc: true,
+				Cursor: internal.EncodeCursor(internal.Cursor{Sort: "starts_at", Desc: true, Key: after.Format(time.RFC3339), ID: 7})},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(query+" AND sessions.starts_at < $2 AND (sessions.starts_at, tickets.id) < ($3, $4) ORDER BY sessions.starts_at DESC, tickets.id DESC LIMIT 21")).
+					WithArgs(ticket.User_ID, now, after, 7).
+					WillReturnRows(sqlm2.NewRows(columns))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) "+owned+" AND sessions.starts_at < $2")).
+					WithArgs(ticket.User_ID, now).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(3))
+			},
+		},
+		{
+			name:           "success, all with status",
+			expectedError:  nil,
+			expectedResult: &internal.Page{Data: []internal.Identifiable{ticket}, Meta: internal.Meta{Limit: 5, Total: 1}},
+			filter:         Filter{User_ID: ticket.User_ID},
+			query:          &internal.Query{Limit: 5, Sort: "starts_at", Desc: true, Filters: map[string]string{"status": ticket.Status}},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(query+" AND tickets.status = $2 ORDER BY sessions.starts_at DESC, tickets.id DESC LIMIT 6")).
+					WithArgs(ticket.User_ID, ticket.Status).
+					WillReturnRows(sqlm2.
+						NewRows(columns).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) "+owned+" AND tickets.status = $2")).
+					WithArgs(ticket.User_ID, ticket.Status).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
+			},
+		},
+		{
+			name:           "failed, unknown filter",
+			expectedError:  internal.ErrValidationFailed,
+			expectedResult: nil,
+			filter:         Filter{User_ID: ticket.User_ID},
+			query:          &internal.Query{Filters: map[string]string{"price": "10"}},
+			prepare:        func(sqlm2 sqlmock.Sqlmock) {},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			filter:         Filter{User_ID: ticket.User_ID},
+			query:          &internal.Query{Limit: 20},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta(query)).
+					WillReturnError(internal.ErrInternalFailure)
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveByUserCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.RetrieveByUser(tc.filter, tc.query, now, ctx)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.True(t, errors.Is(err, tc.expectedError))
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	refundedAt := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	refunded := *ticket
+	refunded.Status = Refunded
+	refunded.Refund = &Refund{Amount: 6.1, Policy: Partial, Refunded_at: refundedAt}
+
+	query := regexp.QuoteMeta("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = $1 FOR UPDATE OF tickets")
+	columns := []string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}
+
+	testLockCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    *Resource
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
 			expectedResult: ticket,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectExec("DELETE FROM tickets WHERE id = \\$1").
+				sqlm2.ExpectQuery(query).
 					WithArgs(ticket.ID).
-					WillReturnResult(sqlmock.NewResult(1, 1))
+					WillReturnRows(sqlm2.
+						NewRows(columns).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "success, refunded",
+			expectedError:  nil,
+			expectedResult: &refunded,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(ticket.ID).
+					WillReturnRows(sqlm2.
+						NewRows(columns).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, Refunded, 6.1, Partial, nil, refundedAt, nil, 0, ticket.Version))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, sql no rows error",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(ticket.ID).
+					WillReturnRows(sqlm2.NewRows(columns))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
 			},
-			id: int64(ticket.ID),
 		},
 		{
 			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectExec("DELETE FROM tickets WHERE id = \\$1").
-					WithArgs(ticket.ID).
+				sqlm2.ExpectQuery(query).
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
-			id: int64(ticket.ID),
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
 		},
 	}
 
-	for _, tc := range testDeleteCases {
+	for _, tc := range testLockCases {
 		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
 
 			logger, err := zap.NewProduction()
 			if err != nil {
@@ -340,50 +618,68 @@ func TestDelete(t *testing.T) {
 			repo := &Repository{DB: db, Log: logger}
 			ctx := context.Background()
 
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
 			tc.prepare(mock)
-			err = repo.Delete(tc.id, ctx)
+
+			res, err := repo.Lock(ticket.ID, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
-func TestSeatNumber(t *testing.T) {
-	testSeatNumberCases := []struct {
+func TestRefund(t *testing.T) {
+	refund := &Refund{Amount: 12.2, Policy: Forced, Reason: "Projector is broken", Refunded_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)}
+	query := regexp.QuoteMeta("UPDATE tickets SET status = $1, refund_amount = $2, refund_policy = $3, refund_reason = $4, refunded_at = $5 WHERE id = $6 AND status IN ($7,$8)")
+	from := []string{Paid, Issued}
+
+	testRefundCases := []struct {
 		name              string
 		expectedError     error
-		expectedResult    internal.Identifiable
+		expectedResult    bool
 		prepare           func(sqlm2 sqlmock.Sqlmock)
 		transactionResult func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
-			name:          "success",
-			expectedError: nil,
-			expectedResult: &Resource{
-				Starts_at:  "",
-				Price:      0,
-				Seat:       1,
-				ID:         0,
-				Title:      "",
-				User_ID:    0,
-				Session_ID: 0,
-			},
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: true,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT MAX(tickets.seat) FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1")).
-					WithArgs(ticket.Session_ID).
-					WillReturnRows(sqlm2.
-						NewRows([]string{"seat"}).
-						AddRow(ticket.Seat))
+				sqlm2.ExpectExec(query).
+					WithArgs(Refunded, refund.Amount, refund.Policy, refund.Reason, refund.Refunded_at, ticket.ID, Paid, Issued).
+					WillReturnResult(sqlmock.NewResult(0, 1))
 			},
 			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
 				sqlm2.ExpectCommit()
 			},
 		},
+		{
+			name:           "failed, already refunded",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(Refunded, refund.Amount, refund.Policy, refund.Reason, refund.Refunded_at, ticket.ID, Paid, Issued).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
 		{
 			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
-			expectedResult: nil,
+			expectedResult: false,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT MAX(tickets.seat) FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1")).
+				sqlm2.ExpectExec(query).
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
@@ -392,7 +688,7 @@ func TestSeatNumber(t *testing.T) {
 		},
 	}
 
-	for _, tc := range testSeatNumberCases {
+	for _, tc := range testRefundCases {
 		t.Run(tc.name, func(t *testing.T) {
 			db, mock := NewMock()
 			defer func() {
@@ -421,42 +717,124 @@ func TestSeatNumber(t *testing.T) {
 
 			tc.prepare(mock)
 
-			lastSeat, err := repo.SeatNumber(ticket.Session_ID, ctx, tx)
+			refunded, err := repo.Refund(ticket.ID, refund, from, ctx, tx)
 
 			tc.transactionResult(mock)
 
-			assert.Equal(t, tc.expectedResult, lastSeat)
+			assert.Equal(t, tc.expectedResult, refunded)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
-func TestHallSeatNumber(t *testing.T) {
-	testHallSeatNumberCases := []struct {
+func TestMove(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE id = $2 AND status IN ($3,$4)")
+	from := []string{Paid, Issued}
+
+	testMoveCases := []struct {
 		name              string
 		expectedError     error
-		expectedResult    internal.Identifiable
+		expectedResult    bool
 		prepare           func(sqlm2 sqlmock.Sqlmock)
 		transactionResult func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
-			name:          "success",
-			expectedError: nil,
-			expectedResult: &Resource{
-				Starts_at:  "",
-				Price:      0,
-				Seat:       1,
-				ID:         0,
-				Title:      "",
-				User_ID:    0,
-				Session_ID: 0,
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(CheckedIn, ticket.ID, Paid, Issued).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, status has changed",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(CheckedIn, ticket.ID, Paid, Issued).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
 			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1")).
-					WithArgs(ticket.Session_ID).
-					WillReturnRows(sqlm2.
-						NewRows([]string{"seat"}).
-						AddRow(ticket.Seat))
+				sqlm2.ExpectExec(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testMoveCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			moved, err := repo.Move(ticket.ID, from, CheckedIn, ctx, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, moved)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestMoveByOrder(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE order_id = $2 AND status IN ($3)")
+
+	testMoveByOrderCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    int64
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: 2,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(Paid, 1, Reserved).
+					WillReturnResult(sqlmock.NewResult(0, 2))
 			},
 			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
 				sqlm2.ExpectCommit()
@@ -465,9 +843,9 @@ func TestHallSeatNumber(t *testing.T) {
 		{
 			name:           "failed, database error",
 			expectedError:  internal.ErrInternalFailure,
-			expectedResult: nil,
+			expectedResult: 0,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1")).
+				sqlm2.ExpectExec(query).
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
@@ -476,7 +854,7 @@ func TestHallSeatNumber(t *testing.T) {
 		},
 	}
 
-	for _, tc := range testHallSeatNumberCases {
+	for _, tc := range testMoveByOrderCases {
 		t.Run(tc.name, func(t *testing.T) {
 			db, mock := NewMock()
 			defer func() {
@@ -505,11 +883,136 @@ func TestHallSeatNumber(t *testing.T) {
 
 			tc.prepare(mock)
 
-			lastSeat, err := repo.HallSeatNumber(ticket.Session_ID, ctx, tx)
+			moved, err := repo.MoveByOrder(1, []string{Reserved}, Paid, ctx, tx)
 
 			tc.transactionResult(mock)
 
-			assert.Equal(t, tc.expectedResult, lastSeat)
+			assert.Equal(t, tc.expectedResult, moved)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestExpire(t *testing.T) {
+	before := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE status IN ($2,$3,$4) AND session_id IN (SELECT id FROM sessions WHERE starts_at < $5)")
+	from := []string{Reserved, Paid, Issued}
+
+	testExpireCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult int64
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: 3,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(Expired, Reserved, Paid, Issued, before).
+					WillReturnResult(sqlmock.NewResult(0, 3))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testExpireCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			expired, err := repo.Expire(before, from, context.Background())
+
+			assert.Equal(t, tc.expectedResult, expired)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieveByOrder(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT tickets.id, user_id, price, session_id, movies.name, tickets.seat, tickets.seat_row, tickets.seat_number, sessions.starts_at, sessions.hall_id, tickets.price_breakdown, tickets.status, tickets.refund_amount, tickets.refund_policy, tickets.refund_reason, tickets.refunded_at, tickets.promo_code, tickets.discount, tickets.version FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.order_id = $1 ORDER BY tickets.id")
+	columns := []string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}
+
+	testRetrieveByOrderCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult []*Resource
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: []*Resource{ticket},
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(1).
+					WillReturnRows(sqlm2.
+						NewRows(columns).
+						AddRow(ticket.ID, ticket.User_ID, ticket.Price, ticket.Session_ID, ticket.Title, ticket.Seat, ticket.Row, ticket.Number, ticket.Starts_at, ticket.Hall_ID, breakdown, ticket.Status, nil, nil, nil, nil, nil, 0, ticket.Version))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveByOrderCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			tickets, err := repo.RetrieveByOrder(1, context.Background())
+
+			assert.Equal(t, tc.expectedResult, tickets)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
@@ -518,4 +1021,7 @@ func TestHallSeatNumber(t *testing.T) {
 func TestGID(t *testing.T) {
 	res := &Resource{ID: ticket.ID}
 	assert.Equal(t, ticket.ID, res.GID())
+
+	seats := &SeatMap{Session_ID: ticket.Session_ID}
+	assert.Equal(t, ticket.Session_ID, seats.GID())
 }
@@ -5,9 +5,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"regexp"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 
@@ -63,11 +65,11 @@ func TestCreate(t *testing.T) {
 					WillReturnRows(sqlm2.
 						NewRows([]string{"id"}).
 						AddRow(user_privileges.ID))
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
 					WithArgs(user_privileges.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"user.email", "id", "privileges.name"}).
-						AddRow(user_privileges.Email, user_privileges.ID, user_privileges.Privilege))
+						NewRows([]string{"user.email", "id", "privileges.name", "user_id", "privilege_id"}).
+						AddRow(user_privileges.Email, user_privileges.ID, user_privileges.Privilege, user_privileges.User_id, user_privileges.Privilege_id))
 			},
 			object: user_privileges,
 		},
@@ -81,6 +83,26 @@ func TestCreate(t *testing.T) {
 			},
 			object: user_privileges,
 		},
+		{
+			name:           "failed, privilege already granted",
+			expectedError:  internal.ErrPrivilegeGranted,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("INSERT INTO user_privileges (.*)").
+					WillReturnError(&pq.Error{Code: uniqueViolation})
+			},
+			object: user_privileges,
+		},
+		{
+			name:           "failed, unknown user",
+			expectedError:  internal.ErrNotFound,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("INSERT INTO user_privileges (.*)").
+					WillReturnError(&pq.Error{Code: foreignKeyViolation})
+			},
+			object: user_privileges,
+		},
 		{
 			name:           "failed, assertion error",
 			expectedError:  internal.ErrInternalFailure,
@@ -136,11 +158,11 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: user_privileges,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
 					WithArgs(user_privileges.ID).
 					WillReturnRows(sqlm2.
-						NewRows([]string{"user.email", "id", "privileges.name"}).
-						AddRow(user_privileges.Email, user_privileges.ID, user_privileges.Privilege))
+						NewRows([]string{"user.email", "id", "privileges.name", "user_id", "privilege_id"}).
+						AddRow(user_privileges.Email, user_privileges.ID, user_privileges.Privilege, user_privileges.User_id, user_privileges.Privilege_id))
 			},
 		},
 		{
@@ -148,7 +170,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
@@ -157,7 +179,7 @@ func TestRetrieve(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.id = \\$1").
 					WillReturnRows(sqlm2.
 						NewRows(nil))
 			},
@@ -199,18 +221,20 @@ func TestRetrieveAll(t *testing.T) {
 	testRetrieveAllCases := []struct {
 		name           string
 		expectedError  error
-		expectedResult []internal.Identifiable
+		expectedResult *internal.Page
 		prepare        func(sqlm2 sqlmock.Sqlmock)
 	}{
 		{
 			name:           "success",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{user_privileges},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{user_privileges}, Meta: internal.Meta{Limit: internal.DefaultLimit, Total: 1}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id").
 					WillReturnRows(sqlm2.
-						NewRows([]string{"user.email", "id", "privileges.name"}).
-						AddRow(user_privileges.Email, user_privileges.ID, user_privileges.Privilege))
+						NewRows([]string{"user.email", "id", "privileges.name", "user_id", "privilege_id"}).
+						AddRow(user_privileges.Email, user_privileges.ID, user_privileges.Privilege, user_privileges.User_id, user_privileges.Privilege_id))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM user_privileges")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(1))
 			},
 		},
 		{
@@ -218,17 +242,19 @@ func TestRetrieveAll(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id").
 					WillReturnError(internal.ErrInternalFailure)
 			},
 		},
 		{
 			name:           "failed, sql no rows error",
 			expectedError:  nil,
-			expectedResult: []internal.Identifiable{},
+			expectedResult: &internal.Page{Data: []internal.Identifiable{}, Meta: internal.Meta{Limit: internal.DefaultLimit}},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id").
+				sqlm2.ExpectQuery("SELECT users.email, user_privileges.id, privileges.name, user_privileges.user_id, user_privileges.privilege_id FROM user_privileges JOIN users ON user_privileges.user_id = users.id JOIN privileges ON user_privileges.privilege_id = privileges.id").
 					WillReturnRows(sqlm2.NewRows([]string{}))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM user_privileges")).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(0))
 			},
 		},
 	}
@@ -251,13 +277,34 @@ func TestRetrieveAll(t *testing.T) {
 			ctx := context.Background()
 
 			tc.prepare(mock)
-			res, err := repo.RetrieveAll(ctx)
+			res, err := repo.RetrieveAll(&internal.Query{}, ctx)
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
 		})
 	}
 }
 
+func TestUpdate(t *testing.T) {
+	db, mock := NewMock()
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	repo := &Repository{DB: db, Log: logger}
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE user_privileges SET user_id = $1, privilege_id = $2 WHERE id = $3")).
+		WithArgs(user_privileges.User_id, user_privileges.Privilege_id, user_privileges.ID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	res, err := repo.Update(user_privileges, ctx)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+}
+
 func TestDelete(t *testing.T) {
 	db, mock := NewMock()
 	defer func() {
@@ -3,11 +3,20 @@ package user_privileges
 import (
 	"context"
 	"database/sql"
+	"strconv"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/list"
+)
+
+// Postgres error codes of constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
 )
 
 // Repository is a struct to store storage and logger connection
@@ -52,6 +61,14 @@ func (r *Repository) Create(i internal.Identifiable, ctx context.Context) (inter
 		QueryRowContext(ctx).
 		Scan(&id)
 
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return nil, internal.ErrPrivilegeGranted
+	}
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return nil, internal.ErrNotFound
+	}
+
 	if err != nil {
 		r.Log.Info("Failed to run Create user_privilege query.",
 			zap.Error(err),
@@ -68,7 +85,7 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 	var res Resource
 
 	err := sq.
-		Select("users.email", "user_privileges.id", "privileges.name").
+		Select("users.email", "user_privileges.id", "privileges.name", "user_privileges.user_id", "user_privileges.privilege_id").
 		From("user_privileges").
 		Join("users ON user_privileges.user_id = users.id").
 		Join("privileges ON user_privileges.privilege_id = privileges.id").
@@ -78,7 +95,7 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryRowContext(ctx).
-		Scan(&res.Email, &res.ID, &res.Privilege)
+		Scan(&res.Email, &res.ID, &res.Privilege, &res.User_id, &res.Privilege_id)
 
 	if err == sql.ErrNoRows {
 
@@ -96,6 +113,61 @@ func (r *Repository) Retrieve(id int64, ctx context.Context) (internal.Identifia
 	return &res, nil
 }
 
+// Update entity in storage, returns nil when there's no such user privilege
+func (r *Repository) Update(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+
+	user_privilege, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to update user_privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	result, err := sq.
+		Update("user_privileges").
+		Set("user_id", user_privilege.User_id).
+		Set("privilege_id", user_privilege.Privilege_id).
+		Where(sq.Eq{
+			"id": user_privilege.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return nil, internal.ErrPrivilegeGranted
+	}
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == foreignKeyViolation {
+		return nil, internal.ErrNotFound
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Update user_privilege query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count updated user_privileges.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	if updated == 0 {
+		return nil, nil
+	}
+
+	return r.Retrieve(user_privilege.ID, ctx)
+}
+
 // Delete entity in storage
 func (r *Repository) Delete(id int64, ctx context.Context) error {
 
@@ -119,16 +191,28 @@ func (r *Repository) Delete(id int64, ctx context.Context) error {
 	return nil
 }
 
-// RetrieveAll entity from storage
-func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
+// fields user privileges list can be sorted and filtered by
+var fields = list.Fields{
+	"user_id":      {Column: "user_privileges.user_id", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*Resource).User_id, 10) }},
+	"privilege_id": {Column: "user_privileges.privilege_id", Kind: list.Int, Key: func(i internal.Identifiable) string { return strconv.FormatInt(i.(*Resource).Privilege_id, 10) }},
+}
+
+// RetrieveAll returns page of user privileges matching query
+func (r *Repository) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
 
-	rows, err := sq.
-		Select("users.email", "user_privileges.id", "privileges.name").
+	query, err := list.Select(sq.
+		Select("users.email", "user_privileges.id", "privileges.name", "user_privileges.user_id", "user_privileges.privilege_id").
 		From("user_privileges").
 		Join("users ON user_privileges.user_id = users.id").
-		Join("privileges ON user_privileges.privilege_id = privileges.id").
+		Join("privileges ON user_privileges.privilege_id = privileges.id"), "user_privileges", q, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := query.
 		PlaceholderFormat(sq.Dollar).
-		RunWith(r.DB).QueryContext(ctx)
+		RunWith(r.DB).
+		QueryContext(ctx)
 
 	if err != nil {
 		r.Log.Info("Failed to run RetrieveAll user_privilege query.",
@@ -138,16 +222,12 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		return nil, internal.ErrInternalFailure
 	}
 
-	var data []*Resource
+	var data []internal.Identifiable
 
 	for rows.Next() {
 		res := &Resource{}
 
-		err = rows.Scan(&res.Email, &res.ID, &res.Privilege)
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
+		err = rows.Scan(&res.Email, &res.ID, &res.Privilege, &res.User_id, &res.Privilege_id)
 		if err != nil {
 			r.Log.Info("Failed to scan rows into user_privilege structures.",
 				zap.Error(err),
@@ -159,11 +239,14 @@ func (r *Repository) RetrieveAll(ctx context.Context) ([]internal.Identifiable,
 		data = append(data, res)
 	}
 
-	var dataSlice []*Resource = data
-	var interfaceSlice []internal.Identifiable = make([]internal.Identifiable, len(dataSlice))
-	for i, d := range dataSlice {
-		interfaceSlice[i] = d
+	total, err := list.Total(r.DB, "user_privileges", q, fields, ctx)
+	if err != nil {
+		r.Log.Info("Failed to count user privileges.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
 	}
 
-	return interfaceSlice, nil
+	return list.Page(data, q, fields, total), nil
 }
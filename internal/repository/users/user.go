@@ -3,11 +3,14 @@ package user
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	role "github.com/darkjedidj/cinema-service/internal/repository/roles"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
 )
 
 // Repository is a struct to store DB and logger connection
@@ -57,6 +60,31 @@ func (r *Repository) Create(i internal.Identifiable, ctx context.Context) error
 	return nil
 }
 
+// Insert stores user in transaction and returns its ID
+func (r *Repository) Insert(user *Resource, ctx context.Context, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	err := sq.
+		Insert("users").
+		Columns("email", "password").
+		Values(user.EMail, user.Password).
+		Suffix("RETURNING id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if err != nil {
+		r.Log.Info("Failed to run Insert user query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
 // Retrieve entity from storage
 func (r *Repository) Retrieve(email string, ctx context.Context) (internal.Identifiable, error) {
 	var res Resource
@@ -88,24 +116,30 @@ func (r *Repository) Retrieve(email string, ctx context.Context) (internal.Ident
 	return &res, nil
 }
 
-// RetrievePrivileges entity from storage
-func (r *Repository) RetrievePrivileges(id int64) ([]string, error) {
+// RetrievePermissions returns names of permissions user holds through roles or legacy
+// privileges, superadmin holds all of them
+func (r *Repository) RetrievePermissions(id int64, ctx context.Context) ([]string, error) {
 
 	var data []string
 
 	rows, err := sq.
-		Select("privileges.name").
-		From("privileges").
-		Join("user_privileges on user_privileges.privilege_id = privileges.id").
-		Where(sq.Eq{
-			"user_privileges.user_id": id,
+		Select("permissions.name").
+		From("permissions").
+		Where(sq.Or{
+			sq.Expr("permissions.id IN (SELECT role_permissions.permission_id FROM role_permissions "+
+				"JOIN user_roles ON user_roles.role_id = role_permissions.role_id WHERE user_roles.user_id = ?)", id),
+			sq.Expr("permissions.privilege IN (SELECT privileges.name FROM privileges "+
+				"JOIN user_privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.user_id = ?)", id),
+			sq.Expr("EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id "+
+				"WHERE user_roles.user_id = ? AND roles.name = ?)", id, role.Superadmin),
 		}).
+		OrderBy("permissions.name").
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
-		Query()
+		QueryContext(ctx)
 
 	if err != nil {
-		r.Log.Info("Failed to run Retrieve privileges query.",
+		r.Log.Info("Failed to run Retrieve permissions query.",
 			zap.Error(err),
 		)
 
@@ -115,12 +149,8 @@ func (r *Repository) RetrievePrivileges(id int64) ([]string, error) {
 		var name string
 
 		err = rows.Scan(&name)
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-
 		if err != nil {
-			r.Log.Info("Failed to scan rows into privileges",
+			r.Log.Info("Failed to scan rows into permissions",
 				zap.Error(err),
 			)
 
@@ -144,6 +174,9 @@ func (r *Repository) RetrieveTickets(ticket int64, user int64) (bool, error) {
 			"tickets.id":      ticket,
 			"tickets.user_id": user,
 		}).
+		Where(sq.NotEq{
+			"tickets.status": t.Refunded,
+		}).
 		PlaceholderFormat(sq.Dollar).
 		RunWith(r.DB).
 		QueryRow().
@@ -164,3 +197,70 @@ func (r *Repository) RetrieveTickets(ticket int64, user int64) (bool, error) {
 
 	return true, nil
 }
+
+// BirthDate returns verified birth date of user, nil when user didn't prove age yet
+func (r *Repository) BirthDate(id int64, ctx context.Context) (*time.Time, error) {
+	var born sql.NullTime
+
+	err := sq.
+		Select("birth_date").
+		From("users").
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryRowContext(ctx).
+		Scan(&born)
+
+	if err == sql.ErrNoRows {
+
+		return nil, nil
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve birth date query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	if !born.Valid {
+		return nil, nil
+	}
+
+	return &born.Time, nil
+}
+
+// SetBirthDate stores birth date staff verified with document of user, false when there's no such user
+func (r *Repository) SetBirthDate(id int64, born time.Time, ctx context.Context) (bool, error) {
+	res, err := sq.
+		Update("users").
+		Set("birth_date", born).
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Set birth date query.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count users with birth date set.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	return rows > 0, nil
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
@@ -110,6 +111,71 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestInsert(t *testing.T) {
+	query := regexp.QuoteMeta("INSERT INTO users (email,password) VALUES ($1,$2) RETURNING id")
+
+	testInsertCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult int64
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: user.ID,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(user.EMail, user.Password).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(user.ID))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("duplicate key value violates unique constraint"))
+			},
+		},
+	}
+
+	for _, tc := range testInsertCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := db.Begin()
+			assert.NoError(t, err)
+
+			tc.prepare(mock)
+			res, err := repo.Insert(user, ctx, tx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestRetrieve(t *testing.T) {
 	db, mock := NewMock()
 	defer func() {
@@ -181,7 +247,15 @@ func TestRetrieve(t *testing.T) {
 	}
 }
 
-func TestRetrievePrivileges(t *testing.T) {
+func TestRetrievePermissions(t *testing.T) {
+	permissions := "SELECT permissions.name FROM permissions WHERE (" +
+		"permissions.id IN (SELECT role_permissions.permission_id FROM role_permissions " +
+		"JOIN user_roles ON user_roles.role_id = role_permissions.role_id WHERE user_roles.user_id = $1) OR " +
+		"permissions.privilege IN (SELECT privileges.name FROM privileges " +
+		"JOIN user_privileges ON user_privileges.privilege_id = privileges.id WHERE user_privileges.user_id = $2) OR " +
+		"EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id " +
+		"WHERE user_roles.user_id = $3 AND roles.name = $4)) ORDER BY permissions.name"
+
 	db, mock := NewMock()
 	defer func() {
 		db.Close()
@@ -196,13 +270,14 @@ func TestRetrievePrivileges(t *testing.T) {
 		{
 			name:           "success",
 			expectedError:  nil,
-			expectedResult: []string{"hall"},
+			expectedResult: []string{"halls:read", "halls:write"},
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT privileges.name FROM privileges JOIN user_privileges on user_privileges.privilege_id = privileges.id WHERE user_privileges.user_id = \\$1").
-					WithArgs(user.ID).
+				sqlm2.ExpectQuery(regexp.QuoteMeta(permissions)).
+					WithArgs(user.ID, user.ID, user.ID, "superadmin").
 					WillReturnRows(sqlm2.
-						NewRows([]string{"privileges.name"}).
-						AddRow("hall"))
+						NewRows([]string{"permissions.name"}).
+						AddRow("halls:read").
+						AddRow("halls:write"))
 			},
 		},
 		{
@@ -210,8 +285,8 @@ func TestRetrievePrivileges(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: nil,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT privileges.name FROM privileges JOIN user_privileges on user_privileges.privilege_id = privileges.id WHERE user_privileges.user_id = \\$1").
-					WithArgs(user.ID).
+				sqlm2.ExpectQuery(regexp.QuoteMeta(permissions)).
+					WithArgs(user.ID, user.ID, user.ID, "superadmin").
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 		},
@@ -234,7 +309,7 @@ func TestRetrievePrivileges(t *testing.T) {
 			repo := &Repository{DB: db, Log: logger}
 
 			tc.prepare(mock)
-			res, err := repo.RetrievePrivileges(user.ID)
+			res, err := repo.RetrievePermissions(user.ID, context.Background())
 
 			assert.Equal(t, tc.expectedResult, res)
 			assert.Equal(t, tc.expectedError, err)
@@ -259,8 +334,8 @@ func TestRetrieveTickets(t *testing.T) {
 			expectedError:  nil,
 			expectedResult: true,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id FROM tickets WHERE tickets.id = \\$1 AND tickets.user_id = \\$2").
-					WithArgs(1, user.ID).
+				sqlm2.ExpectQuery("SELECT tickets.id FROM tickets WHERE tickets.id = \\$1 AND tickets.user_id = \\$2 AND tickets.status <> \\$3").
+					WithArgs(1, user.ID, "refunded").
 					WillReturnRows(sqlm2.
 						NewRows([]string{"tickets.id"}).
 						AddRow(1))
@@ -271,8 +346,8 @@ func TestRetrieveTickets(t *testing.T) {
 			expectedError:  internal.ErrInternalFailure,
 			expectedResult: false,
 			prepare: func(sqlm2 sqlmock.Sqlmock) {
-				sqlm2.ExpectQuery("SELECT tickets.id FROM tickets WHERE tickets.id = \\$1 AND tickets.user_id = \\$2").
-					WithArgs(1, user.ID).
+				sqlm2.ExpectQuery("SELECT tickets.id FROM tickets WHERE tickets.id = \\$1 AND tickets.user_id = \\$2 AND tickets.status <> \\$3").
+					WithArgs(1, user.ID, "refunded").
 					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
 			},
 		},
@@ -303,6 +378,163 @@ func TestRetrieveTickets(t *testing.T) {
 	}
 }
 
+func TestBirthDate(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	born := time.Date(2004, time.May, 17, 0, 0, 0, 0, time.UTC)
+
+	testBirthDateCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *time.Time
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: &born,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("SELECT birth_date FROM users WHERE id = \\$1").
+					WithArgs(user.ID).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"birth_date"}).
+						AddRow(born))
+			},
+		},
+		{
+			name:           "success, not verified",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("SELECT birth_date FROM users WHERE id = \\$1").
+					WithArgs(user.ID).
+					WillReturnRows(sqlm2.
+						NewRows([]string{"birth_date"}).
+						AddRow(nil))
+			},
+		},
+		{
+			name:           "failed, sql no rows error",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("SELECT birth_date FROM users WHERE id = \\$1").
+					WithArgs(user.ID).
+					WillReturnRows(sqlm2.
+						NewRows(nil))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery("SELECT birth_date FROM users WHERE id = \\$1").
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testBirthDateCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.BirthDate(user.ID, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestSetBirthDate(t *testing.T) {
+	db, mock := NewMock()
+	defer func() {
+		db.Close()
+	}()
+
+	born := time.Date(2004, time.May, 17, 0, 0, 0, 0, time.UTC)
+
+	testSetBirthDateCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult bool
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: true,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec("UPDATE users SET birth_date = \\$1 WHERE id = \\$2").
+					WithArgs(born, user.ID).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:           "success, no user",
+			expectedError:  nil,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec("UPDATE users SET birth_date = \\$1 WHERE id = \\$2").
+					WithArgs(born, user.ID).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: false,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec("UPDATE users SET birth_date = \\$1 WHERE id = \\$2").
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testSetBirthDateCases {
+		t.Run(tc.name, func(t *testing.T) {
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			tc.prepare(mock)
+			res, err := repo.SetBirthDate(user.ID, born, ctx)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
 func TestGID(t *testing.T) {
 	res := &Resource{ID: user.ID}
 	assert.Equal(t, user.ID, res.GID())
@@ -0,0 +1,400 @@
+package waitlist
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+// uniqueViolation is a postgres error code for unique constraint violation
+const uniqueViolation = "23505"
+
+// Entry statuses
+const (
+	Waiting  = "waiting"
+	Offered  = "offered"  // Seat is held for user until offer expires
+	Accepted = "accepted" // User bought offered seat
+	Expired  = "expired"  // Offer or session passed
+	Left     = "left"     // User left waitlist
+)
+
+// Repository is a struct to store DB and logger connection
+type Repository struct {
+	DB  *sql.DB
+	Log *zap.Logger
+}
+
+// Resource is a struct to store data about entity
+type Resource struct {
+	ID         int64
+	Session_ID int64
+	User_ID    int64
+	Status     string
+	Position   int64      // Place in queue while waiting
+	Hold       string     `json:",omitempty"` // Token of offered seat hold
+	Row        int64      `json:",omitempty"`
+	Number     int64      `json:",omitempty"`
+	Expires_at *time.Time `json:",omitempty"` // Offer deadline
+	Created_at time.Time
+}
+
+func (r *Resource) GID() int64 {
+	return r.ID
+}
+
+var columns = []string{"id", "session_id", "user_id", "status", "hold", "seat_row", "seat_number", "expires_at", "created_at"}
+
+// position counts waiting entries of the same session queued before entry
+var position = sq.Expr("CASE WHEN status = ? THEN (SELECT COUNT(*) FROM waitlist AS queue "+
+	"WHERE queue.session_id = waitlist.session_id AND queue.status = ? AND queue.id <= waitlist.id) ELSE 0 END", Waiting, Waiting)
+
+// Create new entity in storage
+func (r *Repository) Create(ctx context.Context, i internal.Identifiable, tx *sql.Tx) (int64, error) {
+	var id int64
+
+	entry, ok := i.(*Resource)
+	if !ok {
+		r.Log.Info("Failed to create waitlist object.",
+			zap.Bool("ok", ok),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	err := sq.
+		Insert("waitlist").
+		Columns("session_id", "user_id", "status", "created_at").
+		Values(entry.Session_ID, entry.User_ID, Waiting, entry.Created_at).
+		Suffix("RETURNING \"id\"").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryRowContext(ctx).
+		Scan(&id)
+
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == uniqueViolation {
+		return 0, internal.ErrWaitlisted
+	}
+
+	if err != nil {
+		r.Log.Info("Failed to run Create waitlist query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return id, nil
+}
+
+// Retrieve returns active entry of user for session with queue position
+func (r *Repository) Retrieve(session int64, user int64, ctx context.Context) (*Resource, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		Column(position).
+		From("waitlist").
+		Where(sq.Eq{
+			"session_id": session,
+			"user_id":    user,
+			"status":     []string{Waiting, Offered},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Retrieve waitlist query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	data, err := r.scan(rows)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	return data[0], nil
+}
+
+// RetrieveByUser returns all entries of user, latest first
+func (r *Repository) RetrieveByUser(id int64, ctx context.Context) ([]*Resource, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		Column(position).
+		From("waitlist").
+		Where(sq.Eq{
+			"user_id": id,
+		}).
+		OrderBy("id DESC").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run RetrieveByUser waitlist query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return r.scan(rows)
+}
+
+// Lock selects active entry of user for session for update within transaction
+func (r *Repository) Lock(session int64, user int64, ctx context.Context, tx *sql.Tx) (*Resource, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		Column("0").
+		From("waitlist").
+		Where(sq.Eq{
+			"session_id": session,
+			"user_id":    user,
+			"status":     []string{Waiting, Offered},
+		}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Lock waitlist query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	data, err := r.scan(rows)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+
+	return data[0], nil
+}
+
+// Next locks first waiting entries of session in queue order, entries locked by others are skipped
+func (r *Repository) Next(session int64, limit uint64, ctx context.Context, tx *sql.Tx) ([]*Resource, error) {
+
+	rows, err := sq.
+		Select(columns...).
+		Column("0").
+		From("waitlist").
+		Where(sq.Eq{
+			"session_id": session,
+			"status":     Waiting,
+		}).
+		OrderBy("id").
+		Limit(limit).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Next waitlist query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return r.scan(rows)
+}
+
+// Offer stores seat hold offered to waiting entry
+func (r *Repository) Offer(e *Resource, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("waitlist").
+		SetMap(map[string]interface{}{
+			"status":      Offered,
+			"hold":        e.Hold,
+			"seat_row":    e.Row,
+			"seat_number": e.Number,
+			"expires_at":  e.Expires_at,
+		}).
+		Where(sq.Eq{
+			"id": e.ID,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Offer waitlist query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// SetStatus changes entry status
+func (r *Repository) SetStatus(id int64, status string, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("waitlist").
+		Set("status", status).
+		Where(sq.Eq{
+			"id": id,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run SetStatus waitlist query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Accept marks offer as accepted once its seat hold is bought
+func (r *Repository) Accept(hold string, ctx context.Context, tx *sql.Tx) error {
+
+	_, err := sq.
+		Update("waitlist").
+		Set("status", Accepted).
+		Where(sq.Eq{
+			"hold":   hold,
+			"status": Offered,
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(tx).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Accept waitlist query.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Lapse expires offers past their deadline and waiting entries of started sessions,
+// returns number of expired entries
+func (r *Repository) Lapse(now time.Time, ctx context.Context) (int64, error) {
+
+	res, err := sq.
+		Update("waitlist").
+		Set("status", Expired).
+		Where(sq.Or{
+			sq.And{
+				sq.Eq{"status": Offered},
+				sq.LtOrEq{"expires_at": now},
+			},
+			sq.And{
+				sq.Eq{"status": Waiting},
+				sq.Expr("session_id IN (SELECT id FROM sessions WHERE starts_at <= ?)", now),
+			},
+		}).
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		ExecContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Lapse waitlist query.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		r.Log.Info("Failed to count expired waitlist entries.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	return rows, nil
+}
+
+// Sessions returns sessions which have users waiting
+func (r *Repository) Sessions(ctx context.Context) ([]int64, error) {
+
+	rows, err := sq.
+		Select("DISTINCT session_id").
+		From("waitlist").
+		Where(sq.Eq{
+			"status": Waiting,
+		}).
+		OrderBy("session_id").
+		PlaceholderFormat(sq.Dollar).
+		RunWith(r.DB).
+		QueryContext(ctx)
+
+	if err != nil {
+		r.Log.Info("Failed to run Sessions waitlist query.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	var data []int64
+
+	for rows.Next() {
+		var id int64
+
+		err = rows.Scan(&id)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into sessions.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, id)
+	}
+
+	return data, nil
+}
+
+// scan reads waitlist entries followed by their queue position
+func (r *Repository) scan(rows *sql.Rows) ([]*Resource, error) {
+	defer rows.Close()
+
+	data := []*Resource{}
+
+	for rows.Next() {
+		var res Resource
+
+		err := rows.Scan(&res.ID, &res.Session_ID, &res.User_ID, &res.Status, &res.Hold, &res.Row, &res.Number,
+			&res.Expires_at, &res.Created_at, &res.Position)
+		if err != nil {
+			r.Log.Info("Failed to scan rows into waitlist structures.",
+				zap.Error(err),
+			)
+
+			return nil, internal.ErrInternalFailure
+		}
+
+		data = append(data, &res)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,290 @@
+package waitlist
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+var expires = time.Date(2022, time.April, 1, 18, 15, 0, 0, time.UTC)
+
+var entry = &Resource{
+	ID:         1,
+	Session_ID: 2,
+	User_ID:    3,
+	Status:     Waiting,
+	Position:   4,
+	Created_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC),
+}
+
+var offer = &Resource{
+	ID:         1,
+	Session_ID: 2,
+	User_ID:    3,
+	Status:     Offered,
+	Hold:       "f00d",
+	Row:        1,
+	Number:     5,
+	Expires_at: &expires,
+	Created_at: time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC),
+}
+
+var rows = []string{"id", "session_id", "user_id", "status", "hold", "seat_row", "seat_number", "expires_at", "created_at", "position"}
+
+func NewMock() (*sql.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	return db, mock
+}
+
+func TestCreate(t *testing.T) {
+	query := regexp.QuoteMeta(`INSERT INTO waitlist (session_id,user_id,status,created_at) VALUES ($1,$2,$3,$4) RETURNING "id"`)
+
+	testCreateCases := []struct {
+		name              string
+		expectedError     error
+		expectedResult    int64
+		prepare           func(sqlm2 sqlmock.Sqlmock)
+		transactionResult func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: entry.ID,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(entry.Session_ID, entry.User_ID, Waiting, entry.Created_at).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(entry.ID))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:           "failed, already waiting",
+			expectedError:  internal.ErrWaitlisted,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(&pq.Error{Code: uniqueViolation})
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+			transactionResult: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+			ctx := context.Background()
+
+			mock.ExpectBegin()
+			tx, err := repo.DB.Begin()
+			if err != nil {
+				log.Fatalf("can't start transaction : %v", err)
+			}
+
+			tc.prepare(mock)
+
+			id, err := repo.Create(ctx, entry, tx)
+
+			tc.transactionResult(mock)
+
+			assert.Equal(t, tc.expectedResult, id)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT id, session_id, user_id, status, hold, seat_row, seat_number, expires_at, created_at, " +
+		"CASE WHEN status = $1 THEN (SELECT COUNT(*) FROM waitlist AS queue WHERE queue.session_id = waitlist.session_id " +
+		"AND queue.status = $2 AND queue.id <= waitlist.id) ELSE 0 END FROM waitlist " +
+		"WHERE session_id = $3 AND status IN ($4,$5) AND user_id = $6")
+
+	testRetrieveCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult *Resource
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success, waiting",
+			expectedError:  nil,
+			expectedResult: entry,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(Waiting, Waiting, entry.Session_ID, Waiting, Offered, entry.User_ID).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(entry.ID, entry.Session_ID, entry.User_ID, Waiting, "", 0, 0, nil, entry.Created_at, entry.Position))
+			},
+		},
+		{
+			name:           "success, offered",
+			expectedError:  nil,
+			expectedResult: offer,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WithArgs(Waiting, Waiting, offer.Session_ID, Waiting, Offered, offer.User_ID).
+					WillReturnRows(sqlm2.NewRows(rows).
+						AddRow(offer.ID, offer.Session_ID, offer.User_ID, Offered, offer.Hold, offer.Row, offer.Number,
+							expires, offer.Created_at, 0))
+			},
+		},
+		{
+			name:           "success, not waiting",
+			expectedError:  nil,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnRows(sqlm2.NewRows(rows))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testRetrieveCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			res, err := repo.Retrieve(entry.Session_ID, entry.User_ID, context.Background())
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestLapse(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE waitlist SET status = $1 WHERE ((status = $2 AND expires_at <= $3) OR " +
+		"(status = $4 AND session_id IN (SELECT id FROM sessions WHERE starts_at <= $5)))")
+
+	testLapseCases := []struct {
+		name           string
+		expectedError  error
+		expectedResult int64
+		prepare        func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:           "success",
+			expectedError:  nil,
+			expectedResult: 2,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WithArgs(Expired, Offered, expires, Waiting, expires).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+			},
+		},
+		{
+			name:           "failed, database error",
+			expectedError:  internal.ErrInternalFailure,
+			expectedResult: 0,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(query).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+			},
+		},
+	}
+
+	for _, tc := range testLapseCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock := NewMock()
+			defer func() {
+				db.Close()
+			}()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			repo := &Repository{DB: db, Log: logger}
+
+			tc.prepare(mock)
+
+			expired, err := repo.Lapse(expires, context.Background())
+
+			assert.Equal(t, tc.expectedResult, expired)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestGID(t *testing.T) {
+	assert.Equal(t, entry.ID, entry.GID())
+}
@@ -3,44 +3,185 @@ package halls
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
 	h "github.com/darkjedidj/cinema-service/internal/repository/halls"
+	lt "github.com/darkjedidj/cinema-service/internal/repository/layouts"
 )
 
 // Service is a struct to store DB and logger connection
 type Service struct {
-	repo *h.Repository
-	log  *zap.Logger
+	repo    *h.Repository
+	layouts *lt.Repository
+	log     *zap.Logger
 }
 
 // Init returns Service object
 func Init(db *sql.DB, l *zap.Logger) *Service {
 
 	return &Service{
-		repo: &h.Repository{DB: db, Log: l},
-		log:  l,
+		repo:    &h.Repository{DB: db, Log: l},
+		layouts: &lt.Repository{DB: db, Log: l},
+		log:     l,
 	}
 }
 
 // Create logic layer for repository method
 func (s *Service) Create(r internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := r.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert hall object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
 	return s.repo.Create(r, ctx)
 }
 
+// Update replaces hall after create validation. Seats of hall with layout change with layout,
+// seats of plain hall change only while every sold seat keeps its place.
+// Hall is locked until it's replaced, so no ticket is sold between the check and the change.
+// Hall with version set is replaced only if nobody changed it since that version.
+func (s *Service) Update(id int64, r internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := r.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert hall object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.ID = id
+
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	current, err := s.repo.Lock(id, ctx, tx)
+	if err != nil || current == nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if res.Version > 0 && res.Version != current.Version {
+		return nil, s.rollback(tx, internal.ErrVersionMismatch)
+	}
+
+	if current.Seats != res.Seats || current.Rows != res.Rows {
+		err = s.reseat(res, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+	}
+
+	err = s.repo.Update(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(id, ctx)
+}
+
+// reseat checks seats of hall can change, every sold seat has to keep its place
+func (s *Service) reseat(res *h.Resource, ctx context.Context, tx *sql.Tx) error {
+	layout, err := s.layouts.Retrieve(res.ID, ctx)
+	if err != nil {
+		return err
+	}
+
+	if layout != nil {
+		return fmt.Errorf("%w: seats of hall with layout are changed with its layout", internal.ErrValidationFailed)
+	}
+
+	sold, err := s.repo.Sold(res.ID, ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	grid := lt.Grid(res.ID, int64(res.Rows), int64(res.Seats))
+
+	for _, place := range sold {
+		position, seat := grid.Seat(place.Row, place.Number)
+		if seat == nil || position != place.Seat {
+			return internal.ErrTicketsSold
+		}
+	}
+
+	return nil
+}
+
 // Retrieve logic layer for repository method
 func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
 	return s.repo.Retrieve(id, ctx)
 }
 
 // RetriveAll logic layer for repository method
-func (s *Service) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
-	return s.repo.RetrieveAll(ctx)
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
 }
 
 // Delete logic layer for repository method
 func (s *Service) Delete(id int64, ctx context.Context) error {
-	return s.repo.Delete(id, ctx)
+	return s.repo.Delete(id, 0, ctx)
+}
+
+// DeleteVersion deletes entity only while it still has expected version
+func (s *Service) DeleteVersion(id int64, version int64, ctx context.Context) error {
+	return s.repo.Delete(id, version, ctx)
+}
+
+// validate checks hall has rows and enough seats to fill them
+func validate(res *h.Resource) error {
+	if res.Rows < 1 {
+		return fmt.Errorf("%w: hall must have at least one row", internal.ErrValidationFailed)
+	}
+
+	if res.Rows > res.Seats {
+		return fmt.Errorf("%w: hall has more rows than seats", internal.ErrValidationFailed)
+	}
+
+	return nil
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
 }
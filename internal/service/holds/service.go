@@ -0,0 +1,212 @@
+package holds
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	h "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	lt "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// defaultTTL is used when HOLD_TTL is not set
+const defaultTTL = 10 * time.Minute
+
+// Hold limits keeping one user from blocking session sales
+const (
+	maxSeats = 10 // Seats in one hold, the same as tickets in one order
+	maxHolds = 3  // Active holds of one user
+)
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo    *h.Repository
+	layouts *lt.Repository
+	tickets *t.Repository
+	clock   clock.Clock
+	ttl     time.Duration
+	log     *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo:    &h.Repository{DB: db, Log: l},
+		layouts: &lt.Repository{DB: db, Log: l},
+		tickets: &t.Repository{DB: db, Log: l},
+		clock:   clock.Real{},
+		ttl:     TTL(),
+		log:     l,
+	}
+}
+
+// TTL reads hold lifetime from HOLD_TTL environment variable
+func TTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("HOLD_TTL"))
+	if err != nil || ttl <= 0 {
+		return defaultTTL
+	}
+
+	return ttl
+}
+
+// Create reserves seats for checkout and returns hold token
+func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert hold object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	if len(res.Seats) == 0 {
+		return nil, fmt.Errorf("%w: no seats selected", internal.ErrValidationFailed)
+	}
+
+	if len(res.Seats) > maxSeats {
+		return nil, fmt.Errorf("%w: hold can't have more than %d seats", internal.ErrValidationFailed, maxSeats)
+	}
+
+	token, err := NewToken()
+	if err != nil {
+		s.log.Info("Failed to generate hold token.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	now := s.clock.Now().UTC()
+	res.Token = token
+	res.Expires_at = now.Add(s.ttl)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	layout, err := s.layouts.RetrieveBySession(res.Session_ID, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if layout == nil {
+		return nil, s.rollback(tx, fmt.Errorf("%w: session does not exist", internal.ErrValidationFailed))
+	}
+
+	err = s.repo.Expire(res.Session_ID, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	active, err := s.repo.Active(res.User_ID, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if active >= maxHolds {
+		return nil, s.rollback(tx, fmt.Errorf("%w: release one of %d active holds first", internal.ErrHoldLimit, active))
+	}
+
+	sold, err := s.tickets.TakenSeats(res.Session_ID, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	held, err := s.repo.HeldSeats(res.Session_ID, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	busy := make(map[int64]bool, len(sold)+len(held))
+	for _, seat := range append(sold, held...) {
+		busy[seat] = true
+	}
+
+	for i := range res.Seats {
+		seat := &res.Seats[i]
+
+		position, place := layout.Seat(seat.Row, seat.Number)
+		if place == nil {
+			return nil, s.rollback(tx, fmt.Errorf("%w: seat is outside of hall layout", internal.ErrValidationFailed))
+		}
+
+		if place.Blocked {
+			return nil, s.rollback(tx, fmt.Errorf("%w: seat is blocked", internal.ErrValidationFailed))
+		}
+
+		if busy[position] {
+			return nil, s.rollback(tx, internal.ErrSeatTaken)
+		}
+		busy[position] = true
+
+		seat.Seat = position
+	}
+
+	err = s.repo.Create(ctx, res, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// RetrieveHold returns active hold of user, nil when user has no such hold
+func (s *Service) RetrieveHold(token string, user int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(token, user, s.clock.Now().UTC(), ctx)
+}
+
+// DeleteHold releases hold of user
+func (s *Service) DeleteHold(token string, user int64, ctx context.Context) error {
+	return s.repo.Delete(token, user, ctx)
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
+
+// NewToken generates random hold token
+func NewToken() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
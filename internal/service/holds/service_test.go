@@ -0,0 +1,95 @@
+package holds
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	h "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	lt "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+func TestCreateLimits(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+	tooMany := make([]h.Seat, maxSeats+1)
+	for i := range tooMany {
+		tooMany[i] = h.Seat{Row: 1, Number: int64(i + 1)}
+	}
+
+	testCreateLimitsCases := []struct {
+		name          string
+		seats         []h.Seat
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "failed, too many seats",
+			seats:         tooMany,
+			expectedError: internal.ErrValidationFailed,
+			prepare:       func(sqlm2 sqlmock.Sqlmock) {},
+		},
+		{
+			name:          "failed, too many active holds",
+			seats:         []h.Seat{{Row: 1, Number: 2}},
+			expectedError: internal.ErrHoldLimit,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT halls.id, halls.rows, halls.seats FROM sessions")).
+					WithArgs(4).
+					WillReturnRows(sqlm2.NewRows([]string{"id", "rows", "seats"}).AddRow(1, 10, 10))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("FROM hall_seats")).
+					WillReturnRows(sqlm2.NewRows([]string{"seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle"}))
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM seat_holds WHERE session_id = $1 AND expires_at <= $2")).
+					WithArgs(4, now).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT id FROM users WHERE id = $1 FOR UPDATE")).
+					WithArgs(7).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(7))
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(DISTINCT token) FROM seat_holds")).
+					WithArgs(7, now).
+					WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(maxHolds))
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testCreateLimitsCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			s := &Service{
+				repo:    &h.Repository{DB: db, Log: logger},
+				layouts: &lt.Repository{DB: db, Log: logger},
+				clock:   clock.NewFake(now),
+				ttl:     defaultTTL,
+				log:     logger,
+			}
+
+			tc.prepare(mock)
+
+			res, err := s.Create(&h.Resource{Session_ID: 4, User_ID: 7, Seats: tc.seats}, context.Background())
+
+			assert.Nil(t, res)
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
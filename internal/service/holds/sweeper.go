@@ -0,0 +1,60 @@
+package holds
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// SweepInterval is a pause between expired holds cleanups
+const SweepInterval = time.Minute
+
+// Sweeper releases expired seat holds in background
+type Sweeper struct {
+	repo     *h.Repository
+	clock    clock.Clock
+	interval time.Duration
+	log      *zap.Logger
+}
+
+// NewSweeper returns Sweeper object
+func NewSweeper(db *sql.DB, l *zap.Logger, c clock.Clock, interval time.Duration) *Sweeper {
+
+	return &Sweeper{
+		repo:     &h.Repository{DB: db, Log: l},
+		clock:    c,
+		interval: interval,
+		log:      l,
+	}
+}
+
+// Run releases expired holds every interval until context is cancelled
+func (s *Sweeper) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(s.interval):
+			s.Sweep(ctx)
+		}
+	}
+}
+
+// Sweep releases holds expired by now
+func (s *Sweeper) Sweep(ctx context.Context) {
+	released, err := s.repo.Release(s.clock.Now().UTC(), ctx)
+	if err != nil {
+		return
+	}
+
+	if released > 0 {
+		s.log.Info("Released expired seat holds.",
+			zap.Int64("seats", released),
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package holds
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+func TestSweeper(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	start := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		NewSweeper(db, logger, clk, SweepInterval).Run(ctx)
+		close(done)
+	}()
+
+	query := regexp.QuoteMeta("DELETE FROM seat_holds WHERE expires_at <= $1")
+
+	for i := 1; i <= 2; i++ {
+		assert.Eventually(t, func() bool { return clk.Waiters() == 1 }, time.Second, time.Millisecond)
+
+		// Nothing is released until interval elapses
+		clk.Advance(SweepInterval - time.Second)
+		assert.NoError(t, mock.ExpectationsWereMet())
+
+		mock.ExpectExec(query).
+			WithArgs(start.Add(time.Duration(i) * SweepInterval)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		clk.Advance(time.Second)
+
+		assert.Eventually(t, func() bool { return mock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("sweeper did not stop after context was cancelled")
+	}
+}
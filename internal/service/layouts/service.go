@@ -0,0 +1,225 @@
+package layouts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	hl "github.com/darkjedidj/cinema-service/internal/repository/halls"
+	h "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// categories lists seat categories known to storage
+var categories = map[string]bool{
+	h.Standard:  true,
+	"premium":   true,
+	"love_seat": true,
+}
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo  *h.Repository
+	halls *hl.Repository
+	clock clock.Clock
+	log   *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo:  &h.Repository{DB: db, Log: l},
+		halls: &hl.Repository{DB: db, Log: l},
+		clock: clock.Real{},
+		log:   l,
+	}
+}
+
+// Create replaces hall layout while hall is locked, so no ticket or hold takes seat of old layout meanwhile
+func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert layout object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	hall, err := s.lock(res.Hall_ID, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if hall == nil {
+		return nil, s.rollback(tx, fmt.Errorf("%w: hall does not exist", internal.ErrValidationFailed))
+	}
+
+	err = s.repo.Create(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		s.log.Info("Failed to commit transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(res.Hall_ID, ctx)
+}
+
+// Retrieve logic layer for repository method
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Delete drops hall layout while hall is locked, hall falls back to grid of equal rows
+func (s *Service) Delete(id int64, ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	hall, err := s.lock(id, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	if hall == nil {
+		return s.rollback(tx, nil)
+	}
+
+	err = s.repo.Delete(id, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		s.log.Info("Failed to commit transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// lock locks hall for update and checks its seats aren't sold or held for upcoming sessions,
+// nil when there's no such hall
+func (s *Service) lock(id int64, ctx context.Context, tx *sql.Tx) (*hl.Resource, error) {
+	hall, err := s.halls.Lock(id, ctx, tx)
+	if err != nil || hall == nil {
+		return nil, err
+	}
+
+	inUse, err := s.repo.InUse(id, s.clock.Now().UTC(), ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if inUse {
+		return nil, internal.ErrLayoutInUse
+	}
+
+	return hall, nil
+}
+
+// validate checks layout rows and seats, fills default categories
+func validate(res *h.Resource) error {
+	if len(res.Rows) == 0 {
+		return fmt.Errorf("%w: layout has no rows", internal.ErrValidationFailed)
+	}
+
+	rows := make(map[int64]bool, len(res.Rows))
+
+	for i := range res.Rows {
+		row := &res.Rows[i]
+
+		if row.Row < 1 {
+			return fmt.Errorf("%w: row number must be positive", internal.ErrValidationFailed)
+		}
+
+		if rows[row.Row] {
+			return fmt.Errorf("%w: row %d is duplicated", internal.ErrValidationFailed, row.Row)
+		}
+		rows[row.Row] = true
+
+		if len(row.Seats) == 0 {
+			return fmt.Errorf("%w: row %d has no seats", internal.ErrValidationFailed, row.Row)
+		}
+
+		seats := make(map[int64]bool, len(row.Seats))
+
+		for j := range row.Seats {
+			seat := &row.Seats[j]
+
+			if seat.Number < 1 {
+				return fmt.Errorf("%w: seat number must be positive", internal.ErrValidationFailed)
+			}
+
+			if seats[seat.Number] {
+				return fmt.Errorf("%w: seat %d in row %d is duplicated", internal.ErrValidationFailed, seat.Number, row.Row)
+			}
+			seats[seat.Number] = true
+
+			if seat.Category == "" {
+				seat.Category = h.Standard
+			}
+
+			if !categories[seat.Category] {
+				return fmt.Errorf("%w: unknown seat category %q", internal.ErrValidationFailed, seat.Category)
+			}
+		}
+	}
+
+	return nil
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
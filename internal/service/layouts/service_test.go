@@ -0,0 +1,184 @@
+package layouts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	hl "github.com/darkjedidj/cinema-service/internal/repository/halls"
+	h "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+var (
+	now = time.Date(2022, time.January, 1, 8, 0, 0, 0, time.UTC)
+
+	lock = regexp.QuoteMeta("SELECT vip, id, seats, rows, version FROM halls WHERE id = $1 FOR UPDATE")
+	sold = regexp.QuoteMeta("SELECT COUNT(tickets.id) FROM tickets JOIN sessions ON tickets.session_id = sessions.id")
+	held = regexp.QuoteMeta("SELECT COUNT(seat_holds.id) FROM seat_holds JOIN sessions ON seat_holds.session_id = sessions.id")
+)
+
+// locked expects hall 15 to be locked with tickets sold and seats held
+func locked(sqlm2 sqlmock.Sqlmock, tickets, holds int) {
+	sqlm2.ExpectBegin()
+	sqlm2.ExpectQuery(lock).
+		WithArgs(15).
+		WillReturnRows(sqlm2.NewRows([]string{"vip", "id", "seats", "rows", "version"}).AddRow(false, 15, 2, 1, 3))
+	sqlm2.ExpectQuery(sold).
+		WithArgs(15, "refunded").
+		WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(tickets))
+
+	if tickets == 0 {
+		sqlm2.ExpectQuery(held).
+			WithArgs(15, now).
+			WillReturnRows(sqlm2.NewRows([]string{"count"}).AddRow(holds))
+	}
+}
+
+func newService() (*Service, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	return &Service{
+		repo:  &h.Repository{DB: db, Log: logger},
+		halls: &hl.Repository{DB: db, Log: logger},
+		clock: clock.NewFake(now),
+		log:   logger,
+	}, mock, func() { db.Close() }
+}
+
+func TestCreate(t *testing.T) {
+	layout := &h.Resource{Hall_ID: 15, Rows: []h.Row{{Row: 1, Seats: []h.Seat{{Number: 1}, {Number: 2}}}}}
+
+	testCreateCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				locked(sqlm2, 0, 0)
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(15).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				sqlm2.ExpectExec("INSERT INTO hall_seats (.*)").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				sqlm2.ExpectExec(regexp.QuoteMeta("UPDATE halls SET seats = $1, rows = $2 WHERE id = $3")).
+					WithArgs(2, 1, 15).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectCommit()
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT seat_row, seat_number, category, wheelchair, blocked, aisle FROM hall_seats")).
+					WithArgs(15).
+					WillReturnRows(sqlm2.NewRows([]string{"seat_row", "seat_number", "category", "wheelchair", "blocked", "aisle"}).
+						AddRow(1, 1, h.Standard, false, false, false).
+						AddRow(1, 2, h.Standard, false, false, false))
+			},
+		},
+		{
+			name:          "failed, tickets sold",
+			expectedError: internal.ErrLayoutInUse,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				locked(sqlm2, 1, 0)
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, seats held",
+			expectedError: internal.ErrLayoutInUse,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				locked(sqlm2, 0, 1)
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, no such hall",
+			expectedError: internal.ErrValidationFailed,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(lock).
+					WithArgs(15).
+					WillReturnRows(sqlm2.NewRows(nil))
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, database error",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				locked(sqlm2, 0, 0)
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WillReturnError(fmt.Errorf("unable to perform your request, please try again later"))
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testCreateCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock, done := newService()
+			defer done()
+
+			tc.prepare(mock)
+			_, err := s.Create(layout, context.Background())
+			assert.ErrorIs(t, err, tc.expectedError)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	testDeleteCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				locked(sqlm2, 0, 0)
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM hall_seats WHERE hall_id = $1")).
+					WithArgs(15).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:          "failed, seats held",
+			expectedError: internal.ErrLayoutInUse,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				locked(sqlm2, 0, 2)
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testDeleteCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, mock, done := newService()
+			defer done()
+
+			tc.prepare(mock)
+			err := s.Delete(15, context.Background())
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
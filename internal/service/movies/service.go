@@ -4,32 +4,53 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
 	h "github.com/darkjedidj/cinema-service/internal/repository/movies"
+	ss "github.com/darkjedidj/cinema-service/internal/repository/sessions"
+	"github.com/darkjedidj/cinema-service/internal/service/sessions"
 )
 
 const maxMinutes, minMinutes, maxLetters, minLetters = 350, 30, 50, 0
 
+// Limits of catalog details
+const (
+	maxDescription = 2000
+	maxPeople      = 50 // Cast members
+	maxGenres      = 10
+	maxGenre       = 30
+	maxPerson      = 100
+)
+
+// language matches language tags like en or pt-BR
+var language = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
 // Service is a struct to store DB and logger connection
 type Service struct {
-	repo *h.Repository
-	log  *zap.Logger
+	repo     *h.Repository
+	sessions *ss.Repository
+	turnover time.Duration
+	log      *zap.Logger
 }
 
 // Init returns Service object
 func Init(db *sql.DB, l *zap.Logger) *Service {
 
 	return &Service{
-		repo: &h.Repository{DB: db, Log: l},
-		log:  l,
+		repo:     &h.Repository{DB: db, Log: l},
+		sessions: &ss.Repository{DB: db, Log: l},
+		turnover: sessions.Turnover(),
+		log:      l,
 	}
 }
 
-// Create logic layer for repository method
+// Create stores validated movie with its genres in one transaction
 func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
 	res, ok := i.(*h.Resource)
 	if !ok {
@@ -40,38 +61,124 @@ func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal
 		return nil, internal.ErrInternalFailure
 	}
 
-	duration, err := time.ParseDuration(res.Duration)
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
 	if err != nil {
-		error := fmt.Errorf("%w: failed to parse duration", internal.ErrValidationFailed)
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
 
-		return nil, error
+		return nil, internal.ErrInternalFailure
 	}
 
-	if duration.Minutes() < minMinutes {
-		error := fmt.Errorf("%w: duration too short", internal.ErrValidationFailed)
+	id, err := s.repo.Insert(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
 
-		return nil, error
+	err = s.repo.SetGenres(id, res.Genres, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
 	}
 
-	if duration.Minutes() > maxMinutes {
-		error := fmt.Errorf("%w: duration too long", internal.ErrValidationFailed)
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Update replaces movie after create validation. New duration must not make
+// upcoming sessions of the movie overlap following screenings.
+// Movie with version set is replaced only if nobody changed it since that version.
+func (s *Service) Update(id int64, i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert movie object.",
+			zap.Bool("ok", ok),
+		)
 
-		return nil, error
+		return nil, internal.ErrInternalFailure
 	}
 
-	if len(res.Name) <= minLetters {
-		error := fmt.Errorf("%w: name too short", internal.ErrValidationFailed)
+	res.ID = id
 
-		return nil, error
+	err := validate(res)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(res.Name) > maxLetters {
-		error := fmt.Errorf("%w: name too long", internal.ErrValidationFailed)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	found, err := s.repo.Update(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if !found && res.Version > 0 {
+		return nil, s.rollback(tx, internal.ErrVersionMismatch)
+	}
+
+	if !found {
+		return nil, s.rollback(tx, nil)
+	}
+
+	err = s.repo.SetGenres(id, res.Genres, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	upcoming, err := s.sessions.Upcoming(id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	var conflicts []*ss.Resource
+
+	seen := map[int64]bool{}
+
+	for _, session := range upcoming {
+		overlaps, err := s.sessions.Conflicts(session, s.turnover, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		for _, overlap := range overlaps {
+			if !seen[overlap.ID] {
+				seen[overlap.ID] = true
+				conflicts = append(conflicts, overlap)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return nil, s.rollback(tx, &sessions.Conflict{Sessions: conflicts})
+	}
 
-		return nil, error
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
 	}
 
-	return s.repo.Create(i, ctx)
+	return s.repo.Retrieve(id, ctx)
 }
 
 // Retrieve logic layer for repository method
@@ -80,11 +187,158 @@ func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable
 }
 
 // RetrieveAll logic layer for repository method
-func (s *Service) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
-	return s.repo.RetrieveAll(ctx)
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
 }
 
 // Delete logic layer for repository method
 func (s *Service) Delete(id int64, ctx context.Context) error {
-	return s.repo.Delete(id, ctx)
+	return s.repo.Delete(id, 0, ctx)
+}
+
+// DeleteVersion deletes entity only while it still has expected version
+func (s *Service) DeleteVersion(id int64, version int64, ctx context.Context) error {
+	return s.repo.Delete(id, version, ctx)
+}
+
+// validate checks movie name and duration limits and catalog details, genres are normalized to lower case
+func validate(res *h.Resource) error {
+	duration, err := parseDuration(res.Duration)
+	if err != nil {
+		return fmt.Errorf("%w: failed to parse duration", internal.ErrValidationFailed)
+	}
+
+	if duration.Minutes() < minMinutes {
+		return fmt.Errorf("%w: duration too short", internal.ErrValidationFailed)
+	}
+
+	if duration.Minutes() > maxMinutes {
+		return fmt.Errorf("%w: duration too long", internal.ErrValidationFailed)
+	}
+
+	if len(res.Name) <= minLetters {
+		return fmt.Errorf("%w: name too short", internal.ErrValidationFailed)
+	}
+
+	if len(res.Name) > maxLetters {
+		return fmt.Errorf("%w: name too long", internal.ErrValidationFailed)
+	}
+
+	if len(res.Description) > maxDescription {
+		return fmt.Errorf("%w: description can't be longer than %d characters", internal.ErrValidationFailed, maxDescription)
+	}
+
+	if res.Release_date != "" {
+		_, err = time.Parse("2006-01-02", res.Release_date)
+		if err != nil {
+			return fmt.Errorf("%w: release date must look like 2022-04-01", internal.ErrValidationFailed)
+		}
+	}
+
+	if _, ok := h.Ratings[res.Age_rating]; !ok && res.Age_rating != "" {
+		return fmt.Errorf("%w: age rating must be one of 0+, 6+, 12+, 16+, 18+", internal.ErrValidationFailed)
+	}
+
+	res.Genres, err = genres(res.Genres)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range append([]string{res.Language}, append(res.Subtitles, res.Dubbing...)...) {
+		if tag != "" && !language.MatchString(tag) {
+			return fmt.Errorf("%w: language %q must be a tag like en or pt-BR", internal.ErrValidationFailed, tag)
+		}
+	}
+
+	if res.Language == "" && (len(res.Subtitles) > 0 || len(res.Dubbing) > 0) {
+		return fmt.Errorf("%w: subtitles and dubbing need original language", internal.ErrValidationFailed)
+	}
+
+	if len(res.Director) > maxPerson {
+		return fmt.Errorf("%w: director name too long", internal.ErrValidationFailed)
+	}
+
+	if len(res.Cast) > maxPeople {
+		return fmt.Errorf("%w: cast can't list more than %d people", internal.ErrValidationFailed, maxPeople)
+	}
+
+	for _, person := range res.Cast {
+		if strings.TrimSpace(person) == "" || len(person) > maxPerson {
+			return fmt.Errorf("%w: cast names must be from 1 to %d characters", internal.ErrValidationFailed, maxPerson)
+		}
+	}
+
+	if res.Poster != "" && !link(res.Poster) {
+		return fmt.Errorf("%w: poster must be http or https URL", internal.ErrValidationFailed)
+	}
+
+	if res.Trailer != "" && !link(res.Trailer) {
+		return fmt.Errorf("%w: trailer must be http or https URL", internal.ErrValidationFailed)
+	}
+
+	return nil
+}
+
+// genres trims genre names to lower case without repeats
+func genres(names []string) ([]string, error) {
+	var res []string
+
+	seen := map[string]bool{}
+
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if name == "" || len(name) > maxGenre {
+			return nil, fmt.Errorf("%w: genre names must be from 1 to %d characters", internal.ErrValidationFailed, maxGenre)
+		}
+
+		if !seen[name] {
+			seen[name] = true
+			res = append(res, name)
+		}
+	}
+
+	if len(res) > maxGenres {
+		return nil, fmt.Errorf("%w: movie can't have more than %d genres", internal.ErrValidationFailed, maxGenres)
+	}
+
+	return res, nil
+}
+
+// link reports whether asset reference is absolute http or https URL
+func link(value string) bool {
+	u, err := url.Parse(value)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// parseDuration reads duration like 2h15m, or 02:15:00 as storage returns it
+func parseDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err == nil {
+		return duration, nil
+	}
+
+	var hours, minutes, seconds int
+
+	_, scanErr := fmt.Sscanf(value, "%d:%d:%d", &hours, &minutes, &seconds)
+	if scanErr != nil {
+		return 0, err
+	}
+
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second, nil
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
 }
@@ -0,0 +1,64 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// ExpireInterval is a pause between unpaid orders checks
+const ExpireInterval = time.Minute
+
+// Expirer closes orders which weren't paid in time and releases their seats in background
+type Expirer struct {
+	s        *Service
+	clock    clock.Clock
+	ttl      time.Duration
+	interval time.Duration
+	log      *zap.Logger
+}
+
+// NewExpirer returns Expirer object
+func NewExpirer(db *sql.DB, l *zap.Logger, c clock.Clock, interval time.Duration) *Expirer {
+
+	s := Init(db, l)
+	s.clock = c
+
+	return &Expirer{
+		s:        s,
+		clock:    c,
+		ttl:      PaymentTTL(),
+		interval: interval,
+		log:      l,
+	}
+}
+
+// Run expires orders every interval until context is cancelled
+func (e *Expirer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.clock.After(e.interval):
+			e.Expire(ctx)
+		}
+	}
+}
+
+// Expire closes orders pending longer than payment ttl
+func (e *Expirer) Expire(ctx context.Context) {
+	expired, err := e.s.Expire(e.clock.Now().UTC().Add(-e.ttl), ctx)
+	if err != nil {
+		return
+	}
+
+	if expired > 0 {
+		e.log.Info("Expired unpaid orders.",
+			zap.Int64("orders", expired),
+		)
+	}
+}
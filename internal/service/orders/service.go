@@ -0,0 +1,577 @@
+package orders
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	o "github.com/darkjedidj/cinema-service/internal/repository/orders"
+	p "github.com/darkjedidj/cinema-service/internal/repository/payments"
+	pm "github.com/darkjedidj/cinema-service/internal/repository/promos"
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/internal/service/promos"
+	"github.com/darkjedidj/cinema-service/internal/service/tickets"
+	"github.com/darkjedidj/cinema-service/package/clock"
+	"github.com/darkjedidj/cinema-service/package/payment"
+)
+
+// maxTickets limits tickets bought in one order
+const maxTickets = 10
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo     *o.Repository
+	payments *p.Repository
+	tickets  *h.Repository
+	buyer    *tickets.Service
+	promos   *promos.Service
+	provider payment.PaymentProvider // Nil when PAYMENT_PROVIDER is unknown
+	clock    clock.Clock
+	log      *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	provider, err := payment.FromEnv()
+	if err != nil {
+		l.Info("Payments are disabled.",
+			zap.Error(err),
+		)
+	}
+
+	return &Service{
+		repo:     &o.Repository{DB: db, Log: l},
+		payments: &p.Repository{DB: db, Log: l},
+		tickets:  &h.Repository{DB: db, Log: l},
+		buyer:    tickets.Init(db, l),
+		promos:   promos.Init(db, l),
+		provider: provider,
+		clock:    clock.Real{},
+		log:      l,
+	}
+}
+
+// PaymentTTL reads how long orders wait for payment from PAYMENT_TTL environment variable
+func PaymentTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("PAYMENT_TTL"))
+	if err != nil || ttl <= 0 {
+		return 15 * time.Minute
+	}
+
+	return ttl
+}
+
+// Create reserves all order tickets in one transaction, none are reserved when any seat fails,
+// then authorizes payment and issues tickets once it is captured
+func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*o.Resource)
+	if !ok {
+		s.log.Info("Failed to assert order object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	if s.provider == nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	if len(res.Tickets) == 0 {
+		return nil, fmt.Errorf("%w: order has no tickets", internal.ErrValidationFailed)
+	}
+
+	if len(res.Tickets) > maxTickets {
+		return nil, fmt.Errorf("%w: order can't have more than %d tickets", internal.ErrValidationFailed, maxTickets)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	for n, ticket := range res.Tickets {
+		if ticket == nil {
+			return nil, s.rollback(tx, fmt.Errorf("%w: ticket %d is empty", internal.ErrValidationFailed, n+1))
+		}
+
+		ticket.User_ID = res.User_ID
+		ticket.Status = h.Reserved
+
+		ticket.ID, err = s.buyer.Buy(ticket, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, fmt.Errorf("ticket %d: %w", n+1, err))
+		}
+	}
+
+	var red *pm.Redemption
+
+	if res.Promo_Code != "" {
+		red, err = s.promos.Apply(res.Promo_Code, res.User_ID, res.Tickets, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		res.Promo_Code = red.Code
+		res.Discount = red.Amount
+	}
+
+	var total float64
+
+	for _, ticket := range res.Tickets {
+		total += ticket.Price
+	}
+
+	res.Total = math.Round(total*100) / 100
+	res.Created_at = s.clock.Now().UTC()
+	res.Status = o.Pending
+
+	id, err := s.repo.Create(ctx, res, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if red != nil {
+		red.Order_ID = id
+
+		err = s.promos.Redeem(red, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	err = s.pay(id, res.Total, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Retrieve(id, ctx)
+}
+
+// pay authorizes order payment and captures it when provider authorizes it right away
+func (s *Service) pay(id int64, amount float64, ctx context.Context) error {
+	auth, authErr := s.provider.Authorize(ctx, &payment.Intent{Order_ID: id, Amount: amount})
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	if authErr != nil {
+		s.log.Info("Failed to authorize payment.",
+			zap.Int64("order", id),
+			zap.Error(authErr),
+		)
+
+		err = s.fail(id, nil, o.Failed, ctx, tx)
+		if err != nil {
+			return s.rollback(tx, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return internal.ErrInternalFailure
+		}
+
+		return internal.ErrPaymentFailed
+	}
+
+	pay := &p.Resource{
+		Order_ID:   id,
+		Provider:   s.provider.Name(),
+		Reference:  auth.Reference,
+		Amount:     amount,
+		Status:     auth.Status,
+		Created_at: s.clock.Now().UTC(),
+	}
+
+	pay.ID, err = s.payments.Create(ctx, pay, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	var next *settlement
+
+	if pay.Status == payment.Authorized {
+		next, err = s.capture(pay, ctx, tx)
+		if err != nil {
+			return s.rollback(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return internal.ErrInternalFailure
+	}
+
+	return s.settle(next, ctx)
+}
+
+// Webhook applies signed provider callback, repeated deliveries of event are ignored
+func (s *Service) Webhook(payload []byte, signature string, ctx context.Context) error {
+	if s.provider == nil {
+		return internal.ErrInternalFailure
+	}
+
+	event, err := s.provider.VerifyWebhook(payload, signature)
+	if err != nil {
+		return fmt.Errorf("%w: %s", internal.ErrWebhookInvalid, err)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	pay, err := s.payments.Lock(s.provider.Name(), event.Reference, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	if pay == nil {
+		s.log.Info("Skipped webhook of unknown payment.",
+			zap.String("event", event.ID),
+		)
+
+		return s.rollback(tx, nil)
+	}
+
+	fresh, err := s.payments.Record(event.ID, pay.ID, event.Type, s.clock.Now().UTC(), ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	if !fresh {
+		return s.rollback(tx, nil)
+	}
+
+	var next *settlement
+
+	switch event.Type {
+	case payment.EventAuthorized:
+		if pay.Status == payment.Pending {
+			pay.Status = payment.Authorized
+		}
+
+		next, err = s.capture(pay, ctx, tx)
+	case payment.EventCaptured:
+		pay.Status = payment.Captured
+
+		next, err = s.capture(pay, ctx, tx)
+	case payment.EventFailed:
+		err = s.fail(pay.Order_ID, pay, o.Failed, ctx, tx)
+	}
+
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return internal.ErrInternalFailure
+	}
+
+	err = s.settle(next, ctx)
+	if err != nil && !errors.Is(err, internal.ErrPaymentFailed) {
+		return err
+	}
+
+	return nil
+}
+
+// settlement is provider call made once transaction recording its outcome is committed,
+// so money never moves when storage doesn't know it
+type settlement struct {
+	pay    *p.Resource
+	refund bool // Captured money is returned instead of authorized money being captured
+}
+
+// capture records payment of pending order as captured and its tickets as paid,
+// authorized money is captured by returned settlement after commit
+func (s *Service) capture(pay *p.Resource, ctx context.Context, tx *sql.Tx) (*settlement, error) {
+	order, err := s.repo.Lock(pay.Order_ID, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if order == nil {
+		return nil, nil
+	}
+
+	if order.Status != o.Pending {
+		if order.Status != o.Paid && pay.Status == payment.Captured {
+			return s.reverse(pay, ctx, tx)
+		}
+
+		return nil, nil
+	}
+
+	var next *settlement
+
+	switch pay.Status {
+	case payment.Authorized:
+		next = &settlement{pay: pay}
+	case payment.Captured:
+	default:
+		return nil, nil
+	}
+
+	err = s.payments.SetStatus(pay.ID, payment.Captured, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.SetStatus(order.ID, o.Paid, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.tickets.MoveByOrder(order.ID, []string{h.Reserved}, h.Paid, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// reverse records refund of money captured for order which was closed meanwhile,
+// money is returned by returned settlement after commit
+func (s *Service) reverse(pay *p.Resource, ctx context.Context, tx *sql.Tx) (*settlement, error) {
+	err := s.payments.AddRefund(pay.ID, pay.Amount, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.payments.SetStatus(pay.ID, payment.Refunded, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &settlement{pay: pay, refund: true}, nil
+}
+
+// settle makes committed provider call. Order of payment which can't be captured is failed,
+// refund that fails has to be returned by hand.
+func (s *Service) settle(next *settlement, ctx context.Context) error {
+	if next == nil {
+		return nil
+	}
+
+	pay := next.pay
+
+	if next.refund {
+		err := s.provider.Refund(ctx, pay.Reference, pay.Amount)
+		if err != nil {
+			s.log.Info("Failed to refund payment of closed order, recorded refund has to be returned by hand.",
+				zap.Int64("order", pay.Order_ID),
+				zap.String("reference", pay.Reference),
+				zap.Float64("amount", pay.Amount),
+				zap.Error(err),
+			)
+
+			return internal.ErrPaymentFailed
+		}
+
+		return nil
+	}
+
+	err := s.provider.Capture(ctx, pay.Reference, pay.Amount)
+	if err == nil {
+		return nil
+	}
+
+	s.log.Info("Failed to capture payment.",
+		zap.Int64("order", pay.Order_ID),
+		zap.String("reference", pay.Reference),
+		zap.Error(err),
+	)
+
+	err = s.void(pay, ctx)
+	if err != nil {
+		s.log.Info("Failed to fail order of uncaptured payment.",
+			zap.Int64("order", pay.Order_ID),
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return internal.ErrPaymentFailed
+}
+
+// void fails paid order whose payment couldn't be captured, releasing its seats and promo code use
+func (s *Service) void(pay *p.Resource, ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	order, err := s.repo.Lock(pay.Order_ID, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	if order == nil || order.Status != o.Paid {
+		return s.rollback(tx, nil)
+	}
+
+	err = s.close(order.ID, pay, o.Failed, h.Paid, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// fail closes pending order with status and releases its reserved seats and promo code use
+func (s *Service) fail(id int64, pay *p.Resource, status string, ctx context.Context, tx *sql.Tx) error {
+	order, err := s.repo.Lock(id, ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	if order == nil || order.Status != o.Pending {
+		return nil
+	}
+
+	return s.close(id, pay, status, h.Reserved, ctx, tx)
+}
+
+// close moves order to status, fails its payment and expires its tickets in held status
+func (s *Service) close(id int64, pay *p.Resource, status string, held string, ctx context.Context, tx *sql.Tx) error {
+	if pay != nil {
+		err := s.payments.SetStatus(pay.ID, payment.Failed, ctx, tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.repo.SetStatus(id, status, ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	err = s.promos.Release(id, ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.tickets.MoveByOrder(id, []string{held}, h.Expired, ctx, tx)
+
+	return err
+}
+
+// Expire closes orders not paid since passed time
+func (s *Service) Expire(before time.Time, ctx context.Context) (int64, error) {
+	stale, err := s.repo.Stale(before, ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var expired int64
+
+	for _, id := range stale {
+		tx, err := s.repo.DB.BeginTx(ctx, nil)
+		if err != nil {
+			s.log.Info("Failed to open transaction.",
+				zap.Error(err),
+			)
+
+			return expired, internal.ErrInternalFailure
+		}
+
+		err = s.fail(id, nil, o.Expired, ctx, tx)
+		if err != nil {
+			return expired, s.rollback(tx, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return expired, internal.ErrInternalFailure
+		}
+
+		expired++
+	}
+
+	return expired, nil
+}
+
+// Retrieve returns order with its tickets and payment
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	res, err := s.repo.Retrieve(id, ctx)
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	res.Tickets, err = s.tickets.RetrieveByOrder(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Payment, err = s.payments.RetrieveByOrder(id, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
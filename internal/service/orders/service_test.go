@@ -0,0 +1,266 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	o "github.com/darkjedidj/cinema-service/internal/repository/orders"
+	p "github.com/darkjedidj/cinema-service/internal/repository/payments"
+	promo "github.com/darkjedidj/cinema-service/internal/repository/promos"
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/internal/service/promos"
+	"github.com/darkjedidj/cinema-service/package/clock"
+	"github.com/darkjedidj/cinema-service/package/payment"
+)
+
+func TestWebhook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	ctx := context.Background()
+	start := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+	provider := payment.NewFake([]byte("secret"))
+	provider.Async = true
+
+	s := &Service{
+		repo:     &o.Repository{DB: db, Log: logger},
+		payments: &p.Repository{DB: db, Log: logger},
+		tickets:  &h.Repository{DB: db, Log: logger},
+		provider: provider,
+		clock:    clock.NewFake(start),
+		log:      logger,
+	}
+
+	auth, err := provider.Authorize(ctx, &payment.Intent{Order_ID: 1, Amount: 24.4})
+	assert.NoError(t, err)
+
+	lockPayment := regexp.QuoteMeta("FROM payments WHERE payments.provider = $1 AND payments.reference = $2 FOR UPDATE")
+	record := regexp.QuoteMeta("INSERT INTO payment_events")
+	lockOrder := regexp.QuoteMeta("FROM orders WHERE id = $1 FOR UPDATE")
+	payments := []string{"id", "order_id", "provider", "reference", "amount", "refunded", "status", "created_at"}
+	orders := []string{"id", "user_id", "total", "promo_code", "discount", "created_at", "status"}
+
+	// Provider confirms payment, it is captured and tickets are issued
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockPayment).
+		WithArgs(payment.FakeName, auth.Reference).
+		WillReturnRows(mock.NewRows(payments).AddRow(7, 1, payment.FakeName, auth.Reference, 24.4, 0, payment.Pending, start))
+	mock.ExpectExec(record).
+		WithArgs("evt_1", 7, payment.EventAuthorized, start).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(lockOrder).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows(orders).AddRow(1, 1, 24.4, nil, 0, start, o.Pending))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE payments SET status = $1 WHERE id = $2")).
+		WithArgs(payment.Captured, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET status = $1 WHERE id = $2")).
+		WithArgs(o.Paid, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE order_id = $2 AND status IN ($3)")).
+		WithArgs(h.Paid, 1, h.Reserved).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	payload, signature := provider.Webhook("evt_1", payment.EventAuthorized, auth.Reference)
+	assert.NoError(t, s.Webhook(payload, signature, ctx))
+
+	// Repeated delivery changes nothing
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockPayment).
+		WithArgs(payment.FakeName, auth.Reference).
+		WillReturnRows(mock.NewRows(payments).AddRow(7, 1, payment.FakeName, auth.Reference, 24.4, 0, payment.Captured, start))
+	mock.ExpectExec(record).
+		WithArgs("evt_1", 7, payment.EventAuthorized, start).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	assert.NoError(t, s.Webhook(payload, signature, ctx))
+
+	// Forged callback is rejected before touching storage
+	err = s.Webhook(payload, "forged", ctx)
+	assert.ErrorIs(t, err, internal.ErrWebhookInvalid)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{
+		"authorize 1 24.40",
+		"capture " + auth.Reference + " 24.40",
+	}, provider.Calls())
+}
+
+func TestWebhookFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	ctx := context.Background()
+	start := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	provider := payment.NewFake([]byte("secret"))
+
+	s := &Service{
+		repo:     &o.Repository{DB: db, Log: logger},
+		payments: &p.Repository{DB: db, Log: logger},
+		tickets:  &h.Repository{DB: db, Log: logger},
+		promos:   promos.Init(db, logger),
+		provider: provider,
+		clock:    clock.NewFake(start),
+		log:      logger,
+	}
+
+	// Declined payment releases reserved seats and promo code use
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM payments WHERE payments.provider = $1 AND payments.reference = $2 FOR UPDATE")).
+		WithArgs(payment.FakeName, "fake_1").
+		WillReturnRows(mock.NewRows([]string{"id", "order_id", "provider", "reference", "amount", "refunded", "status", "created_at"}).
+			AddRow(7, 1, payment.FakeName, "fake_1", 24.4, 0, payment.Pending, start))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO payment_events")).
+		WithArgs("evt_2", 7, payment.EventFailed, start).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("FROM orders WHERE id = $1 FOR UPDATE")).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows([]string{"id", "user_id", "total", "promo_code", "discount", "created_at", "status"}).
+			AddRow(1, 1, 24.4, "SPRING", 2.4, start, o.Pending))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE payments SET status = $1 WHERE id = $2")).
+		WithArgs(payment.Failed, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET status = $1 WHERE id = $2")).
+		WithArgs(o.Failed, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, promo_id, amount FROM promo_redemptions WHERE order_id = $1")).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows([]string{"id", "promo_id", "amount"}).AddRow(5, 3, 2.4))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM promo_redemptions WHERE id = $1")).
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE promo_codes SET uses = uses + $1")).
+		WithArgs(-1, promo.Voucher, 2.4, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE order_id = $2 AND status IN ($3)")).
+		WithArgs(h.Expired, 1, h.Reserved).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	payload, signature := provider.Webhook("evt_2", payment.EventFailed, "fake_1")
+	assert.NoError(t, s.Webhook(payload, signature, ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookCaptureDeclined(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	ctx := context.Background()
+	start := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	provider := payment.NewFake([]byte("secret"))
+
+	s := &Service{
+		repo:     &o.Repository{DB: db, Log: logger},
+		payments: &p.Repository{DB: db, Log: logger},
+		tickets:  &h.Repository{DB: db, Log: logger},
+		promos:   promos.Init(db, logger),
+		provider: provider,
+		clock:    clock.NewFake(start),
+		log:      logger,
+	}
+
+	lockOrder := regexp.QuoteMeta("FROM orders WHERE id = $1 FOR UPDATE")
+	orders := []string{"id", "user_id", "total", "promo_code", "discount", "created_at", "status"}
+
+	// Order is paid and committed before provider is asked to capture
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("FROM payments WHERE payments.provider = $1 AND payments.reference = $2 FOR UPDATE")).
+		WithArgs(payment.FakeName, "lost_1").
+		WillReturnRows(mock.NewRows([]string{"id", "order_id", "provider", "reference", "amount", "refunded", "status", "created_at"}).
+			AddRow(7, 1, payment.FakeName, "lost_1", 24.4, 0, payment.Pending, start))
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO payment_events")).
+		WithArgs("evt_3", 7, payment.EventAuthorized, start).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(lockOrder).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows(orders).AddRow(1, 1, 24.4, nil, 0, start, o.Pending))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE payments SET status = $1 WHERE id = $2")).
+		WithArgs(payment.Captured, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET status = $1 WHERE id = $2")).
+		WithArgs(o.Paid, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE order_id = $2 AND status IN ($3)")).
+		WithArgs(h.Paid, 1, h.Reserved).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// Declined capture fails paid order and frees its seats in new transaction
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockOrder).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows(orders).AddRow(1, 1, 24.4, nil, 0, start, o.Paid))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE payments SET status = $1 WHERE id = $2")).
+		WithArgs(payment.Failed, 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE orders SET status = $1 WHERE id = $2")).
+		WithArgs(o.Failed, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, promo_id, amount FROM promo_redemptions WHERE order_id = $1")).
+		WithArgs(1).
+		WillReturnRows(mock.NewRows([]string{"id", "promo_id", "amount"}))
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE tickets SET status = $1 WHERE order_id = $2 AND status IN ($3)")).
+		WithArgs(h.Expired, 1, h.Paid).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	payload, signature := provider.Webhook("evt_3", payment.EventAuthorized, "lost_1")
+	assert.NoError(t, s.Webhook(payload, signature, ctx))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []string{"capture lost_1 24.40"}, provider.Calls())
+}
@@ -0,0 +1,103 @@
+package pricing
+
+import (
+	"math"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+)
+
+const (
+	clockLayout = "15:04"
+	dayLayout   = "2006-01-02"
+)
+
+// Quote computes ticket price for seat category in session.
+// Movie base price wins over default one, surcharges are summed up,
+// holiday rules replace discounts and only the best discount is applied.
+func Quote(rules []*p.Resource, show *p.Show, category string) (*p.Breakdown, error) {
+	var base *p.Resource
+
+	for _, rule := range rules {
+		if rule.Kind != p.Base || !forMovie(rule, show) {
+			continue
+		}
+
+		if base == nil || rule.Movie_ID != 0 {
+			base = rule
+		}
+	}
+
+	if base == nil {
+		return nil, internal.ErrPriceNotSet
+	}
+
+	res := &p.Breakdown{Lines: []p.Line{{Kind: base.Kind, Name: base.Name, Amount: base.Amount}}}
+	subtotal := base.Amount
+	holiday := false
+
+	for _, rule := range rules {
+		if !forMovie(rule, show) {
+			continue
+		}
+
+		switch {
+		case rule.Kind == p.VIP && show.VIP,
+			rule.Kind == p.Category && rule.Category == category:
+		case rule.Kind == p.Holiday && rule.Day == show.Starts_at.Format(dayLayout):
+			holiday = true
+		default:
+			continue
+		}
+
+		res.Lines = append(res.Lines, p.Line{Kind: rule.Kind, Name: rule.Name, Amount: rule.Amount})
+		subtotal += rule.Amount
+	}
+
+	var discount *p.Resource
+
+	for _, rule := range rules {
+		if holiday || !forMovie(rule, show) || !discounted(rule, show) {
+			continue
+		}
+
+		if discount == nil || rule.Amount > discount.Amount {
+			discount = rule
+		}
+	}
+
+	if discount != nil {
+		off := -round(subtotal * discount.Amount / 100)
+
+		res.Lines = append(res.Lines, p.Line{Kind: discount.Kind, Name: discount.Name, Amount: off})
+		subtotal += off
+	}
+
+	res.Total = round(math.Max(subtotal, 0))
+
+	return res, nil
+}
+
+// forMovie reports if rule applies to movie of session
+func forMovie(rule *p.Resource, show *p.Show) bool {
+	return rule.Movie_ID == 0 || rule.Movie_ID == show.Movie_ID
+}
+
+// discounted reports if session falls under discount rule
+func discounted(rule *p.Resource, show *p.Show) bool {
+	switch rule.Kind {
+	case p.Matinee:
+		starts := show.Starts_at.Format(clockLayout)
+
+		return starts >= rule.Starts && starts < rule.Ends
+	case p.Weekday:
+		return rule.Weekday != nil && *rule.Weekday == int64(show.Starts_at.Weekday())
+	default:
+		return false
+	}
+}
+
+// round keeps cents only
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,119 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+)
+
+func TestQuote(t *testing.T) {
+	tuesday := int64(time.Tuesday)
+
+	rules := []*p.Resource{
+		{Kind: p.Base, Name: "Standard ticket", Amount: 10},
+		{Kind: p.Base, Name: "Premiere", Amount: 15, Movie_ID: 3},
+		{Kind: p.VIP, Name: "VIP hall", Amount: 5},
+		{Kind: p.Category, Name: "Premium seat", Amount: 2, Category: "premium"},
+		{Kind: p.Matinee, Name: "Matinee", Amount: 20, Starts: "00:00", Ends: "12:00"},
+		{Kind: p.Weekday, Name: "Cheap Tuesday", Amount: 50, Weekday: &tuesday},
+		{Kind: p.Holiday, Name: "New Year", Amount: 3, Day: "2022-01-01"},
+	}
+
+	// 2022-04-04 is Monday, 2022-04-05 is Tuesday
+	evening := time.Date(2022, time.April, 4, 19, 0, 0, 0, time.UTC)
+	morning := time.Date(2022, time.April, 4, 11, 59, 0, 0, time.UTC)
+
+	testQuoteCases := []struct {
+		name           string
+		rules          []*p.Resource
+		show           *p.Show
+		category       string
+		expectedError  error
+		expectedResult *p.Breakdown
+	}{
+		{
+			name:     "default base price",
+			rules:    rules,
+			show:     &p.Show{Movie_ID: 1, Starts_at: evening},
+			category: "standard",
+			expectedResult: &p.Breakdown{
+				Lines: []p.Line{{Kind: p.Base, Name: "Standard ticket", Amount: 10}},
+				Total: 10,
+			},
+		},
+		{
+			name:     "movie base price with surcharges",
+			rules:    rules,
+			show:     &p.Show{Movie_ID: 3, VIP: true, Starts_at: evening},
+			category: "premium",
+			expectedResult: &p.Breakdown{
+				Lines: []p.Line{
+					{Kind: p.Base, Name: "Premiere", Amount: 15},
+					{Kind: p.VIP, Name: "VIP hall", Amount: 5},
+					{Kind: p.Category, Name: "Premium seat", Amount: 2},
+				},
+				Total: 22,
+			},
+		},
+		{
+			name:     "matinee discount",
+			rules:    rules,
+			show:     &p.Show{Movie_ID: 1, Starts_at: morning},
+			category: "premium",
+			expectedResult: &p.Breakdown{
+				Lines: []p.Line{
+					{Kind: p.Base, Name: "Standard ticket", Amount: 10},
+					{Kind: p.Category, Name: "Premium seat", Amount: 2},
+					{Kind: p.Matinee, Name: "Matinee", Amount: -2.4},
+				},
+				Total: 9.6,
+			},
+		},
+		{
+			name:     "best discount only",
+			rules:    rules,
+			show:     &p.Show{Movie_ID: 1, Starts_at: morning.AddDate(0, 0, 1)},
+			category: "standard",
+			expectedResult: &p.Breakdown{
+				Lines: []p.Line{
+					{Kind: p.Base, Name: "Standard ticket", Amount: 10},
+					{Kind: p.Weekday, Name: "Cheap Tuesday", Amount: -5},
+				},
+				Total: 5,
+			},
+		},
+		{
+			name:     "holiday overrides discounts",
+			rules:    rules,
+			show:     &p.Show{Movie_ID: 1, Starts_at: time.Date(2022, time.January, 1, 10, 0, 0, 0, time.UTC)},
+			category: "standard",
+			expectedResult: &p.Breakdown{
+				Lines: []p.Line{
+					{Kind: p.Base, Name: "Standard ticket", Amount: 10},
+					{Kind: p.Holiday, Name: "New Year", Amount: 3},
+				},
+				Total: 13,
+			},
+		},
+		{
+			name:          "failed, no base price",
+			rules:         rules[2:],
+			show:          &p.Show{Movie_ID: 1, Starts_at: evening},
+			category:      "standard",
+			expectedError: internal.ErrPriceNotSet,
+		},
+	}
+
+	for _, tc := range testQuoteCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Quote(tc.rules, tc.show, tc.category)
+
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package pricing
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+)
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo *p.Repository
+	log  *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo: &p.Repository{DB: db, Log: l},
+		log:  l,
+	}
+}
+
+// Create validates pricing rule and stores it
+func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*p.Resource)
+	if !ok {
+		s.log.Info("Failed to assert pricing rule object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(res, ctx)
+}
+
+// Retrieve logic layer for repository method
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(id, ctx)
+}
+
+// RetriveAll logic layer for repository method
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
+}
+
+// Delete logic layer for repository method
+func (s *Service) Delete(id int64, ctx context.Context) error {
+	return s.repo.Delete(id, ctx)
+}
+
+// validate checks fields required by rule kind
+func validate(res *p.Resource) error {
+	if res.Name == "" {
+		return fmt.Errorf("%w: rule name is required", internal.ErrValidationFailed)
+	}
+
+	if res.Amount < 0 {
+		return fmt.Errorf("%w: amount can't be negative", internal.ErrValidationFailed)
+	}
+
+	if res.Movie_ID < 0 {
+		return fmt.Errorf("%w: unknown movie", internal.ErrValidationFailed)
+	}
+
+	switch res.Kind {
+	case p.Base, p.VIP:
+	case p.Category:
+		if res.Category == "" {
+			return fmt.Errorf("%w: category rule requires seat category", internal.ErrValidationFailed)
+		}
+	case p.Matinee:
+		starts, err := time.Parse(clockLayout, res.Starts)
+		if err != nil {
+			return fmt.Errorf("%w: starts must be in HH:MM format", internal.ErrValidationFailed)
+		}
+
+		ends, err := time.Parse(clockLayout, res.Ends)
+		if err != nil {
+			return fmt.Errorf("%w: ends must be in HH:MM format", internal.ErrValidationFailed)
+		}
+
+		if !starts.Before(ends) {
+			return fmt.Errorf("%w: matinee must start before it ends", internal.ErrValidationFailed)
+		}
+
+		if res.Amount > 100 {
+			return fmt.Errorf("%w: discount can't exceed 100 percent", internal.ErrValidationFailed)
+		}
+	case p.Weekday:
+		if res.Weekday == nil || *res.Weekday < 0 || *res.Weekday > 6 {
+			return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6", internal.ErrValidationFailed)
+		}
+
+		if res.Amount > 100 {
+			return fmt.Errorf("%w: discount can't exceed 100 percent", internal.ErrValidationFailed)
+		}
+	case p.Holiday:
+		_, err := time.Parse(dayLayout, res.Day)
+		if err != nil {
+			return fmt.Errorf("%w: day must be in YYYY-MM-DD format", internal.ErrValidationFailed)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule kind %q", internal.ErrValidationFailed, res.Kind)
+	}
+
+	return nil
+}
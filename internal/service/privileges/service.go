@@ -0,0 +1,106 @@
+package privileges
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/privileges"
+)
+
+// maxDescription is the longest description privilege can have
+const maxDescription = 500
+
+// name privilege names must match, the same as route resource names
+var name = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo *p.Repository
+	log  *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo: &p.Repository{DB: db, Log: l},
+		log:  l,
+	}
+}
+
+// Create validates privilege and adds it to catalog
+func (s *Service) Create(r internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := r.(*p.Resource)
+	if !ok {
+		s.log.Info("Failed to assert privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(res, ctx)
+}
+
+// Update validates privilege and replaces it, renames are passed on to permissions
+func (s *Service) Update(id int64, r internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := r.(*p.Resource)
+	if !ok {
+		s.log.Info("Failed to assert privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.ID = id
+
+	err := validate(res)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Update(res, ctx)
+}
+
+// Retrieve logic layer for repository method
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(id, ctx)
+}
+
+// RetriveAll logic layer for repository method
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
+}
+
+// Delete logic layer for repository method
+func (s *Service) Delete(id int64, ctx context.Context) error {
+	return s.repo.Delete(id, ctx)
+}
+
+// validate normalizes privilege and checks its name and description
+func validate(res *p.Resource) error {
+	res.Name = strings.TrimSpace(res.Name)
+	res.Description = strings.TrimSpace(res.Description)
+
+	if !name.MatchString(res.Name) {
+		return fmt.Errorf("%w: name must be 2 to 50 lower case letters, digits, dashes or underscores", internal.ErrValidationFailed)
+	}
+
+	if len(res.Description) > maxDescription {
+		return fmt.Errorf("%w: description can't be longer than %d characters", internal.ErrValidationFailed, maxDescription)
+	}
+
+	return nil
+}
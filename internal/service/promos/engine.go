@@ -0,0 +1,48 @@
+package promos
+
+import (
+	"math"
+
+	pr "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+	p "github.com/darkjedidj/cinema-service/internal/repository/promos"
+)
+
+// Applies reports if promo code restrictions allow ticket of session
+func Applies(promo *p.Resource, session int64, show *pr.Show) bool {
+	return (promo.Movie_ID == 0 || promo.Movie_ID == show.Movie_ID) &&
+		(promo.Hall_ID == 0 || promo.Hall_ID == show.Hall_ID) &&
+		(promo.Session_ID == 0 || promo.Session_ID == session)
+}
+
+// Discount splits promo code discount over eligible ticket prices.
+// Percent codes take percent off every ticket, fixed codes and vouchers
+// cover tickets in turn until their amount or balance runs out.
+func Discount(promo *p.Resource, prices []float64, eligible []bool) []float64 {
+	offs := make([]float64, len(prices))
+
+	budget := promo.Amount
+	if promo.Kind == p.Voucher {
+		budget = promo.Balance
+	}
+
+	for n, price := range prices {
+		if !eligible[n] || price <= 0 {
+			continue
+		}
+
+		switch promo.Kind {
+		case p.Percent:
+			offs[n] = round(math.Min(price*promo.Amount/100, price))
+		case p.Fixed, p.Voucher:
+			offs[n] = round(math.Min(budget, price))
+			budget -= offs[n]
+		}
+	}
+
+	return offs
+}
+
+// round keeps cents only
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
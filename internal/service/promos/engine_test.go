@@ -0,0 +1,110 @@
+package promos
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pr "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+	p "github.com/darkjedidj/cinema-service/internal/repository/promos"
+)
+
+func TestApplies(t *testing.T) {
+	show := &pr.Show{Movie_ID: 3, Hall_ID: 2}
+
+	testAppliesCases := []struct {
+		name           string
+		promo          *p.Resource
+		session        int64
+		expectedResult bool
+	}{
+		{
+			name:           "no restrictions",
+			promo:          &p.Resource{},
+			session:        1,
+			expectedResult: true,
+		},
+		{
+			name:           "movie matches",
+			promo:          &p.Resource{Movie_ID: 3, Hall_ID: 2},
+			session:        1,
+			expectedResult: true,
+		},
+		{
+			name:           "other movie",
+			promo:          &p.Resource{Movie_ID: 4},
+			session:        1,
+			expectedResult: false,
+		},
+		{
+			name:           "other hall",
+			promo:          &p.Resource{Hall_ID: 1},
+			session:        1,
+			expectedResult: false,
+		},
+		{
+			name:           "other session",
+			promo:          &p.Resource{Session_ID: 5},
+			session:        1,
+			expectedResult: false,
+		},
+	}
+
+	for _, tc := range testAppliesCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, Applies(tc.promo, tc.session, show))
+		})
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	testDiscountCases := []struct {
+		name           string
+		promo          *p.Resource
+		prices         []float64
+		eligible       []bool
+		expectedResult []float64
+	}{
+		{
+			name:           "percent off every eligible ticket",
+			promo:          &p.Resource{Kind: p.Percent, Amount: 15},
+			prices:         []float64{12.2, 10, 8},
+			eligible:       []bool{true, false, true},
+			expectedResult: []float64{1.83, 0, 1.2},
+		},
+		{
+			name:           "fixed amount spread over tickets",
+			promo:          &p.Resource{Kind: p.Fixed, Amount: 15},
+			prices:         []float64{10, 10, 10},
+			eligible:       []bool{true, true, true},
+			expectedResult: []float64{10, 5, 0},
+		},
+		{
+			name:           "fixed amount skips ineligible tickets",
+			promo:          &p.Resource{Kind: p.Fixed, Amount: 3},
+			prices:         []float64{10, 10},
+			eligible:       []bool{false, true},
+			expectedResult: []float64{0, 3},
+		},
+		{
+			name:           "voucher pays up to its balance",
+			promo:          &p.Resource{Kind: p.Voucher, Amount: 50, Balance: 12.5},
+			prices:         []float64{10, 10},
+			eligible:       []bool{true, true},
+			expectedResult: []float64{10, 2.5},
+		},
+		{
+			name:           "free tickets stay free",
+			promo:          &p.Resource{Kind: p.Percent, Amount: 100},
+			prices:         []float64{0, 10},
+			eligible:       []bool{true, true},
+			expectedResult: []float64{0, 10},
+		},
+	}
+
+	for _, tc := range testDiscountCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, Discount(tc.promo, tc.prices, tc.eligible))
+		})
+	}
+}
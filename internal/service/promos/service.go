@@ -0,0 +1,217 @@
+package promos
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	pr "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+	p "github.com/darkjedidj/cinema-service/internal/repository/promos"
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo    *p.Repository
+	tickets *h.Repository
+	pricing *pr.Repository
+	clock   clock.Clock
+	log     *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo:    &p.Repository{DB: db, Log: l},
+		tickets: &h.Repository{DB: db, Log: l},
+		pricing: &pr.Repository{DB: db, Log: l},
+		clock:   clock.Real{},
+		log:     l,
+	}
+}
+
+// Create validates promo code and stores it
+func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*p.Resource)
+	if !ok {
+		s.log.Info("Failed to assert promo code object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.Code = Normalize(res.Code)
+	res.Uses = 0
+
+	err := validate(res, s.clock.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Kind == p.Voucher {
+		res.Balance = res.Amount
+		res.Max_uses = 1
+	} else {
+		res.Balance = 0
+	}
+
+	return s.repo.Create(res, ctx)
+}
+
+// Retrieve logic layer for repository method
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(id, ctx)
+}
+
+// RetriveAll logic layer for repository method
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
+}
+
+// Delete logic layer for repository method
+func (s *Service) Delete(id int64, ctx context.Context) error {
+	return s.repo.Delete(id, ctx)
+}
+
+// Normalize makes promo codes case insensitive
+func Normalize(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Apply checks promo code for user and takes its discount off bought tickets within transaction.
+// Caller links returned redemption to ticket or order and stores it with Redeem.
+func (s *Service) Apply(code string, user int64, tickets []*h.Resource, ctx context.Context, tx *sql.Tx) (*p.Redemption, error) {
+	promo, err := s.repo.Lock(Normalize(code), ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if promo == nil {
+		return nil, fmt.Errorf("%w: promo code does not exist", internal.ErrPromoInvalid)
+	}
+
+	now := s.clock.Now().UTC()
+
+	if (promo.Starts_at != nil && now.Before(*promo.Starts_at)) || (promo.Ends_at != nil && !now.Before(*promo.Ends_at)) {
+		return nil, fmt.Errorf("%w: promo code is not active", internal.ErrPromoInvalid)
+	}
+
+	if (promo.Max_uses > 0 && promo.Uses >= promo.Max_uses) || (promo.Kind == p.Voucher && promo.Balance <= 0) {
+		return nil, fmt.Errorf("%w: promo code is used up", internal.ErrPromoInvalid)
+	}
+
+	if promo.Per_user > 0 {
+		used, err := s.repo.UsedBy(promo.ID, user, ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		if used >= promo.Per_user {
+			return nil, fmt.Errorf("%w: promo code was already used", internal.ErrPromoInvalid)
+		}
+	}
+
+	prices := make([]float64, len(tickets))
+	eligible := make([]bool, len(tickets))
+
+	for n, ticket := range tickets {
+		show, err := s.pricing.Show(ticket.Session_ID, ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		prices[n] = ticket.Price
+		eligible[n] = show != nil && Applies(promo, ticket.Session_ID, show)
+	}
+
+	offs := Discount(promo, prices, eligible)
+
+	red := &p.Redemption{Promo_ID: promo.ID, Code: promo.Code, User_ID: user, Redeemed_at: now}
+
+	for n, ticket := range tickets {
+		if offs[n] == 0 {
+			continue
+		}
+
+		ticket.Price = round(ticket.Price - offs[n])
+		ticket.Discount = offs[n]
+		ticket.Promo_Code = promo.Code
+
+		if ticket.Breakdown != nil {
+			ticket.Breakdown.Lines = append(ticket.Breakdown.Lines, pr.Line{Kind: promo.Kind, Name: promo.Code, Amount: -offs[n]})
+			ticket.Breakdown.Total = ticket.Price
+		}
+
+		err = s.tickets.Discount(ticket, ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+
+		red.Amount += offs[n]
+	}
+
+	red.Amount = round(red.Amount)
+
+	if red.Amount == 0 {
+		return nil, fmt.Errorf("%w: promo code doesn't apply to selected tickets", internal.ErrPromoInvalid)
+	}
+
+	return red, nil
+}
+
+// Redeem counts promo code use of applied redemption
+func (s *Service) Redeem(red *p.Redemption, ctx context.Context, tx *sql.Tx) error {
+	return s.repo.Redeem(red, ctx, tx)
+}
+
+// Release returns promo code use of order which was never paid
+func (s *Service) Release(order int64, ctx context.Context, tx *sql.Tx) error {
+	return s.repo.Release(order, ctx, tx)
+}
+
+// validate checks fields required by promo code kind, code has to be usable after now
+func validate(res *p.Resource, now time.Time) error {
+	if res.Code == "" {
+		return fmt.Errorf("%w: code is required", internal.ErrValidationFailed)
+	}
+
+	if res.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", internal.ErrValidationFailed)
+	}
+
+	if res.Max_uses < 0 || res.Per_user < 0 {
+		return fmt.Errorf("%w: usage limits can't be negative", internal.ErrValidationFailed)
+	}
+
+	if res.Movie_ID < 0 || res.Hall_ID < 0 || res.Session_ID < 0 {
+		return fmt.Errorf("%w: unknown movie, hall or session", internal.ErrValidationFailed)
+	}
+
+	if res.Starts_at != nil && res.Ends_at != nil && !res.Starts_at.Before(*res.Ends_at) {
+		return fmt.Errorf("%w: promo code must start before it ends", internal.ErrValidationFailed)
+	}
+
+	if res.Ends_at != nil && !res.Ends_at.After(now) {
+		return fmt.Errorf("%w: promo code has already ended", internal.ErrValidationFailed)
+	}
+
+	switch res.Kind {
+	case p.Percent:
+		if res.Amount > 100 {
+			return fmt.Errorf("%w: discount can't exceed 100 percent", internal.ErrValidationFailed)
+		}
+	case p.Fixed, p.Voucher:
+	default:
+		return fmt.Errorf("%w: unknown promo code kind %q", internal.ErrValidationFailed, res.Kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package promos
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/promos"
+)
+
+func TestValidate(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	yesterday := now.AddDate(0, 0, -1)
+	tomorrow := now.AddDate(0, 0, 1)
+
+	testValidateCases := []struct {
+		name          string
+		promo         *p.Resource
+		expectedError error
+	}{
+		{
+			name:  "percent",
+			promo: &p.Resource{Code: "SPRING", Kind: p.Percent, Amount: 15, Max_uses: 100, Per_user: 1, Starts_at: &yesterday, Ends_at: &tomorrow},
+		},
+		{
+			name:  "fixed",
+			promo: &p.Resource{Code: "MINUS5", Kind: p.Fixed, Amount: 5, Movie_ID: 3},
+		},
+		{
+			name:  "voucher",
+			promo: &p.Resource{Code: "GIFT50", Kind: p.Voucher, Amount: 50},
+		},
+		{
+			name:          "missing code",
+			promo:         &p.Resource{Kind: p.Percent, Amount: 15},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "amount not positive",
+			promo:         &p.Resource{Code: "SPRING", Kind: p.Fixed, Amount: 0},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "negative usage limit",
+			promo:         &p.Resource{Code: "SPRING", Kind: p.Percent, Amount: 15, Per_user: -1},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "unknown session",
+			promo:         &p.Resource{Code: "SPRING", Kind: p.Percent, Amount: 15, Session_ID: -1},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "ends before start",
+			promo:         &p.Resource{Code: "SPRING", Kind: p.Percent, Amount: 15, Starts_at: &tomorrow, Ends_at: &yesterday},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "already ended",
+			promo:         &p.Resource{Code: "SPRING", Kind: p.Percent, Amount: 15, Ends_at: &yesterday},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "percent over 100",
+			promo:         &p.Resource{Code: "SPRING", Kind: p.Percent, Amount: 120},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "unknown kind",
+			promo:         &p.Resource{Code: "SPRING", Kind: "spring", Amount: 15},
+			expectedError: internal.ErrValidationFailed,
+		},
+	}
+	for _, tc := range testValidateCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := validate(tc.promo, now)
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
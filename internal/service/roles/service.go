@@ -0,0 +1,433 @@
+package roles
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	r "github.com/darkjedidj/cinema-service/internal/repository/roles"
+	u "github.com/darkjedidj/cinema-service/internal/repository/users"
+	users "github.com/darkjedidj/cinema-service/internal/service/user"
+	e "github.com/darkjedidj/cinema-service/package"
+)
+
+const maxDescription = 500
+
+// name matches role names like box-office or night_manager
+var name = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo  *r.Repository
+	users *users.Service
+	log   *zap.Logger
+}
+
+// Assignment is a struct to store names of roles user holds
+type Assignment struct {
+	Roles []string `json:"roles"`
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo:  &r.Repository{DB: db, Log: l},
+		users: users.Init(db, l),
+		log:   l,
+	}
+}
+
+// Create stores validated role with its permissions in one transaction
+func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*r.Resource)
+	if !ok {
+		s.log.Info("Failed to assert role object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := s.validate(res, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	id, err := s.repo.Insert(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = s.repo.SetPermissions(id, res.Permissions, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Update replaces role name, description and permissions. Built-in roles keep their
+// names and superadmin keeps every permission.
+func (s *Service) Update(id int64, i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*r.Resource)
+	if !ok {
+		s.log.Info("Failed to assert role object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.ID = id
+
+	err := s.validate(res, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	current, err := s.repo.Lock(id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if current == nil {
+		return nil, s.rollback(tx, nil)
+	}
+
+	if current.System && res.Name != current.Name {
+		return nil, s.rollback(tx, fmt.Errorf("%w: %s role can't be renamed", internal.ErrSystemRole, current.Name))
+	}
+
+	if current.Name == r.Superadmin && res.Permissions != nil {
+		return nil, s.rollback(tx, fmt.Errorf("%w: superadmin holds every permission", internal.ErrSystemRole))
+	}
+
+	err = s.repo.Update(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if current.Name != r.Superadmin {
+		err = s.repo.SetPermissions(id, res.Permissions, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Retrieve logic layer for repository method
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(id, ctx)
+}
+
+// RetrieveAll logic layer for repository method
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
+}
+
+// Delete removes role from users holding it and deletes it, built-in roles can't be deleted
+func (s *Service) Delete(id int64, ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	current, err := s.repo.Lock(id, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	if current == nil {
+		return s.rollback(tx, nil)
+	}
+
+	if current.System {
+		return s.rollback(tx, fmt.Errorf("%w: %s role can't be deleted", internal.ErrSystemRole, current.Name))
+	}
+
+	err = s.repo.Delete(id, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// UserRoles logic layer for repository method
+func (s *Service) UserRoles(user int64, ctx context.Context) ([]internal.Identifiable, error) {
+	return s.repo.RetrieveByUser(user, ctx)
+}
+
+// Assign replaces roles of user with roles of passed names. The last superadmin can't lose the role,
+// otherwise nobody would be left to manage roles.
+func (s *Service) Assign(user int64, names []string, ctx context.Context) ([]internal.Identifiable, error) {
+	names = unique(names)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	roles, err := s.repo.Find(names, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if len(roles) != len(names) {
+		return nil, s.rollback(tx, fmt.Errorf("%w: unknown roles %s", internal.ErrValidationFailed, strings.Join(missing(names, roles), ", ")))
+	}
+
+	holders, err := s.repo.Holders(r.Superadmin, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if len(holders) == 1 && holders[0] == user && !contains(names, r.Superadmin) {
+		return nil, s.rollback(tx, fmt.Errorf("%w: the last superadmin can't lose the role", internal.ErrSystemRole))
+	}
+
+	ids := make([]int64, len(roles))
+	for n, role := range roles {
+		ids[n] = role.ID
+	}
+
+	err = s.repo.Assign(user, ids, ctx, tx)
+	if errors.Is(err, internal.ErrNotFound) {
+		return nil, s.rollback(tx, fmt.Errorf("%w: user %d", internal.ErrNotFound, user))
+	}
+
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.RetrieveByUser(user, ctx)
+}
+
+// Bootstrap makes user of email the first superadmin so fresh database can be managed.
+// User signs up with password when missing. Nothing is changed and false is returned
+// when superadmin already exists.
+func (s *Service) Bootstrap(email string, password string, ctx context.Context) (bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return false, internal.ErrInternalFailure
+	}
+
+	holders, err := s.repo.Holders(r.Superadmin, ctx, tx)
+	if err != nil {
+		return false, s.rollback(tx, err)
+	}
+
+	if len(holders) > 0 {
+		return false, s.rollback(tx, nil)
+	}
+
+	found, err := s.users.Retrieve(email, ctx)
+	if err != nil {
+		return false, s.rollback(tx, err)
+	}
+
+	var user int64
+
+	if found != nil {
+		user = found.GID()
+	} else {
+		if password == "" {
+			return false, s.rollback(tx, fmt.Errorf("%w: password is required to sign up superadmin", internal.ErrValidationFailed))
+		}
+
+		user, err = s.users.Insert(&u.Resource{EMail: email, Password: e.GetHash([]byte(password))}, ctx, tx)
+		if err != nil {
+			return false, s.rollback(tx, err)
+		}
+	}
+
+	roles, err := s.repo.Find([]string{r.Superadmin}, ctx, tx)
+	if err != nil {
+		return false, s.rollback(tx, err)
+	}
+
+	if len(roles) == 0 {
+		s.log.Info("Failed to find superadmin role.",
+			zap.Int("roles", len(roles)),
+		)
+
+		return false, s.rollback(tx, internal.ErrInternalFailure)
+	}
+
+	err = s.repo.Grant(user, roles[0].ID, ctx, tx)
+	if err != nil {
+		return false, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, internal.ErrInternalFailure
+	}
+
+	return true, nil
+}
+
+// validate checks role name and description, permissions must be known ones
+func (s *Service) validate(res *r.Resource, ctx context.Context) error {
+	if !name.MatchString(res.Name) {
+		return fmt.Errorf("%w: name must be 2 to 50 lower case letters, digits, dashes or underscores", internal.ErrValidationFailed)
+	}
+
+	if len(res.Description) > maxDescription {
+		return fmt.Errorf("%w: description can't be longer than %d characters", internal.ErrValidationFailed, maxDescription)
+	}
+
+	if res.Permissions == nil {
+		return nil
+	}
+
+	known, err := s.repo.Permissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	res.Permissions = unique(res.Permissions)
+
+	for _, permission := range res.Permissions {
+		if !contains(known, permission) {
+			return fmt.Errorf("%w: unknown permission %q", internal.ErrValidationFailed, permission)
+		}
+	}
+
+	return nil
+}
+
+// unique returns sorted names without duplicates
+func unique(names []string) []string {
+	res := []string{}
+
+	for _, name := range names {
+		if !contains(res, name) {
+			res = append(res, name)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
+
+// contains reports if name is listed
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// missing returns names no role was found for
+func missing(names []string, roles []*r.Resource) []string {
+	var res []string
+
+	for _, name := range names {
+		found := false
+
+		for _, role := range roles {
+			found = found || role.Name == name
+		}
+
+		if !found {
+			res = append(res, name)
+		}
+	}
+
+	return res
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
@@ -0,0 +1,288 @@
+package roles
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	r "github.com/darkjedidj/cinema-service/internal/repository/roles"
+	users "github.com/darkjedidj/cinema-service/internal/service/user"
+)
+
+var (
+	find = regexp.QuoteMeta("FROM roles WHERE roles.name IN ")
+
+	holders = regexp.QuoteMeta("SELECT user_roles.user_id FROM user_roles JOIN roles ON user_roles.role_id = roles.id " +
+		"WHERE roles.name = $1 ORDER BY user_roles.user_id FOR UPDATE OF user_roles")
+
+	roleColumns = []string{"id", "name", "description", "system", "permissions"}
+)
+
+func TestAssign(t *testing.T) {
+	testAssignCases := []struct {
+		name          string
+		user          int64
+		roles         []string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success, roles are replaced",
+			user:          7,
+			roles:         []string{"manager", "cashier", "cashier"},
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(find).
+					WithArgs("cashier", "manager").
+					WillReturnRows(sqlm2.NewRows(roleColumns).
+						AddRow(2, "cashier", "", true, "{}").
+						AddRow(3, "manager", "", true, "{}"))
+				sqlm2.ExpectQuery(holders).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows([]string{"user_id"}).AddRow(1))
+				sqlm2.ExpectExec(regexp.QuoteMeta("DELETE FROM user_roles WHERE user_id = $1")).
+					WithArgs(7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectExec(regexp.QuoteMeta("INSERT INTO user_roles (user_id,role_id) VALUES ($1,$2),($3,$4)")).
+					WithArgs(7, 2, 7, 3).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				sqlm2.ExpectCommit()
+				sqlm2.ExpectQuery(regexp.QuoteMeta("JOIN user_roles ON user_roles.role_id = roles.id WHERE user_roles.user_id = $1")).
+					WithArgs(7).
+					WillReturnRows(sqlm2.NewRows(roleColumns).
+						AddRow(2, "cashier", "", true, "{}").
+						AddRow(3, "manager", "", true, "{}"))
+			},
+		},
+		{
+			name:          "failed, unknown role",
+			user:          7,
+			roles:         []string{"cashier", "wizard"},
+			expectedError: internal.ErrValidationFailed,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(find).
+					WithArgs("cashier", "wizard").
+					WillReturnRows(sqlm2.NewRows(roleColumns).AddRow(2, "cashier", "", true, "{}"))
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, last superadmin",
+			user:          1,
+			roles:         []string{"manager"},
+			expectedError: internal.ErrSystemRole,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(find).
+					WithArgs("manager").
+					WillReturnRows(sqlm2.NewRows(roleColumns).AddRow(3, "manager", "", true, "{}"))
+				sqlm2.ExpectQuery(holders).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows([]string{"user_id"}).AddRow(1))
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testAssignCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			s := &Service{repo: &r.Repository{DB: db, Log: logger}, log: logger}
+
+			tc.prepare(mock)
+
+			res, err := s.Assign(tc.user, tc.roles, context.Background())
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, res, 2)
+		})
+	}
+}
+
+func TestBootstrapSkipped(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	s := &Service{repo: &r.Repository{DB: db, Log: logger}, log: logger}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(holders).
+		WithArgs(r.Superadmin).
+		WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow(1))
+	mock.ExpectRollback()
+
+	created, err := s.Bootstrap("admin@cinema.com", "secret", context.Background())
+	assert.NoError(t, err)
+	assert.False(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBootstrap(t *testing.T) {
+	retrieve := regexp.QuoteMeta("SELECT id, email, password FROM users WHERE email = $1")
+	insert := regexp.QuoteMeta("INSERT INTO users (email,password) VALUES ($1,$2) RETURNING id")
+	grant := regexp.QuoteMeta("INSERT INTO user_roles (user_id,role_id) VALUES ($1,$2) ON CONFLICT DO NOTHING")
+
+	testBootstrapCases := []struct {
+		name          string
+		email         string
+		password      string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success, user signs up in transaction",
+			email:         "admin@cinema.com",
+			password:      "secret",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(holders).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows([]string{"user_id"}))
+				sqlm2.ExpectQuery(retrieve).
+					WithArgs("admin@cinema.com").
+					WillReturnRows(sqlm2.NewRows([]string{"id", "email", "password"}))
+				sqlm2.ExpectQuery(insert).
+					WithArgs("admin@cinema.com", sqlmock.AnyArg()).
+					WillReturnRows(sqlm2.NewRows([]string{"id"}).AddRow(7))
+				sqlm2.ExpectQuery(find).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows(roleColumns).AddRow(4, r.Superadmin, "", true, "{}"))
+				sqlm2.ExpectExec(grant).
+					WithArgs(7, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:          "success, existing user",
+			email:         "admin@cinema.com",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(holders).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows([]string{"user_id"}))
+				sqlm2.ExpectQuery(retrieve).
+					WithArgs("admin@cinema.com").
+					WillReturnRows(sqlm2.NewRows([]string{"id", "email", "password"}).AddRow(3, "admin@cinema.com", "hash"))
+				sqlm2.ExpectQuery(find).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows(roleColumns).AddRow(4, r.Superadmin, "", true, "{}"))
+				sqlm2.ExpectExec(grant).
+					WithArgs(3, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:          "failed, malformed email",
+			email:         "admin",
+			password:      "secret",
+			expectedError: internal.ErrWrongEmail,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(holders).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows([]string{"user_id"}))
+				sqlm2.ExpectQuery(retrieve).
+					WithArgs("admin").
+					WillReturnRows(sqlm2.NewRows([]string{"id", "email", "password"}))
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, missing password",
+			email:         "admin@cinema.com",
+			expectedError: internal.ErrValidationFailed,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(holders).
+					WithArgs(r.Superadmin).
+					WillReturnRows(sqlm2.NewRows([]string{"user_id"}))
+				sqlm2.ExpectQuery(retrieve).
+					WithArgs("admin@cinema.com").
+					WillReturnRows(sqlm2.NewRows([]string{"id", "email", "password"}))
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testBootstrapCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			s := &Service{repo: &r.Repository{DB: db, Log: logger}, users: users.Init(db, logger), log: logger}
+
+			tc.prepare(mock)
+
+			created, err := s.Bootstrap(tc.email, tc.password, context.Background())
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.False(t, created)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, created)
+		})
+	}
+}
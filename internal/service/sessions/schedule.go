@@ -0,0 +1,304 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	h "github.com/darkjedidj/cinema-service/internal/repository/sessions"
+)
+
+const (
+	// maxDays limits date range of one schedule
+	maxDays = 31
+
+	// maxSlots limits sessions generated by one schedule
+	maxSlots = 1000
+
+	dayLayout   = "2006-01-02"
+	clockLayout = "15:04"
+	startLayout = "2006-01-02 15:04:05"
+)
+
+// Template is a struct to store screenings requested for bulk scheduling
+type Template struct {
+	Movie_ID int64    `json:"movie_id"`
+	Halls    []int64  `json:"halls"`
+	From     string   `json:"from"`             // First day, 2006-01-02
+	To       string   `json:"to"`               // Last day, inclusive
+	Times    []string `json:"times,omitempty"`  // Daily start times, 15:04
+	Opens    string   `json:"opens,omitempty"`  // Without times screenings follow each other from opening
+	Closes   string   `json:"closes,omitempty"` // until last one ends by closing, past midnight when earlier than opening
+	Dry_run  bool     `json:"dry_run"`
+}
+
+func (t *Template) GID() int64 {
+	return t.Movie_ID
+}
+
+// Slot is a struct to store proposed session and screenings it overlaps
+type Slot struct {
+	Session   *h.Resource   `json:"session"`
+	Conflicts []*h.Resource `json:"conflicts,omitempty"` // Planned sessions of this schedule have no ID
+
+	starts time.Time
+}
+
+// Schedule is a struct to store sessions generated from template
+type Schedule struct {
+	Dry_run bool    `json:"dry_run"`
+	Created int64   `json:"created"`
+	Slots   []*Slot `json:"slots"`
+}
+
+func (s *Schedule) GID() int64 {
+	return 0
+}
+
+// conflicts lists distinct screenings overlapping schedule slots
+func (s *Schedule) conflicts() []*h.Resource {
+	var sessions []*h.Resource
+
+	seen := map[string]bool{}
+
+	for _, slot := range s.Slots {
+		for _, conflict := range slot.Conflicts {
+			key := fmt.Sprintf("%d/%d/%s", conflict.ID, conflict.Hall_id, conflict.Starts_at)
+			if seen[key] {
+				continue
+			}
+
+			seen[key] = true
+			sessions = append(sessions, conflict)
+		}
+	}
+
+	return sessions
+}
+
+// Schedule generates sessions from template in one transaction. Dry run only reports
+// proposed sessions with their conflicts, otherwise any conflict cancels whole schedule.
+func (s *Service) Schedule(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	template, ok := i.(*Template)
+	if !ok {
+		s.log.Info("Failed to assert schedule template object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	runtime, err := s.repo.Runtime(template.Movie_ID, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if runtime <= 0 {
+		return nil, s.rollback(tx, fmt.Errorf("%w: movie does not exist or has no duration", internal.ErrValidationFailed))
+	}
+
+	slots, err := Plan(template, runtime, s.turnover)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	for _, hall := range template.Halls {
+		found, err := s.repo.LockHall(hall, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		if !found {
+			return nil, s.rollback(tx, fmt.Errorf("%w: hall %d does not exist", internal.ErrValidationFailed, hall))
+		}
+	}
+
+	schedule := &Schedule{Dry_run: template.Dry_run, Slots: slots}
+
+	for _, slot := range slots {
+		conflicts, err := s.repo.Conflicts(slot.Session, s.turnover, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		slot.Conflicts = append(conflicts, slot.Conflicts...)
+	}
+
+	conflicts := schedule.conflicts()
+
+	if template.Dry_run {
+		return schedule, s.rollback(tx, nil)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, s.rollback(tx, &Conflict{Sessions: conflicts, Schedule: schedule})
+	}
+
+	for _, slot := range slots {
+		slot.Session.ID, err = s.repo.Insert(slot.Session, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		schedule.Created++
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return schedule, nil
+}
+
+// Plan lays out template screenings day by day and marks slots overlapping each other,
+// hall is kept busy for turnover after every screening
+func Plan(t *Template, runtime time.Duration, turnover time.Duration) ([]*Slot, error) {
+	if len(t.Halls) == 0 {
+		return nil, fmt.Errorf("%w: schedule needs at least one hall", internal.ErrValidationFailed)
+	}
+
+	from, err := time.Parse(dayLayout, t.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from must be a date like 2022-04-01", internal.ErrValidationFailed)
+	}
+
+	to, err := time.Parse(dayLayout, t.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: to must be a date like 2022-04-07", internal.ErrValidationFailed)
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: schedule can't end before it starts", internal.ErrValidationFailed)
+	}
+
+	if to.Sub(from) >= maxDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: schedule can't be longer than %d days", internal.ErrValidationFailed, maxDays)
+	}
+
+	starts, err := daily(t, runtime, turnover)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(starts) == 0 {
+		return nil, fmt.Errorf("%w: no screening fits between opening and closing", internal.ErrValidationFailed)
+	}
+
+	days := int(to.Sub(from)/(24*time.Hour)) + 1
+
+	if days*len(t.Halls)*len(starts) > maxSlots {
+		return nil, fmt.Errorf("%w: schedule can't have more than %d sessions", internal.ErrValidationFailed, maxSlots)
+	}
+
+	var slots []*Slot
+
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		for _, hall := range t.Halls {
+			for _, start := range starts {
+				at := day.Add(start)
+
+				slots = append(slots, &Slot{
+					Session: &h.Resource{
+						Hall_id:   hall,
+						Movie_id:  t.Movie_ID,
+						Starts_at: at.Format(startLayout),
+						Ends_at:   at.Add(runtime).Format(startLayout),
+					},
+					starts: at,
+				})
+			}
+		}
+	}
+
+	clash(slots, runtime+turnover)
+
+	return slots, nil
+}
+
+// daily returns screening start offsets from midnight
+func daily(t *Template, runtime time.Duration, turnover time.Duration) ([]time.Duration, error) {
+	var starts []time.Duration
+
+	if len(t.Times) > 0 {
+		for _, value := range t.Times {
+			start, err := timeOfDay(value)
+			if err != nil {
+				return nil, err
+			}
+
+			starts = append(starts, start)
+		}
+
+		sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+		return starts, nil
+	}
+
+	if t.Opens == "" || t.Closes == "" {
+		return nil, fmt.Errorf("%w: schedule needs start times or opening and closing time", internal.ErrValidationFailed)
+	}
+
+	opens, err := timeOfDay(t.Opens)
+	if err != nil {
+		return nil, err
+	}
+
+	closes, err := timeOfDay(t.Closes)
+	if err != nil {
+		return nil, err
+	}
+
+	if closes <= opens {
+		closes += 24 * time.Hour
+	}
+
+	for start := opens; start+runtime <= closes; start += runtime + turnover {
+		starts = append(starts, start)
+	}
+
+	return starts, nil
+}
+
+// timeOfDay parses time of day into offset from midnight
+func timeOfDay(value string) (time.Duration, error) {
+	at, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time %q must look like 18:30", internal.ErrValidationFailed, value)
+	}
+
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// clash marks slots of the same hall starting closer than busy to each other
+func clash(slots []*Slot, busy time.Duration) {
+	for n, slot := range slots {
+		for _, other := range slots[n+1:] {
+			if other.Session.Hall_id != slot.Session.Hall_id {
+				continue
+			}
+
+			gap := other.starts.Sub(slot.starts)
+			if gap < busy && gap > -busy {
+				slot.Conflicts = append(slot.Conflicts, other.Session)
+				other.Conflicts = append(other.Conflicts, slot.Session)
+			}
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sessions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/darkjedidj/cinema-service/internal"
+)
+
+func TestPlan(t *testing.T) {
+	runtime := 2 * time.Hour
+	turnover := 15 * time.Minute
+
+	testPlanCases := []struct {
+		name           string
+		template       *Template
+		expectedError  error
+		expectedStarts []string
+		expectedClash  int
+	}{
+		{
+			name:           "daily times in every hall",
+			template:       &Template{Movie_ID: 2, Halls: []int64{1, 2}, From: "2022-04-01", To: "2022-04-02", Times: []string{"18:00", "12:00"}},
+			expectedStarts: []string{"2022-04-01 12:00:00", "2022-04-01 18:00:00", "2022-04-01 12:00:00", "2022-04-01 18:00:00", "2022-04-02 12:00:00", "2022-04-02 18:00:00", "2022-04-02 12:00:00", "2022-04-02 18:00:00"},
+		},
+		{
+			name:           "as many as fit until closing",
+			template:       &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-04-01", Opens: "10:00", Closes: "17:00"},
+			expectedStarts: []string{"2022-04-01 10:00:00", "2022-04-01 12:15:00", "2022-04-01 14:30:00"},
+		},
+		{
+			name:           "closing past midnight",
+			template:       &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-04-01", Opens: "20:00", Closes: "01:00"},
+			expectedStarts: []string{"2022-04-01 20:00:00", "2022-04-01 22:15:00"},
+		},
+		{
+			name:           "times closer than runtime and turnover clash",
+			template:       &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-04-01", Times: []string{"12:00", "14:10"}},
+			expectedStarts: []string{"2022-04-01 12:00:00", "2022-04-01 14:10:00"},
+			expectedClash:  2,
+		},
+		{
+			name:          "no halls",
+			template:      &Template{Movie_ID: 2, From: "2022-04-01", To: "2022-04-01", Times: []string{"12:00"}},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "range ends before it starts",
+			template:      &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-02", To: "2022-04-01", Times: []string{"12:00"}},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "range too long",
+			template:      &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-05-02", Times: []string{"12:00"}},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "wrong time",
+			template:      &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-04-01", Times: []string{"noon"}},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "no times and no opening",
+			template:      &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-04-01"},
+			expectedError: internal.ErrValidationFailed,
+		},
+		{
+			name:          "nothing fits",
+			template:      &Template{Movie_ID: 2, Halls: []int64{1}, From: "2022-04-01", To: "2022-04-01", Opens: "10:00", Closes: "11:00"},
+			expectedError: internal.ErrValidationFailed,
+		},
+	}
+
+	for _, tc := range testPlanCases {
+		t.Run(tc.name, func(t *testing.T) {
+			slots, err := Plan(tc.template, runtime, turnover)
+			if tc.expectedError != nil {
+				assert.True(t, errors.Is(err, tc.expectedError))
+				return
+			}
+
+			assert.NoError(t, err)
+
+			var starts []string
+			var clashes int
+
+			for _, slot := range slots {
+				starts = append(starts, slot.Session.Starts_at)
+				clashes += len(slot.Conflicts)
+			}
+
+			assert.Equal(t, tc.expectedStarts, starts)
+			assert.Equal(t, tc.expectedClash, clashes)
+		})
+	}
+}
@@ -4,26 +4,58 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
+	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
 	h "github.com/darkjedidj/cinema-service/internal/repository/sessions"
+	"github.com/darkjedidj/cinema-service/package/clock"
 )
 
 // Service is a struct to store DB and logger connection
 type Service struct {
-	repo *h.Repository
-	log  *zap.Logger
+	repo     *h.Repository
+	turnover time.Duration
+	clock    clock.Clock
+	log      *zap.Logger
+}
+
+// Conflict is returned when session overlaps other screenings in the hall
+type Conflict struct {
+	Sessions []*h.Resource
+	Schedule *Schedule // Proposed schedule when conflict was found by bulk scheduling
+}
+
+func (e *Conflict) Error() string {
+	return internal.ErrSessionConflict.Error()
+}
+
+func (e *Conflict) Unwrap() error {
+	return internal.ErrSessionConflict
 }
 
 // Init returns Service object
 func Init(db *sql.DB, l *zap.Logger) *Service {
 
 	return &Service{
-		repo: &h.Repository{DB: db, Log: l},
-		log:  l,
+		repo:     &h.Repository{DB: db, Log: l},
+		turnover: Turnover(),
+		clock:    clock.Real{},
+		log:      l,
+	}
+}
+
+// Turnover reads hall cleaning time between screenings from SESSION_TURNOVER environment variable
+func Turnover() time.Duration {
+	turnover, err := time.ParseDuration(os.Getenv("SESSION_TURNOVER"))
+	if err != nil || turnover < 0 {
+		return 15 * time.Minute
 	}
+
+	return turnover
 }
 
 // Create logic layer for repository method
@@ -37,14 +69,177 @@ func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal
 		return nil, internal.ErrInternalFailure
 	}
 
-	valid, err := s.repo.TimeValid(res, ctx)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	found, err := s.repo.LockHall(res.Hall_id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if !found {
+		return nil, s.rollback(tx, fmt.Errorf("%w: hall does not exist", internal.ErrValidationFailed))
+	}
+
+	conflicts, err := s.repo.Conflicts(res, s.turnover, ctx, tx)
 	if err != nil {
-		return nil, err
+		return nil, s.rollback(tx, err)
 	}
-	if valid {
-		return s.repo.Create(i, ctx)
+
+	if len(conflicts) > 0 {
+		return nil, s.rollback(tx, &Conflict{Sessions: conflicts})
 	}
-	return nil, fmt.Errorf("%w: this time is already in use", internal.ErrValidationFailed)
+
+	id, err := s.repo.Insert(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Update moves session after the same hall and overlap checks as create.
+// Session with sold tickets keeps its hall, movie and start, buyers paid for them.
+// Session with version set is moved only if nobody changed it since that version.
+func (s *Service) Update(id int64, i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := i.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert session object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.ID = id
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	current, err := s.repo.Lock(id, ctx, tx)
+	if err != nil || current == nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if res.Version > 0 && res.Version != current.Version {
+		return nil, s.rollback(tx, internal.ErrVersionMismatch)
+	}
+
+	found, err := s.repo.LockHall(res.Hall_id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if !found {
+		return nil, s.rollback(tx, fmt.Errorf("%w: hall does not exist", internal.ErrValidationFailed))
+	}
+
+	if res.Hall_id != current.Hall_id || res.Movie_id != current.Movie_id || !sameStart(res.Starts_at, current.Starts_at) {
+		sold, err := s.repo.Sold(id, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		if sold > 0 {
+			return nil, s.rollback(tx, internal.ErrTicketsSold)
+		}
+	}
+
+	conflicts, err := s.repo.Conflicts(res, s.turnover, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if len(conflicts) > 0 {
+		return nil, s.rollback(tx, &Conflict{Sessions: conflicts})
+	}
+
+	err = s.repo.Update(res, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Showtimes returns page of upcoming sessions for customers, sorted by start time unless query sorts them.
+// From and to filters are days like 2022-04-01, to includes the whole day, available keeps sessions with seats left.
+func (s *Service) Showtimes(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	now := s.clock.Now().UTC()
+
+	f := &h.ShowtimeFilter{From: now}
+
+	from, to, available := q.Filters["from"], q.Filters["to"], q.Filters["available"]
+	for _, name := range []string{"from", "to", "available"} {
+		delete(q.Filters, name)
+	}
+
+	if from != "" {
+		day, err := time.Parse(dayLayout, from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from must be a date like 2022-04-01", internal.ErrValidationFailed)
+		}
+
+		if day.After(now) {
+			f.From = day
+		}
+	}
+
+	if to != "" {
+		day, err := time.Parse(dayLayout, to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to must be a date like 2022-04-07", internal.ErrValidationFailed)
+		}
+
+		f.To = day.AddDate(0, 0, 1)
+
+		if !f.To.After(f.From) {
+			return nil, fmt.Errorf("%w: to can't be before from", internal.ErrValidationFailed)
+		}
+	}
+
+	if available != "" {
+		value, err := strconv.ParseBool(available)
+		if err != nil {
+			return nil, fmt.Errorf("%w: available must be true or false", internal.ErrValidationFailed)
+		}
+
+		f.Available = value
+	}
+
+	if q.Sort == "" {
+		q.Sort = "starts_at"
+	}
+
+	return s.repo.Showtimes(f, q, now, ctx)
 }
 
 // Retrieve logic layer for repository method
@@ -53,11 +248,52 @@ func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable
 }
 
 // RetriveAll logic layer for repository method
-func (s *Service) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
-	return s.repo.RetrieveAll(ctx)
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
 }
 
 // Delete logic layer for repository method
 func (s *Service) Delete(id int64, ctx context.Context) error {
-	return s.repo.Delete(int64(id), ctx)
+	return s.repo.Delete(id, 0, ctx)
+}
+
+// DeleteVersion deletes entity only while it still has expected version
+func (s *Service) DeleteVersion(id int64, version int64, ctx context.Context) error {
+	return s.repo.Delete(id, version, ctx)
+}
+
+// sameStart tells if both start times are the same moment, text that isn't a time is compared as is
+func sameStart(a string, b string) bool {
+	at, aErr := parseStart(a)
+	bt, bErr := parseStart(b)
+
+	if aErr != nil || bErr != nil {
+		return a == b
+	}
+
+	return at.Equal(bt)
+}
+
+// parseStart reads session start written in RFC 3339 or schedule layout
+func parseStart(value string) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return at, nil
+	}
+
+	return time.Parse(startLayout, value)
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
 }
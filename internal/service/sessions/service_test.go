@@ -0,0 +1,107 @@
+package sessions
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	h "github.com/darkjedidj/cinema-service/internal/repository/sessions"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+func TestUpdateSold(t *testing.T) {
+	lock := regexp.QuoteMeta("SELECT id, hall_id, movie_id, starts_at, version FROM sessions WHERE id = $1 FOR UPDATE")
+	lockHall := regexp.QuoteMeta("SELECT id FROM halls WHERE id = $1 FOR UPDATE")
+	sold := regexp.QuoteMeta("SELECT COUNT(*) FROM tickets WHERE session_id = $1 AND status NOT IN ($2,$3)")
+
+	testUpdateSoldCases := []struct {
+		name          string
+		session       *h.Resource
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "failed, other hall",
+			session:       &h.Resource{Hall_id: 5, Movie_id: 2, Starts_at: "2022-01-01T08:00:00Z"},
+			expectedError: internal.ErrTicketsSold,
+		},
+		{
+			name:          "failed, other movie",
+			session:       &h.Resource{Hall_id: 4, Movie_id: 3, Starts_at: "2022-01-01T08:00:00Z"},
+			expectedError: internal.ErrTicketsSold,
+		},
+		{
+			name:          "failed, other start",
+			session:       &h.Resource{Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 10:00:00"},
+			expectedError: internal.ErrTicketsSold,
+		},
+		{
+			name:          "success, same start in schedule layout",
+			session:       &h.Resource{Hall_id: 4, Movie_id: 2, Starts_at: "2022-01-01 08:00:00"},
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT sessions.id, sessions.hall_id, sessions.movie_id, movies.name, sessions.starts_at")).
+					WillReturnRows(sqlm2.NewRows([]string{"id", "hall_id", "movie_id", "name", "starts_at", "ends_at"}))
+				sqlm2.ExpectExec(regexp.QuoteMeta("UPDATE sessions SET hall_id = $1, movie_id = $2, starts_at = $3 WHERE id = $4")).
+					WithArgs(4, 2, "2022-01-01 08:00:00", 15).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectCommit()
+				sqlm2.ExpectQuery(regexp.QuoteMeta("SELECT sessions.id, sessions.hall_id, sessions.movie_id, halls.vip, movies.name, starts_at, sessions.version FROM sessions")).
+					WithArgs(15).
+					WillReturnRows(sqlm2.NewRows([]string{"id", "hall_id", "movie_id", "vip", "name", "starts_at", "version"}).
+						AddRow(15, 4, 2, false, "Matrix", "2022-01-01T08:00:00Z", 4))
+			},
+		},
+	}
+
+	for _, tc := range testUpdateSoldCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			s := &Service{
+				repo:     &h.Repository{DB: db, Log: logger},
+				turnover: 15 * time.Minute,
+				clock:    clock.NewFake(time.Date(2021, time.December, 1, 12, 0, 0, 0, time.UTC)),
+				log:      logger,
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(lock).
+				WithArgs(15).
+				WillReturnRows(mock.NewRows([]string{"id", "hall_id", "movie_id", "starts_at", "version"}).
+					AddRow(15, 4, 2, "2022-01-01T08:00:00Z", 3))
+			mock.ExpectQuery(lockHall).
+				WithArgs(tc.session.Hall_id).
+				WillReturnRows(mock.NewRows([]string{"id"}).AddRow(tc.session.Hall_id))
+
+			if tc.prepare == nil {
+				mock.ExpectQuery(sold).
+					WithArgs(15, "refunded", "expired").
+					WillReturnRows(mock.NewRows([]string{"count"}).AddRow(2))
+				mock.ExpectRollback()
+			} else {
+				tc.prepare(mock)
+			}
+
+			_, err = s.Update(15, tc.session, context.Background())
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
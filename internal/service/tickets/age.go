@@ -0,0 +1,34 @@
+package tickets
+
+import (
+	"context"
+
+	us "github.com/darkjedidj/cinema-service/internal/repository/users"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// AgeGate decides if user is old enough to buy tickets for movies with minimum age
+type AgeGate interface {
+	Allow(user int64, age int, ctx context.Context) (bool, error)
+}
+
+// Verified lets in users whose verified birth date shows they reached minimum age,
+// users who didn't prove their age are refused
+type Verified struct {
+	users *us.Repository
+	clock clock.Clock
+}
+
+// Allow checks user had birthday of minimum age by today
+func (v Verified) Allow(user int64, age int, ctx context.Context) (bool, error) {
+	born, err := v.users.BirthDate(user, ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if born == nil {
+		return false, nil
+	}
+
+	return !born.AddDate(age, 0, 0).After(v.clock.Now().UTC()), nil
+}
@@ -0,0 +1,89 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	us "github.com/darkjedidj/cinema-service/internal/repository/users"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+func TestVerifiedAllow(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT birth_date FROM users WHERE id = $1")
+
+	testAllowCases := []struct {
+		name           string
+		born           interface{}
+		err            error
+		expectedResult bool
+		expectedError  error
+	}{
+		{
+			name:           "allowed, old enough",
+			born:           time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+			expectedResult: true,
+		},
+		{
+			name:           "allowed, birthday today",
+			born:           time.Date(2003, time.October, 18, 0, 0, 0, 0, time.UTC),
+			expectedResult: true,
+		},
+		{
+			name:           "refused, too young",
+			born:           time.Date(2003, time.October, 19, 0, 0, 0, 0, time.UTC),
+			expectedResult: false,
+		},
+		{
+			name:           "refused, birth date not verified",
+			born:           nil,
+			expectedResult: false,
+		},
+		{
+			name:           "failed, database error",
+			err:            fmt.Errorf("unable to perform your request, please try again later"),
+			expectedResult: false,
+			expectedError:  internal.ErrInternalFailure,
+		},
+	}
+
+	for _, tc := range testAllowCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			expected := mock.ExpectQuery(query).WithArgs(15)
+			if tc.err != nil {
+				expected.WillReturnError(tc.err)
+			} else {
+				expected.WillReturnRows(mock.NewRows([]string{"birth_date"}).AddRow(tc.born))
+			}
+
+			gate := Verified{
+				users: &us.Repository{DB: db, Log: logger},
+				clock: clock.NewFake(time.Date(2021, time.October, 18, 12, 0, 0, 0, time.UTC)),
+			}
+
+			allowed, err := gate.Allow(15, 18, context.Background())
+			assert.Equal(t, tc.expectedResult, allowed)
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package tickets
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// ExpireInterval is a pause between unused tickets checks
+const ExpireInterval = time.Minute
+
+// Expirer marks tickets unused by the end of admission as expired in background
+type Expirer struct {
+	repo     *h.Repository
+	clock    clock.Clock
+	doors    Admission
+	interval time.Duration
+	log      *zap.Logger
+}
+
+// NewExpirer returns Expirer object
+func NewExpirer(db *sql.DB, l *zap.Logger, c clock.Clock, interval time.Duration) *Expirer {
+
+	return &Expirer{
+		repo:     &h.Repository{DB: db, Log: l},
+		clock:    c,
+		doors:    AdmissionWindow(),
+		interval: interval,
+		log:      l,
+	}
+}
+
+// Run expires tickets every interval until context is cancelled
+func (e *Expirer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.clock.After(e.interval):
+			e.Expire(ctx)
+		}
+	}
+}
+
+// Expire marks tickets of sessions with closed admission as expired
+func (e *Expirer) Expire(ctx context.Context) {
+	before := e.clock.Now().UTC().Add(-e.doors.Closes)
+
+	expired, err := e.repo.Expire(before, unused(), ctx)
+	if err != nil {
+		return
+	}
+
+	if expired > 0 {
+		e.log.Info("Expired unused tickets.",
+			zap.Int64("tickets", expired),
+		)
+	}
+}
+
+// unused lists statuses of tickets expirer takes, reserved tickets wait for payment
+// of their order and are released by order expirer together with the order
+func unused() []string {
+	var from []string
+
+	for _, status := range sources(h.Expired) {
+		if status != h.Reserved {
+			from = append(from, status)
+		}
+	}
+
+	return from
+}
@@ -0,0 +1,14 @@
+package tickets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+func TestUnused(t *testing.T) {
+	assert.Equal(t, []string{h.Paid, h.Issued}, unused())
+	assert.NotContains(t, unused(), h.Reserved)
+}
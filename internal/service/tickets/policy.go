@@ -0,0 +1,51 @@
+package tickets
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+// Policy describes how much customer gets back on cancellation
+type Policy struct {
+	Cutoff     time.Duration // Customers can't cancel closer to session start
+	FullBefore time.Duration // Full refund when cancelled earlier than this
+	Percent    float64       // Part of price returned after FullBefore, none when zero
+}
+
+// RefundPolicy reads policy from REFUND_CUTOFF, REFUND_FULL_BEFORE and REFUND_PERCENT environment variables
+func RefundPolicy() Policy {
+	p := Policy{Cutoff: 2 * time.Hour, FullBefore: 24 * time.Hour, Percent: 50}
+
+	if cutoff, err := time.ParseDuration(os.Getenv("REFUND_CUTOFF")); err == nil && cutoff >= 0 {
+		p.Cutoff = cutoff
+	}
+
+	if full, err := time.ParseDuration(os.Getenv("REFUND_FULL_BEFORE")); err == nil && full >= 0 {
+		p.FullBefore = full
+	}
+
+	if percent, err := strconv.ParseFloat(os.Getenv("REFUND_PERCENT"), 64); err == nil && percent >= 0 && percent <= 100 {
+		p.Percent = percent
+	}
+
+	return p
+}
+
+// Refund computes refund of ticket price with time left before session,
+// returns nil when cutoff has passed
+func (p Policy) Refund(price float64, left time.Duration) *h.Refund {
+	switch {
+	case left < p.Cutoff:
+		return nil
+	case left >= p.FullBefore:
+		return &h.Refund{Amount: price, Policy: h.Full}
+	case p.Percent > 0:
+		return &h.Refund{Amount: math.Round(price*p.Percent) / 100, Policy: h.Partial}
+	default:
+		return &h.Refund{Amount: 0, Policy: h.None}
+	}
+}
@@ -0,0 +1,58 @@
+package tickets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+func TestPolicyRefund(t *testing.T) {
+	policy := Policy{Cutoff: 2 * time.Hour, FullBefore: 24 * time.Hour, Percent: 50}
+
+	testRefundCases := []struct {
+		name           string
+		policy         Policy
+		left           time.Duration
+		expectedResult *h.Refund
+	}{
+		{
+			name:           "full refund",
+			policy:         policy,
+			left:           48 * time.Hour,
+			expectedResult: &h.Refund{Amount: 12.5, Policy: h.Full},
+		},
+		{
+			name:           "partial refund",
+			policy:         policy,
+			left:           3 * time.Hour,
+			expectedResult: &h.Refund{Amount: 6.25, Policy: h.Partial},
+		},
+		{
+			name:           "no refund",
+			policy:         Policy{Cutoff: 2 * time.Hour, FullBefore: 24 * time.Hour},
+			left:           3 * time.Hour,
+			expectedResult: &h.Refund{Amount: 0, Policy: h.None},
+		},
+		{
+			name:           "cutoff passed",
+			policy:         policy,
+			left:           time.Hour,
+			expectedResult: nil,
+		},
+		{
+			name:           "session started",
+			policy:         policy,
+			left:           -time.Hour,
+			expectedResult: nil,
+		},
+	}
+
+	for _, tc := range testRefundCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, tc.policy.Refund(12.5, tc.left))
+		})
+	}
+}
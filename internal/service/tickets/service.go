@@ -2,121 +2,679 @@ package tickets
 
 import (
 	"context"
+	"crypto/ed25519"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	hd "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	lt "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	"github.com/darkjedidj/cinema-service/internal/repository/movies"
+	pm "github.com/darkjedidj/cinema-service/internal/repository/payments"
+	pr "github.com/darkjedidj/cinema-service/internal/repository/pricing"
 	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	us "github.com/darkjedidj/cinema-service/internal/repository/users"
+	wl "github.com/darkjedidj/cinema-service/internal/repository/waitlist"
+	"github.com/darkjedidj/cinema-service/internal/service/pricing"
+	"github.com/darkjedidj/cinema-service/internal/service/promos"
+	"github.com/darkjedidj/cinema-service/internal/service/waitlist"
+	"github.com/darkjedidj/cinema-service/package/clock"
+	"github.com/darkjedidj/cinema-service/package/payment"
+	"github.com/darkjedidj/cinema-service/package/qr"
 )
 
 // Service is a struct to store DB and logger connection
 type Service struct {
-	repo *h.Repository
-	log  *zap.Logger
+	repo    *h.Repository
+	layouts *lt.Repository
+	holds   *hd.Repository
+	pricing *pr.Repository
+	pays    *pm.Repository
+	queue   *wl.Repository
+	waiting *waitlist.Service // Offers refunded seats to waitlisted users
+	promos  *promos.Service
+	clock   clock.Clock
+	policy  Policy
+	doors   Admission
+	ages    AgeGate                 // Checks age of buyers of age rated movies
+	key     ed25519.PrivateKey      // Signs ticket codes, nil when not configured
+	money   payment.PaymentProvider // Returns refunds of paid orders, nil when not configured
+	log     *zap.Logger
 }
 
 // Init returns Service object
 func Init(db *sql.DB, l *zap.Logger) *Service {
 
+	key, err := qr.KeyFromEnv()
+	if err != nil {
+		l.Info("Ticket codes are disabled.",
+			zap.Error(err),
+		)
+	}
+
+	money, err := payment.FromEnv()
+	if err != nil {
+		l.Info("Payment refunds are disabled.",
+			zap.Error(err),
+		)
+	}
+
 	return &Service{
-		repo: &h.Repository{DB: db, Log: l},
-		log:  l,
+		repo:    &h.Repository{DB: db, Log: l},
+		layouts: &lt.Repository{DB: db, Log: l},
+		holds:   &hd.Repository{DB: db, Log: l},
+		pricing: &pr.Repository{DB: db, Log: l},
+		pays:    &pm.Repository{DB: db, Log: l},
+		queue:   &wl.Repository{DB: db, Log: l},
+		waiting: waitlist.Init(db, l),
+		promos:  promos.Init(db, l),
+		clock:   clock.Real{},
+		policy:  RefundPolicy(),
+		doors:   AdmissionWindow(),
+		ages:    Verified{users: &us.Repository{DB: db, Log: l}, clock: clock.Real{}},
+		key:     key,
+		money:   money,
+		log:     l,
 	}
 }
 
-// Create logic layer for repository method
-func (s *Service) Create(i internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
-	res, ok := i.(*h.Resource)
-	if !ok {
-		s.log.Info("Failed to assert ticket object.",
-			zap.Bool("ok", ok),
+// Buy takes held seat for ticket within transaction and prices it by session rules,
+// ticket is stored with status set by caller, caller commits or rolls transaction back
+func (s *Service) Buy(res *h.Resource, ctx context.Context, tx *sql.Tx) (int64, error) {
+	if res.Row < 1 || res.Number < 1 {
+		return 0, fmt.Errorf("%w: seat row and number are required", internal.ErrValidationFailed)
+	}
+
+	if res.Hold == "" {
+		return 0, internal.ErrHoldInvalid
+	}
+
+	layout, err := s.layouts.RetrieveBySession(res.Session_ID, ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if layout == nil {
+		return 0, fmt.Errorf("%w: session does not exist", internal.ErrValidationFailed)
+	}
+
+	taken, err := s.repo.TakenSeats(res.Session_ID, ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if int64(len(taken)) >= layout.Capacity() {
+		return 0, internal.ErrNoSeats
+	}
+
+	seat, place := layout.Seat(res.Row, res.Number)
+	if place == nil {
+		return 0, fmt.Errorf("%w: seat is outside of hall layout", internal.ErrValidationFailed)
+	}
+
+	if place.Blocked {
+		return 0, fmt.Errorf("%w: seat is blocked", internal.ErrValidationFailed)
+	}
+
+	for _, t := range taken {
+		if t == seat {
+			return 0, internal.ErrSeatTaken
+		}
+	}
+
+	held, err := s.holds.Consume(res.Hold, res.User_ID, res.Session_ID, seat, s.clock.Now().UTC(), ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if !held {
+		return 0, internal.ErrHoldInvalid
+	}
+
+	err = s.queue.Accept(res.Hold, ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	show, err := s.pricing.Show(res.Session_ID, ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	if show == nil {
+		return 0, fmt.Errorf("%w: session does not exist", internal.ErrValidationFailed)
+	}
+
+	if age := movie.MinimumAge(show.Age_rating); age > 0 {
+		allowed, err := s.ages.Allow(res.User_ID, age, ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		if !allowed {
+			return 0, internal.ErrAgeRestricted
+		}
+	}
+
+	rules, err := s.pricing.Rules(ctx, tx)
+	if err != nil {
+		return 0, err
+	}
+
+	breakdown, err := pricing.Quote(rules, show, place.Category)
+	if err != nil {
+		return 0, err
+	}
+
+	res.Seat = seat
+	res.Price = breakdown.Total
+	res.Breakdown = breakdown
+
+	return s.repo.Create(ctx, res, tx)
+}
+
+// RetrieveSeats builds availability map of session seats
+func (s *Service) RetrieveSeats(id int64, ctx context.Context) (internal.Identifiable, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
 		)
 
 		return nil, internal.ErrInternalFailure
 	}
 
+	layout, err := s.layouts.RetrieveBySession(id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if layout == nil {
+		return nil, s.rollback(tx, nil)
+	}
+
+	taken, err := s.repo.TakenSeats(id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	held, err := s.holds.HeldSeats(id, s.clock.Now().UTC(), ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return seatMap(id, layout, taken, held), nil
+}
+
+// RetrieveByUser returns page of tickets bought by user, when filter keeps upcoming or past ones.
+// Tickets are sorted by session start, latest first unless only upcoming ones are listed.
+func (s *Service) RetrieveByUser(id int64, q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	when := q.Filters["when"]
+	if when != "" && when != h.Upcoming && when != h.Past {
+		return nil, fmt.Errorf("%w: unknown tickets filter %q", internal.ErrValidationFailed, when)
+	}
+
+	delete(q.Filters, "when")
+
+	if q.Sort == "" {
+		q.Sort, q.Desc = "starts_at", when != h.Upcoming
+	}
+
+	return s.repo.RetrieveByUser(h.Filter{User_ID: id, When: when}, q, s.clock.Now().UTC(), ctx)
+}
+
+// Retrieve logic layer for repository method
+func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.repo.Retrieve(int64(id), ctx)
+}
+
+// RetriveAll logic layer for repository method
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
+}
+
+// Delete refunds ticket on administrator request, keeping it as refunded
+func (s *Service) Delete(id int64, ctx context.Context) error {
+	return s.DeleteVersion(id, 0, ctx)
+}
+
+// DeleteVersion refunds ticket on administrator request while it still has expected version
+func (s *Service) DeleteVersion(id int64, version int64, ctx context.Context) error {
+	_, err := s.refund(id, 0, "deleted by administrator", version, ctx)
+	if errors.Is(err, internal.ErrRefunded) {
+		return nil
+	}
+
+	return err
+}
+
+// Cancel refunds ticket of user by refund policy, nil when user has no such ticket.
+// Version when set must match current ticket version.
+func (s *Service) Cancel(id int64, user int64, version int64, ctx context.Context) (internal.Identifiable, error) {
+	return s.refund(id, user, "", version, ctx)
+}
+
+// Refund returns full ticket price regardless of refund policy.
+// Version when set must match current ticket version.
+func (s *Service) Refund(id int64, reason string, version int64, ctx context.Context) (internal.Identifiable, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: refund reason is required", internal.ErrValidationFailed)
+	}
+
+	return s.refund(id, 0, reason, version, ctx)
+}
+
+// refund moves ticket to refunded status releasing its seat.
+// Tickets of user follow refund policy, forced refunds (zero user) return full price.
+// Version when set must match current ticket version.
+func (s *Service) refund(id int64, user int64, reason string, version int64, ctx context.Context) (internal.Identifiable, error) {
 	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
 	defer cancel()
 
 	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
 	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
 
-		err = tx.Rollback()
-		if err != nil {
-			return nil, internal.ErrInternalFailure
-		}
-		return nil, fmt.Errorf("%w:couldn't open transaction connection", err)
+		return nil, internal.ErrInternalFailure
 	}
 
-	lastSeat, err := s.repo.SeatNumber(res.Session_ID, ctx, tx)
+	ticket, err := s.repo.Lock(id, ctx, tx)
 	if err != nil {
-		err = tx.Rollback()
+		return nil, s.rollback(tx, err)
+	}
+
+	if ticket == nil || (user != 0 && ticket.User_ID != user) {
+		return nil, s.rollback(tx, nil)
+	}
+
+	if version > 0 && ticket.Version != version {
+		return nil, s.rollback(tx, internal.ErrVersionMismatch)
+	}
+
+	if ticket.Status == h.Refunded {
+		return nil, s.rollback(tx, internal.ErrRefunded)
+	}
+
+	if !CanMove(ticket.Status, h.Refunded) {
+		return nil, s.rollback(tx, internal.ErrTicketStatus)
+	}
+
+	now := s.clock.Now().UTC()
+	refund := &h.Refund{Amount: ticket.Price, Policy: h.Forced, Reason: reason}
+
+	if user != 0 {
+		show, err := s.pricing.Show(ticket.Session_ID, ctx, tx)
 		if err != nil {
-			return nil, internal.ErrInternalFailure
+			return nil, s.rollback(tx, err)
 		}
+
+		if show == nil {
+			return nil, s.rollback(tx, internal.ErrInternalFailure)
+		}
+
+		refund = s.policy.Refund(ticket.Price, show.Starts_at.Sub(now))
+		if refund == nil {
+			return nil, s.rollback(tx, internal.ErrRefundClosed)
+		}
+	}
+
+	refund.Refunded_at = now
+
+	refunded, err := s.repo.Refund(id, refund, sources(h.Refunded), ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if !refunded {
+		return nil, s.rollback(tx, internal.ErrRefunded)
+	}
+
+	pay, err := s.owed(id, refund.Amount, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	err = s.repay(id, pay, refund.Amount, ctx)
+	if err != nil {
 		return nil, err
 	}
 
-	lSeat, ok := lastSeat.(*h.Resource)
-	if !ok {
-		s.log.Info("Failed to assert ticket object.",
-			zap.Bool("ok", ok),
+	_, err = s.waiting.Offer(ticket.Session_ID, ctx)
+	if err != nil {
+		s.log.Info("Failed to offer refunded seat to waitlist, dispatcher retries it.",
+			zap.Int64("session", ticket.Session_ID),
+			zap.Error(err),
 		)
+	}
 
-		return nil, internal.ErrInternalFailure
+	return s.repo.Retrieve(id, ctx)
+}
+
+// owed records refund of paid order payment within transaction,
+// nil when ticket wasn't bought in paid order and there's nothing to return
+func (s *Service) owed(id int64, amount float64, ctx context.Context, tx *sql.Tx) (*pm.Resource, error) {
+	if amount <= 0 {
+		return nil, nil
 	}
 
-	maxSeat, err := s.repo.HallSeatNumber(res.Session_ID, ctx, tx)
+	pay, err := s.pays.LockByTicket(id, ctx, tx)
 	if err != nil {
-		err = tx.Rollback()
-		if err != nil {
-			return nil, internal.ErrInternalFailure
-		}
 		return nil, err
 	}
 
-	mSeat, ok := maxSeat.(*h.Resource)
-	if !ok {
-		s.log.Info("Failed to assert ticket object.",
-			zap.Bool("ok", ok),
+	if pay == nil || pay.Status != payment.Captured {
+		return nil, nil
+	}
+
+	if s.money == nil {
+		return nil, internal.ErrPaymentFailed
+	}
+
+	err = s.pays.AddRefund(pay.ID, amount, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return pay, nil
+}
+
+// repay returns recorded refund to customer, it's called once refund is committed
+// so money never leaves when ticket stays sold
+func (s *Service) repay(id int64, pay *pm.Resource, amount float64, ctx context.Context) error {
+	if pay == nil {
+		return nil
+	}
+
+	err := s.money.Refund(ctx, pay.Reference, amount)
+	if err != nil {
+		s.log.Info("Failed to refund payment, recorded refund has to be returned by hand.",
+			zap.Int64("ticket", id),
+			zap.String("reference", pay.Reference),
+			zap.Float64("amount", amount),
+			zap.Error(err),
+		)
+
+		return internal.ErrPaymentFailed
+	}
+
+	return nil
+}
+
+// CheckIn admits holder of ticket to session, nil when there's no such ticket.
+// Version when set must match current ticket version.
+func (s *Service) CheckIn(id int64, session int64, version int64, ctx context.Context) (internal.Identifiable, error) {
+	if session < 1 {
+		return nil, fmt.Errorf("%w: session id is required", internal.ErrValidationFailed)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
 		)
 
 		return nil, internal.ErrInternalFailure
 	}
 
-	if lSeat.Seat >= mSeat.Seat {
-		return nil, internal.ErrNoSeats
+	ticket, err := s.repo.Lock(id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if ticket == nil {
+		return nil, s.rollback(tx, nil)
 	}
 
-	res.Seat = lSeat.Seat + 1
+	if version > 0 && ticket.Version != version {
+		return nil, s.rollback(tx, internal.ErrVersionMismatch)
+	}
+
+	if ticket.Session_ID != session {
+		return nil, s.rollback(tx, internal.ErrWrongSession)
+	}
+
+	if ticket.Status == h.CheckedIn {
+		return nil, s.rollback(tx, internal.ErrCheckedIn)
+	}
+
+	if !CanMove(ticket.Status, h.CheckedIn) {
+		return nil, s.rollback(tx, internal.ErrTicketStatus)
+	}
+
+	show, err := s.pricing.Show(ticket.Session_ID, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if show == nil {
+		return nil, s.rollback(tx, internal.ErrInternalFailure)
+	}
+
+	if !s.doors.Open(show.Starts_at, s.clock.Now().UTC()) {
+		return nil, s.rollback(tx, internal.ErrAdmissionClosed)
+	}
+
+	moved, err := s.repo.Move(id, sources(h.CheckedIn), h.CheckedIn, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if !moved {
+		return nil, s.rollback(tx, internal.ErrCheckedIn)
+	}
 
-	createdID, err := s.repo.Create(ctx, res, tx)
+	err = tx.Commit()
 	if err != nil {
 		return nil, internal.ErrInternalFailure
 	}
 
+	return s.repo.Retrieve(id, ctx)
+}
+
+// Issue marks paid ticket as handed to customer, other tickets keep their status
+func (s *Service) Issue(id int64, ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	_, err = s.repo.Move(id, sources(h.Issued), h.Issued, ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
 	err = tx.Commit()
 	if err != nil {
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Code signs validation code of usable ticket valid until admission closes,
+// nil when user has no such ticket, zero user allows any owner
+func (s *Service) Code(id int64, user int64, ctx context.Context) (internal.Identifiable, error) {
+	if s.key == nil {
 		return nil, internal.ErrInternalFailure
 	}
 
-	return s.repo.Retrieve(int64(createdID), ctx)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	ticket, err := s.repo.Lock(id, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if ticket == nil || (user != 0 && ticket.User_ID != user) {
+		return nil, s.rollback(tx, nil)
+	}
+
+	if !CanMove(ticket.Status, h.CheckedIn) {
+		return nil, s.rollback(tx, internal.ErrTicketStatus)
+	}
+
+	show, err := s.pricing.Show(ticket.Session_ID, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if show == nil {
+		return nil, s.rollback(tx, internal.ErrInternalFailure)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	payload := &qr.Payload{
+		Ticket_ID:  ticket.ID,
+		Session_ID: ticket.Session_ID,
+		Seat:       ticket.Seat,
+		Expires_at: show.Starts_at.Add(s.doors.Closes).UTC().Truncate(time.Second),
+	}
+
+	return &h.Code{Ticket_ID: ticket.ID, Code: qr.Sign(payload, s.key), Expires_at: payload.Expires_at}, nil
 }
 
-// Retrieve logic layer for repository method
-func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable, error) {
-	return s.repo.Retrieve(int64(id), ctx)
+// Verify checks ticket code and returns ticket when it can be admitted, nil when ticket is gone
+func (s *Service) Verify(code string, ctx context.Context) (internal.Identifiable, error) {
+	if s.key == nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	payload, err := qr.Verify(code, s.key.Public().(ed25519.PublicKey), s.clock.Now().UTC())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", internal.ErrCodeInvalid, err)
+	}
+
+	i, err := s.repo.Retrieve(payload.Ticket_ID, ctx)
+	if err != nil || i == nil {
+		return nil, err
+	}
+
+	ticket, ok := i.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert ticket object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	if ticket.Session_ID != payload.Session_ID || ticket.Seat != payload.Seat {
+		return nil, internal.ErrCodeInvalid
+	}
+
+	if ticket.Status == h.CheckedIn {
+		return nil, internal.ErrCheckedIn
+	}
+
+	if !CanMove(ticket.Status, h.CheckedIn) {
+		return nil, internal.ErrTicketStatus
+	}
+
+	return ticket, nil
 }
 
-// RetriveAll logic layer for repository method
-func (s *Service) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
-	return s.repo.RetrieveAll(ctx)
+// VerifyKey returns public key scanners use to verify ticket codes offline
+func (s *Service) VerifyKey() (string, error) {
+	if s.key == nil {
+		return "", internal.ErrInternalFailure
+	}
+
+	return qr.PublicKey(s.key), nil
 }
 
-// Delete logic layer for repository method
-func (s *Service) Delete(id int64, ctx context.Context) error {
-	return s.repo.Delete(int64(id), ctx)
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
+
+// seatMap marks sold seats on hall layout
+func seatMap(id int64, layout *lt.Resource, taken []int64, held []int64) *h.SeatMap {
+	sold := make(map[int64]bool, len(taken))
+	for _, t := range taken {
+		sold[t] = true
+	}
+
+	reserved := make(map[int64]bool, len(held))
+	for _, r := range held {
+		reserved[r] = true
+	}
+
+	res := &h.SeatMap{Session_ID: id, Seats: []h.Seat{}}
+
+	var position int64
+	for _, row := range layout.Rows {
+		for _, seat := range row.Seats {
+			position++
+
+			res.Seats = append(res.Seats, h.Seat{
+				Row:        row.Row,
+				Number:     seat.Number,
+				Category:   seat.Category,
+				Wheelchair: seat.Wheelchair,
+				Blocked:    seat.Blocked,
+				Aisle:      seat.Aisle,
+				Taken:      sold[position],
+				Held:       reserved[position],
+			})
+
+			if !seat.Blocked && !sold[position] && !reserved[position] {
+				res.Free++
+			}
+		}
+	}
+
+	return res
 }
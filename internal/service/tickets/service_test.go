@@ -0,0 +1,86 @@
+package tickets
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+func TestVersionMismatch(t *testing.T) {
+	start := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+	testVersionCases := []struct {
+		name   string
+		change func(s *Service, ctx context.Context) (internal.Identifiable, error)
+	}{
+		{
+			name: "cancel",
+			change: func(s *Service, ctx context.Context) (internal.Identifiable, error) {
+				return s.Cancel(1, 7, 1, ctx)
+			},
+		},
+		{
+			name: "refund",
+			change: func(s *Service, ctx context.Context) (internal.Identifiable, error) {
+				return s.Refund(1, "Projector is broken", 1, ctx)
+			},
+		},
+		{
+			name: "check in",
+			change: func(s *Service, ctx context.Context) (internal.Identifiable, error) {
+				return s.CheckIn(1, 3, 1, ctx)
+			},
+		},
+	}
+	for _, tc := range testVersionCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			s := &Service{
+				repo:  &h.Repository{DB: db, Log: logger},
+				clock: clock.NewFake(start),
+				log:   logger,
+			}
+
+			// Ticket changed since client read it, nothing is changed
+			mock.ExpectBegin()
+			mock.ExpectQuery(regexp.QuoteMeta("FROM tickets JOIN sessions ON tickets.session_id = sessions.id JOIN movies ON sessions.movie_id = movies.id WHERE tickets.id = $1 FOR UPDATE OF tickets")).
+				WithArgs(1).
+				WillReturnRows(mock.NewRows([]string{"id", "user_id", " price", "session_id", "name", "seat", "seat_row", "seat_number", "starts_at", "hall_id", "price_breakdown", "status", "refund_amount", "refund_policy", "refund_reason", "refunded_at", "promo_code", "discount", "version"}).
+					AddRow(1, 7, 12.2, 3, "Dune", 1, 1, 1, start, 2, []byte(`{"total":12.2}`), h.Paid, nil, nil, nil, nil, nil, 0, 2))
+			mock.ExpectRollback()
+
+			res, err := tc.change(s, context.Background())
+			assert.ErrorIs(t, err, internal.ErrVersionMismatch)
+			assert.Nil(t, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package tickets
+
+import (
+	"os"
+	"time"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+// transitions lists statuses ticket can move to from each status,
+// checked-in, refunded and expired tickets are final
+var transitions = map[string][]string{
+	h.Reserved: {h.Paid, h.Expired},
+	h.Paid:     {h.Issued, h.CheckedIn, h.Refunded, h.Expired},
+	h.Issued:   {h.CheckedIn, h.Refunded, h.Expired},
+}
+
+// CanMove reports if ticket can move from one status to another
+func CanMove(from string, to string) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sources returns statuses ticket can move to passed status from
+func sources(to string) []string {
+	var from []string
+
+	for _, status := range []string{h.Reserved, h.Paid, h.Issued} {
+		if CanMove(status, to) {
+			from = append(from, status)
+		}
+	}
+
+	return from
+}
+
+// Admission describes when door staff checks tickets in
+type Admission struct {
+	Opens  time.Duration // Doors open this long before session start
+	Closes time.Duration // Doors close this long after session start, unused tickets expire
+}
+
+// AdmissionWindow reads admission from CHECKIN_OPENS and CHECKIN_CLOSES environment variables
+func AdmissionWindow() Admission {
+	a := Admission{Opens: 30 * time.Minute, Closes: 30 * time.Minute}
+
+	if opens, err := time.ParseDuration(os.Getenv("CHECKIN_OPENS")); err == nil && opens >= 0 {
+		a.Opens = opens
+	}
+
+	if closes, err := time.ParseDuration(os.Getenv("CHECKIN_CLOSES")); err == nil && closes >= 0 {
+		a.Closes = closes
+	}
+
+	return a
+}
+
+// Open reports if doors of session starting at passed time are open now
+func (a Admission) Open(starts time.Time, now time.Time) bool {
+	return !now.Before(starts.Add(-a.Opens)) && now.Before(starts.Add(a.Closes))
+}
@@ -0,0 +1,59 @@
+package tickets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	h "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+)
+
+func TestCanMove(t *testing.T) {
+	testCanMoveCases := []struct {
+		name           string
+		from           string
+		to             string
+		expectedResult bool
+	}{
+		{name: "reserved ticket is paid", from: h.Reserved, to: h.Paid, expectedResult: true},
+		{name: "paid ticket is checked in", from: h.Paid, to: h.CheckedIn, expectedResult: true},
+		{name: "issued ticket is refunded", from: h.Issued, to: h.Refunded, expectedResult: true},
+		{name: "reserved ticket can't be checked in", from: h.Reserved, to: h.CheckedIn, expectedResult: false},
+		{name: "checked in ticket can't be checked in again", from: h.CheckedIn, to: h.CheckedIn, expectedResult: false},
+		{name: "checked in ticket can't be refunded", from: h.CheckedIn, to: h.Refunded, expectedResult: false},
+		{name: "refunded ticket can't be paid", from: h.Refunded, to: h.Paid, expectedResult: false},
+		{name: "expired ticket can't be checked in", from: h.Expired, to: h.CheckedIn, expectedResult: false},
+	}
+	for _, tc := range testCanMoveCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, CanMove(tc.from, tc.to))
+		})
+	}
+
+	assert.Equal(t, []string{h.Paid, h.Issued}, sources(h.CheckedIn))
+	assert.Equal(t, []string{h.Reserved, h.Paid, h.Issued}, sources(h.Expired))
+}
+
+func TestAdmissionOpen(t *testing.T) {
+	doors := Admission{Opens: 30 * time.Minute, Closes: 15 * time.Minute}
+	starts := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+	testOpenCases := []struct {
+		name           string
+		now            time.Time
+		expectedResult bool
+	}{
+		{name: "doors not open yet", now: starts.Add(-31 * time.Minute), expectedResult: false},
+		{name: "doors just opened", now: starts.Add(-30 * time.Minute), expectedResult: true},
+		{name: "session is running", now: starts.Add(10 * time.Minute), expectedResult: true},
+		{name: "doors closed", now: starts.Add(15 * time.Minute), expectedResult: false},
+	}
+	for _, tc := range testOpenCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, doors.Open(starts, tc.now))
+		})
+	}
+}
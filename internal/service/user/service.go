@@ -2,27 +2,54 @@ package users
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"fmt"
 	"regexp"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/auth"
 	h "github.com/darkjedidj/cinema-service/internal/repository/users"
+	"github.com/darkjedidj/cinema-service/package/clock"
+	tkn "github.com/darkjedidj/cinema-service/package/jwt"
 )
 
+// SessionTTL is how long user stays signed in by refreshing tokens
+const SessionTTL = 30 * 24 * time.Hour
+
 // Service is a struct to store DB and logger connection
 type Service struct {
-	repo *h.Repository
-	log  *zap.Logger
+	repo     *h.Repository
+	sessions *auth.Repository
+	clock    clock.Clock
+	log      *zap.Logger
+}
+
+// Tokens is a struct to store credentials issued to signed in user
+type Tokens struct {
+	Token         string `json:"token"`         // Access token
+	Refresh_token string `json:"refresh_token"` // Exchanged once for new tokens
+	Expires_in    int64  `json:"expires_in"`    // Seconds access token stays valid
+}
+
+// Verification is a birth date staff read from document of user
+type Verification struct {
+	Birth_date string `json:"birth_date" example:"2004-05-17"` // Date as YYYY-MM-DD
 }
 
 // Init returns Service object
 func Init(db *sql.DB, l *zap.Logger) *Service {
 
 	return &Service{
-		repo: &h.Repository{DB: db, Log: l},
-		log:  l,
+		repo:     &h.Repository{DB: db, Log: l},
+		sessions: &auth.Repository{DB: db, Log: l},
+		clock:    clock.Real{},
+		log:      l,
 	}
 }
 
@@ -37,12 +64,9 @@ func (s *Service) Create(i internal.Identifiable, ctx context.Context) error {
 		return internal.ErrInternalFailure
 	}
 
-	match, err := regexp.MatchString(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`, res.EMail)
+	err := validate(res)
 	if err != nil {
-		return internal.ErrInternalFailure
-	}
-	if !match {
-		return internal.ErrWrongEmail
+		return err
 	}
 
 	dbuser, err := s.repo.Retrieve(res.EMail, ctx)
@@ -57,17 +81,248 @@ func (s *Service) Create(i internal.Identifiable, ctx context.Context) error {
 	return s.repo.Create(res, ctx)
 }
 
+// Insert stores validated user in transaction and returns its ID, password has to be hashed already
+func (s *Service) Insert(res *h.Resource, ctx context.Context, tx *sql.Tx) (int64, error) {
+	err := validate(res)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.repo.Insert(res, ctx, tx)
+}
+
 // Retrieve logic layer for repository method
 func (s *Service) Retrieve(email string, ctx context.Context) (internal.Identifiable, error) {
 	return s.repo.Retrieve(email, ctx)
 }
 
-// RetrievePrivileges logic layer for repository method
-func (s *Service) RetrievePrivileges(id int64) ([]string, error) {
-	return s.repo.RetrievePrivileges(id)
+// RetrievePermissions logic layer for repository method
+func (s *Service) RetrievePermissions(id int64, ctx context.Context) ([]string, error) {
+	return s.repo.RetrievePermissions(id, ctx)
 }
 
 // RetrieveTickets logic layer for repository method
 func (s *Service) RetrieveTickets(ticket int64, user int64) (bool, error) {
 	return s.repo.RetrieveTickets(ticket, user)
 }
+
+// VerifyBirthDate records birth date staff checked with document of user,
+// age rated tickets are sold only to users with verified birth date
+func (s *Service) VerifyBirthDate(id int64, v *Verification, ctx context.Context) error {
+	born, err := time.Parse("2006-01-02", v.Birth_date)
+	if err != nil {
+		return fmt.Errorf("%w: birth date must be YYYY-MM-DD", internal.ErrValidationFailed)
+	}
+
+	now := s.clock.Now().UTC()
+	if born.After(now) || born.Before(now.AddDate(-130, 0, 0)) {
+		return fmt.Errorf("%w: birth date is out of range", internal.ErrValidationFailed)
+	}
+
+	found, err := s.repo.SetBirthDate(id, born, ctx)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("%w: user %d", internal.ErrNotFound, id)
+	}
+
+	return nil
+}
+
+// Login opens sign-in session of user and issues its first tokens
+func (s *Service) Login(user int64, ctx context.Context) (*Tokens, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.sessions.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	now := s.clock.Now().UTC()
+
+	session, err := s.sessions.Open(user, now, now.Add(SessionTTL), ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	res, err := s.issue(user, session, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// Refresh exchanges refresh token for new tokens of the same session. Every refresh
+// token works once, presenting used one means it leaked and revokes whole session.
+func (s *Service) Refresh(refresh string, ctx context.Context) (*Tokens, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.sessions.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	now := s.clock.Now().UTC()
+	hash := digest(refresh)
+
+	token, err := s.sessions.Lock(hash, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if token == nil || token.Revoked || !now.Before(token.Expires_at) {
+		return nil, s.rollback(tx, internal.ErrRefreshInvalid)
+	}
+
+	if token.Used {
+		s.log.Info("Refresh token reused, revoking session.",
+			zap.Int64("session", token.Session_ID),
+			zap.Int64("user", token.User_ID),
+		)
+
+		err = s.sessions.Revoke(token.Session_ID, now, ctx, tx)
+		if err != nil {
+			return nil, s.rollback(tx, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return nil, internal.ErrInternalFailure
+		}
+
+		return nil, internal.ErrRefreshReused
+	}
+
+	err = s.sessions.Use(hash, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	res, err := s.issue(token.User_ID, token.Session_ID, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return res, nil
+}
+
+// Logout revokes sign-in session, its access and refresh tokens stop working
+func (s *Service) Logout(session int64, ctx context.Context) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.sessions.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	err = s.sessions.Revoke(session, s.clock.Now().UTC(), ctx, tx)
+	if err != nil {
+		return s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return internal.ErrInternalFailure
+	}
+
+	return nil
+}
+
+// Active reports if session of verified token is still open
+func (s *Service) Active(claims *tkn.Claims, ctx context.Context) (bool, error) {
+	if claims.Session == 0 {
+		return false, nil
+	}
+
+	return s.sessions.Active(claims.Session, claims.ID, s.clock.Now().UTC(), ctx)
+}
+
+// issue signs access token and stores new refresh token of session within transaction
+func (s *Service) issue(user int64, session int64, now time.Time, ctx context.Context, tx *sql.Tx) (*Tokens, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		s.log.Info("Failed to generate refresh token.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	refresh := hex.EncodeToString(b)
+
+	err = s.sessions.Issue(digest(refresh), session, now, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := tkn.GenerateJWT(user, session)
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return &Tokens{Token: access, Refresh_token: refresh, Expires_in: int64(tkn.AccessTTL / time.Second)}, nil
+}
+
+// validate checks email of user signing up
+func validate(res *h.Resource) error {
+	match, err := regexp.MatchString(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`, res.EMail)
+	if err != nil {
+		return internal.ErrInternalFailure
+	}
+	if !match {
+		return internal.ErrWrongEmail
+	}
+
+	return nil
+}
+
+// digest returns hash refresh token is stored by, leaked table can't be used to refresh
+func digest(refresh string) string {
+	sum := sha256.Sum256([]byte(refresh))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// rollback aborts transaction and passes error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
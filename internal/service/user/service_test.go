@@ -0,0 +1,234 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	"github.com/darkjedidj/cinema-service/internal/repository/auth"
+	h "github.com/darkjedidj/cinema-service/internal/repository/users"
+	"github.com/darkjedidj/cinema-service/package/clock"
+	tkn "github.com/darkjedidj/cinema-service/package/jwt"
+)
+
+func TestRefresh(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	lock := regexp.QuoteMeta("FROM refresh_tokens JOIN auth_sessions ON refresh_tokens.session_id = auth_sessions.id WHERE refresh_tokens.token_hash = $1 FOR UPDATE")
+	columns := []string{"session_id", "user_id", "used", "expires_at", "revoked"}
+
+	testRefreshCases := []struct {
+		name          string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success, token is rotated",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(lock).
+					WithArgs(digest("old")).
+					WillReturnRows(sqlm2.NewRows(columns).AddRow(4, 7, false, now.Add(time.Hour), false))
+				sqlm2.ExpectExec(regexp.QuoteMeta("UPDATE refresh_tokens SET used_at = $1 WHERE token_hash = $2")).
+					WithArgs(now, digest("old")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectExec(regexp.QuoteMeta("INSERT INTO refresh_tokens (token_hash,session_id,created_at) VALUES ($1,$2,$3)")).
+					WithArgs(sqlmock.AnyArg(), 4, now).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:          "failed, reused token revokes session",
+			expectedError: internal.ErrRefreshReused,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(lock).
+					WithArgs(digest("old")).
+					WillReturnRows(sqlm2.NewRows(columns).AddRow(4, 7, true, now.Add(time.Hour), false))
+				sqlm2.ExpectExec(regexp.QuoteMeta("UPDATE auth_sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL")).
+					WithArgs(now, 4).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				sqlm2.ExpectCommit()
+			},
+		},
+		{
+			name:          "failed, revoked session",
+			expectedError: internal.ErrRefreshInvalid,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(lock).
+					WithArgs(digest("old")).
+					WillReturnRows(sqlm2.NewRows(columns).AddRow(4, 7, false, now.Add(time.Hour), true))
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, expired session",
+			expectedError: internal.ErrRefreshInvalid,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(lock).
+					WithArgs(digest("old")).
+					WillReturnRows(sqlm2.NewRows(columns).AddRow(4, 7, false, now, false))
+				sqlm2.ExpectRollback()
+			},
+		},
+		{
+			name:          "failed, unknown token",
+			expectedError: internal.ErrRefreshInvalid,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectBegin()
+				sqlm2.ExpectQuery(lock).
+					WithArgs(digest("old")).
+					WillReturnError(sql.ErrNoRows)
+				sqlm2.ExpectRollback()
+			},
+		},
+	}
+
+	for _, tc := range testRefreshCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			s := &Service{
+				sessions: &auth.Repository{DB: db, Log: logger},
+				clock:    clock.NewFake(now),
+				log:      logger,
+			}
+
+			tc.prepare(mock)
+
+			res, err := s.Refresh("old", context.Background())
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+
+			if tc.expectedError == nil {
+				assert.NotEqual(t, "old", res.Refresh_token)
+
+				claims, err := tkn.ParseClaims(res.Token)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(7), claims.ID)
+				assert.Equal(t, int64(4), claims.Session)
+			}
+		})
+	}
+}
+
+func TestVerifyBirthDate(t *testing.T) {
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	born := time.Date(2004, time.May, 17, 0, 0, 0, 0, time.UTC)
+	update := regexp.QuoteMeta("UPDATE users SET birth_date = $1 WHERE id = $2")
+
+	testVerifyCases := []struct {
+		name          string
+		birthDate     string
+		expectedError error
+		prepare       func(sqlm2 sqlmock.Sqlmock)
+	}{
+		{
+			name:          "success",
+			birthDate:     "2004-05-17",
+			expectedError: nil,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WithArgs(born, 7).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+		},
+		{
+			name:          "failed, unknown user",
+			birthDate:     "2004-05-17",
+			expectedError: internal.ErrNotFound,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WithArgs(born, 7).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+		{
+			name:          "failed, malformed date",
+			birthDate:     "17.05.2004",
+			expectedError: internal.ErrValidationFailed,
+			prepare:       func(sqlm2 sqlmock.Sqlmock) {},
+		},
+		{
+			name:          "failed, date in future",
+			birthDate:     "2022-04-02",
+			expectedError: internal.ErrValidationFailed,
+			prepare:       func(sqlm2 sqlmock.Sqlmock) {},
+		},
+		{
+			name:          "failed, database error",
+			birthDate:     "2004-05-17",
+			expectedError: internal.ErrInternalFailure,
+			prepare: func(sqlm2 sqlmock.Sqlmock) {
+				sqlm2.ExpectExec(update).
+					WillReturnError(fmt.Errorf("connection reset"))
+			},
+		},
+	}
+
+	for _, tc := range testVerifyCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer db.Close()
+
+			logger, err := zap.NewProduction()
+			if err != nil {
+				log.Fatalf("can't initialize zap logger: %v", err)
+			}
+
+			defer func() {
+				if err := logger.Sync(); err != nil {
+					fmt.Println(err)
+				}
+			}()
+
+			s := &Service{
+				repo:  &h.Repository{DB: db, Log: logger},
+				clock: clock.NewFake(now),
+				log:   logger,
+			}
+
+			tc.prepare(mock)
+
+			err = s.VerifyBirthDate(7, &Verification{Birth_date: tc.birthDate}, context.Background())
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
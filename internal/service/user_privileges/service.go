@@ -3,31 +3,70 @@ package user_privileges
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"go.uber.org/zap"
 
 	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/privileges"
 	h "github.com/darkjedidj/cinema-service/internal/repository/user_privileges"
 )
 
 // Service is a struct to store DB and logger connection
 type Service struct {
-	repo *h.Repository
-	log  *zap.Logger
+	repo       *h.Repository
+	privileges *p.Repository
+	log        *zap.Logger
 }
 
 // Init returns Service object
 func Init(db *sql.DB, l *zap.Logger) *Service {
 
 	return &Service{
-		repo: &h.Repository{DB: db, Log: l},
-		log:  l,
+		repo:       &h.Repository{DB: db, Log: l},
+		privileges: &p.Repository{DB: db, Log: l},
+		log:        l,
 	}
 }
 
-// Create logic layer for repository method
+// Create grants existing privilege to user
 func (s *Service) Create(r internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
-	return s.repo.Create(r, ctx)
+	res, ok := r.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert user_privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	err := s.exists(res.Privilege_id, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(res, ctx)
+}
+
+// Update logic layer for repository method
+func (s *Service) Update(id int64, r internal.Identifiable, ctx context.Context) (internal.Identifiable, error) {
+	res, ok := r.(*h.Resource)
+	if !ok {
+		s.log.Info("Failed to assert user_privilege object.",
+			zap.Bool("ok", ok),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	res.ID = id
+
+	err := s.exists(res.Privilege_id, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.Update(res, ctx)
 }
 
 // Retrieve logic layer for repository method
@@ -36,11 +75,25 @@ func (s *Service) Retrieve(id int64, ctx context.Context) (internal.Identifiable
 }
 
 // RetriveAll logic layer for repository method
-func (s *Service) RetrieveAll(ctx context.Context) ([]internal.Identifiable, error) {
-	return s.repo.RetrieveAll(ctx)
+func (s *Service) RetrieveAll(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.repo.RetrieveAll(q, ctx)
 }
 
 // Delete logic layer for repository method
 func (s *Service) Delete(id int64, ctx context.Context) error {
 	return s.repo.Delete(id, ctx)
 }
+
+// exists checks privilege is in catalog before it's granted
+func (s *Service) exists(id int64, ctx context.Context) error {
+	privilege, err := s.privileges.Retrieve(id, ctx)
+	if err != nil {
+		return err
+	}
+
+	if privilege == nil {
+		return fmt.Errorf("%w: privilege %d", internal.ErrNotFound, id)
+	}
+
+	return nil
+}
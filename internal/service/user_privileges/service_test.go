@@ -0,0 +1,45 @@
+package user_privileges
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	p "github.com/darkjedidj/cinema-service/internal/repository/privileges"
+	h "github.com/darkjedidj/cinema-service/internal/repository/user_privileges"
+)
+
+func TestCreateUnknownPrivilege(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	s := &Service{
+		repo:       &h.Repository{DB: db, Log: logger},
+		privileges: &p.Repository{DB: db, Log: logger},
+		log:        logger,
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, description FROM privileges WHERE id = $1")).
+		WithArgs(int64(42)).
+		WillReturnError(sql.ErrNoRows)
+
+	res, err := s.Create(&h.Resource{User_id: 7, Privilege_id: 42}, context.Background())
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, internal.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
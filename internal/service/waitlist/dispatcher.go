@@ -0,0 +1,67 @@
+package waitlist
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// DispatchInterval is a pause between waitlist offer rounds
+const DispatchInterval = 30 * time.Second
+
+// Dispatcher offers seats freed by expired holds and offers to waiting users in background
+type Dispatcher struct {
+	s        *Service
+	clock    clock.Clock
+	interval time.Duration
+	log      *zap.Logger
+}
+
+// NewDispatcher returns Dispatcher object
+func NewDispatcher(db *sql.DB, l *zap.Logger, c clock.Clock, interval time.Duration) *Dispatcher {
+
+	s := Init(db, l)
+	s.clock = c
+
+	return &Dispatcher{
+		s:        s,
+		clock:    c,
+		interval: interval,
+		log:      l,
+	}
+}
+
+// Run dispatches offers every interval until context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.clock.After(d.interval):
+			d.Dispatch(ctx)
+		}
+	}
+}
+
+// Dispatch runs single offer round
+func (d *Dispatcher) Dispatch(ctx context.Context) {
+	offered, err := d.s.Dispatch(ctx)
+	if err != nil {
+		d.log.Info("Failed to offer seats to waitlisted users.",
+			zap.Int64("offers", offered),
+			zap.Error(err),
+		)
+
+		return
+	}
+
+	if offered > 0 {
+		d.log.Info("Offered seats to waitlisted users.",
+			zap.Int64("offers", offered),
+		)
+	}
+}
@@ -0,0 +1,365 @@
+package waitlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/darkjedidj/cinema-service/internal"
+	hd "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	lt "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	pr "github.com/darkjedidj/cinema-service/internal/repository/pricing"
+	t "github.com/darkjedidj/cinema-service/internal/repository/tickets"
+	w "github.com/darkjedidj/cinema-service/internal/repository/waitlist"
+	"github.com/darkjedidj/cinema-service/internal/service/holds"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+// Service is a struct to store DB and logger connection
+type Service struct {
+	repo    *w.Repository
+	holds   *hd.Repository
+	layouts *lt.Repository
+	tickets *t.Repository
+	pricing *pr.Repository
+	clock   clock.Clock
+	ttl     time.Duration
+	log     *zap.Logger
+}
+
+// Init returns Service object
+func Init(db *sql.DB, l *zap.Logger) *Service {
+
+	return &Service{
+		repo:    &w.Repository{DB: db, Log: l},
+		holds:   &hd.Repository{DB: db, Log: l},
+		layouts: &lt.Repository{DB: db, Log: l},
+		tickets: &t.Repository{DB: db, Log: l},
+		pricing: &pr.Repository{DB: db, Log: l},
+		clock:   clock.Real{},
+		ttl:     OfferTTL(),
+		log:     l,
+	}
+}
+
+// OfferTTL reads how long offered seat is held from WAITLIST_OFFER_TTL environment variable
+func OfferTTL() time.Duration {
+	ttl, err := time.ParseDuration(os.Getenv("WAITLIST_OFFER_TTL"))
+	if err != nil || ttl <= 0 {
+		return 15 * time.Minute
+	}
+
+	return ttl
+}
+
+// Join puts user in queue for sold out session
+func (s *Service) Join(session int64, user int64, ctx context.Context) (internal.Identifiable, error) {
+	if session < 1 {
+		return nil, fmt.Errorf("%w: session id is required", internal.ErrValidationFailed)
+	}
+
+	now := s.clock.Now().UTC()
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	show, err := s.pricing.Show(session, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if show == nil {
+		return nil, s.rollback(tx, fmt.Errorf("%w: session does not exist", internal.ErrValidationFailed))
+	}
+
+	if !show.Starts_at.After(now) {
+		return nil, s.rollback(tx, fmt.Errorf("%w: session has already started", internal.ErrValidationFailed))
+	}
+
+	free, err := s.free(session, now, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if len(free) > 0 {
+		return nil, s.rollback(tx, fmt.Errorf("%w: session still has free seats", internal.ErrValidationFailed))
+	}
+
+	_, err = s.repo.Create(ctx, &w.Resource{Session_ID: session, User_ID: user, Created_at: now}, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	return s.Position(session, user, ctx)
+}
+
+// Position returns active entry of user for session, nil when user isn't waiting
+func (s *Service) Position(session int64, user int64, ctx context.Context) (internal.Identifiable, error) {
+	res, err := s.repo.Retrieve(session, user, ctx)
+	if err != nil || res == nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Entries returns all waitlist entries of user with their offers
+func (s *Service) Entries(user int64, ctx context.Context) ([]internal.Identifiable, error) {
+	entries, err := s.repo.RetrieveByUser(user, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]internal.Identifiable, len(entries))
+	for n, entry := range entries {
+		data[n] = entry
+	}
+
+	return data, nil
+}
+
+// Leave removes user from session queue and passes offered seat to the next user,
+// nil when user isn't waiting
+func (s *Service) Leave(session int64, user int64, ctx context.Context) (internal.Identifiable, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return nil, internal.ErrInternalFailure
+	}
+
+	entry, err := s.repo.Lock(session, user, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	if entry == nil {
+		return nil, s.rollback(tx, nil)
+	}
+
+	err = s.repo.SetStatus(entry.ID, w.Left, ctx, tx)
+	if err != nil {
+		return nil, s.rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, internal.ErrInternalFailure
+	}
+
+	entry.Status = w.Left
+
+	if entry.Hold != "" && s.holds.Delete(entry.Hold, entry.User_ID, ctx) == nil {
+		_, err = s.Offer(session, ctx)
+		if err != nil {
+			s.log.Info("Failed to offer released seat to waitlist, dispatcher retries it.",
+				zap.Int64("session", session),
+				zap.Error(err),
+			)
+		}
+	}
+
+	return entry, nil
+}
+
+// Offer holds free seats of session for the next waiting users until offer expires,
+// returns number of made offers
+func (s *Service) Offer(session int64, ctx context.Context) (int64, error) {
+	now := s.clock.Now().UTC()
+	expires := now.Add(s.ttl)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Second)
+	defer cancel()
+
+	tx, err := s.repo.DB.BeginTx(timeoutCtx, nil)
+	if err != nil {
+		s.log.Info("Failed to open transaction.",
+			zap.Error(err),
+		)
+
+		return 0, internal.ErrInternalFailure
+	}
+
+	err = s.holds.Expire(session, now, ctx, tx)
+	if err != nil {
+		return 0, s.rollback(tx, err)
+	}
+
+	free, err := s.free(session, now, ctx, tx)
+	if err != nil {
+		return 0, s.rollback(tx, err)
+	}
+
+	if len(free) == 0 {
+		return 0, s.rollback(tx, nil)
+	}
+
+	next, err := s.repo.Next(session, uint64(len(free)), ctx, tx)
+	if err != nil {
+		return 0, s.rollback(tx, err)
+	}
+
+	for n, entry := range next {
+		token, err := holds.NewToken()
+		if err != nil {
+			s.log.Info("Failed to generate hold token.",
+				zap.Error(err),
+			)
+
+			return 0, s.rollback(tx, internal.ErrInternalFailure)
+		}
+
+		seat := free[n]
+
+		err = s.holds.Create(ctx, &hd.Resource{
+			Token:      token,
+			Session_ID: session,
+			User_ID:    entry.User_ID,
+			Seats:      []hd.Seat{seat},
+			Expires_at: expires,
+		}, tx)
+		if err != nil {
+			return 0, s.rollback(tx, err)
+		}
+
+		entry.Hold = token
+		entry.Row = seat.Row
+		entry.Number = seat.Number
+		entry.Expires_at = &expires
+
+		err = s.repo.Offer(entry, ctx, tx)
+		if err != nil {
+			return 0, s.rollback(tx, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, internal.ErrInternalFailure
+	}
+
+	return int64(len(next)), nil
+}
+
+// Dispatch expires lapsed offers and offers freed seats of every session users wait for,
+// sessions which fail are logged and retried next run
+func (s *Service) Dispatch(ctx context.Context) (int64, error) {
+	_, err := s.repo.Lapse(s.clock.Now().UTC(), ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sessions, err := s.repo.Sessions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var offered int64
+
+	for _, session := range sessions {
+		n, err := s.Offer(session, ctx)
+		if errors.Is(err, internal.ErrSeatTaken) {
+			// Seat was held by customer meanwhile, next run retries
+			continue
+		}
+
+		if err != nil {
+			// One broken session doesn't hold back offers of others
+			s.log.Info("Failed to offer seats of session.",
+				zap.Int64("session", session),
+				zap.Error(err),
+			)
+
+			continue
+		}
+
+		offered += n
+	}
+
+	return offered, nil
+}
+
+// free returns seats of session which are neither sold nor held
+func (s *Service) free(session int64, now time.Time, ctx context.Context, tx *sql.Tx) ([]hd.Seat, error) {
+	layout, err := s.layouts.RetrieveBySession(session, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if layout == nil {
+		return nil, nil
+	}
+
+	taken, err := s.tickets.TakenSeats(session, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	held, err := s.holds.HeldSeats(session, now, ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return freeSeats(layout, append(taken, held...)), nil
+}
+
+// rollback aborts transaction and passes original error through
+func (s *Service) rollback(tx *sql.Tx, err error) error {
+	rbErr := tx.Rollback()
+	if rbErr != nil {
+		s.log.Info("Failed to rollback transaction.",
+			zap.Error(rbErr),
+		)
+
+		return internal.ErrInternalFailure
+	}
+
+	return err
+}
+
+// freeSeats lists unblocked seats of layout missing from busy positions in layout order
+func freeSeats(layout *lt.Resource, busy []int64) []hd.Seat {
+	taken := make(map[int64]bool, len(busy))
+	for _, b := range busy {
+		taken[b] = true
+	}
+
+	var free []hd.Seat
+
+	var position int64
+	for _, row := range layout.Rows {
+		for _, seat := range row.Seats {
+			position++
+
+			if !seat.Blocked && !taken[position] {
+				free = append(free, hd.Seat{Row: row.Row, Number: seat.Number, Seat: position})
+			}
+		}
+	}
+
+	return free
+}
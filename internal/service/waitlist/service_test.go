@@ -0,0 +1,91 @@
+package waitlist
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	hd "github.com/darkjedidj/cinema-service/internal/repository/holds"
+	lt "github.com/darkjedidj/cinema-service/internal/repository/layouts"
+	w "github.com/darkjedidj/cinema-service/internal/repository/waitlist"
+	"github.com/darkjedidj/cinema-service/package/clock"
+)
+
+func TestFreeSeats(t *testing.T) {
+	layout := lt.Grid(1, 2, 6)
+	layout.Rows[0].Seats[1].Blocked = true
+
+	assert.Equal(t, []hd.Seat{
+		{Row: 1, Number: 3, Seat: 3},
+		{Row: 2, Number: 2, Seat: 5},
+	}, freeSeats(layout, []int64{1, 4, 6}))
+
+	assert.Empty(t, freeSeats(layout, []int64{1, 3, 4, 5, 6}))
+}
+
+func TestDispatch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	logger, err := zap.NewProduction()
+	if err != nil {
+		log.Fatalf("can't initialize zap logger: %v", err)
+	}
+
+	defer func() {
+		if err := logger.Sync(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+
+	s := &Service{
+		repo:    &w.Repository{DB: db, Log: logger},
+		holds:   &hd.Repository{DB: db, Log: logger},
+		layouts: &lt.Repository{DB: db, Log: logger},
+		clock:   clock.NewFake(now),
+		ttl:     15 * time.Minute,
+		log:     logger,
+	}
+
+	expire := regexp.QuoteMeta("DELETE FROM seat_holds WHERE session_id = $1 AND expires_at <= $2")
+
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE waitlist SET status = $1")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DISTINCT session_id FROM waitlist WHERE status = $1 ORDER BY session_id")).
+		WithArgs(w.Waiting).
+		WillReturnRows(mock.NewRows([]string{"session_id"}).AddRow(1).AddRow(2))
+
+	// First session fails, it is logged and retried next run
+	mock.ExpectBegin()
+	mock.ExpectExec(expire).
+		WithArgs(1, now).
+		WillReturnError(fmt.Errorf("deadlock detected"))
+	mock.ExpectRollback()
+
+	// Next session is still offered
+	mock.ExpectBegin()
+	mock.ExpectExec(expire).
+		WithArgs(2, now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT halls.id, halls.rows, halls.seats FROM sessions JOIN halls ON sessions.hall_id = halls.id WHERE sessions.id = $1 FOR SHARE OF halls")).
+		WithArgs(2).
+		WillReturnRows(mock.NewRows([]string{"id", "rows", "seats"}))
+	mock.ExpectRollback()
+
+	offered, err := s.Dispatch(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), offered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
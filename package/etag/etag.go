@@ -0,0 +1,62 @@
+// Package etag builds entity tags from resource versions and evaluates
+// conditional request headers.
+//
+// Version of a resource grows with every change stored, so tag of the same
+// resource is never reused and strong comparison is safe.
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Versioned is implemented by resources counting their changes
+type Versioned interface {
+	Revision() int64
+}
+
+// Of returns strong entity tag of resource, empty when resource is missing or not versioned
+func Of(resource interface{}) string {
+	v, ok := resource.(Versioned)
+	if !ok {
+		return ""
+	}
+
+	return `"` + strconv.FormatInt(v.Revision(), 10) + `"`
+}
+
+// Match reports whether tag satisfies If-Match header. Asterisk matches any
+// existing resource, weak tags never match.
+func Match(header string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Fresh reports whether If-None-Match header lists tag, so copy client holds
+// is current and read may be answered with 304. Weak tags are compared as strong ones.
+func Fresh(header string, tag string) bool {
+	if tag == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+package etag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type resource struct {
+	version int64
+}
+
+func (r *resource) Revision() int64 {
+	return r.version
+}
+
+func TestOf(t *testing.T) {
+	assert.Equal(t, `"3"`, Of(&resource{version: 3}))
+	assert.Equal(t, "", Of(nil))
+	assert.Equal(t, "", Of("not versioned"))
+}
+
+func TestMatch(t *testing.T) {
+	testMatchCases := []struct {
+		name           string
+		header         string
+		tag            string
+		expectedResult bool
+	}{
+		{name: "same tag", header: `"3"`, tag: `"3"`, expectedResult: true},
+		{name: "tag in list", header: `"2", "3"`, tag: `"3"`, expectedResult: true},
+		{name: "any existing", header: "*", tag: `"3"`, expectedResult: true},
+		{name: "stale tag", header: `"2"`, tag: `"3"`, expectedResult: false},
+		{name: "weak tag", header: `W/"3"`, tag: `"3"`, expectedResult: false},
+		{name: "missing resource", header: "*", tag: "", expectedResult: false},
+	}
+
+	for _, tc := range testMatchCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, Match(tc.header, tc.tag))
+		})
+	}
+}
+
+func TestFresh(t *testing.T) {
+	testFreshCases := []struct {
+		name           string
+		header         string
+		tag            string
+		expectedResult bool
+	}{
+		{name: "same tag", header: `"3"`, tag: `"3"`, expectedResult: true},
+		{name: "weak tag", header: `W/"3"`, tag: `"3"`, expectedResult: true},
+		{name: "any existing", header: "*", tag: `"3"`, expectedResult: true},
+		{name: "changed resource", header: `"2"`, tag: `"3"`, expectedResult: false},
+		{name: "no header", header: "", tag: `"3"`, expectedResult: false},
+	}
+
+	for _, tc := range testFreshCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, Fresh(tc.header, tc.tag))
+		})
+	}
+}
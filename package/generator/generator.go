@@ -35,14 +35,14 @@ func Init(db *sql.DB, l *zap.Logger) *Client {
 	}
 }
 
-// GetTicket creates PDF file, stores it in S3 and returns ID
-func (c *Client) GetTicket(ctx context.Context, id int64) (*Link, error) {
+// GetTicket creates PDF file with signed ticket code, stores it in S3 and returns ID
+func (c *Client) GetTicket(ctx context.Context, id int64, code string) (*Link, error) {
 
 	session := cloud.AwsService.GetSession()
 
 	S3BucketName := os.Getenv("BUCKET_NAME")
 
-	ticket, err := c.gen.CreatePDF(id, ctx)
+	ticket, err := c.gen.CreatePDF(id, code, ctx)
 	if err != nil {
 		c.log.Info("Failed to assert ticket object.",
 			zap.Error(err),
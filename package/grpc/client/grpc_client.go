@@ -31,7 +31,7 @@ func Init(db *sql.DB, l *zap.Logger) *Client {
 }
 
 // CreatePDF implements proto.NewTicketGeneratorServer
-func (c *Client) CreatePDF(id int64, ctx context.Context) (int64, error) {
+func (c *Client) CreatePDF(id int64, code string, ctx context.Context) (int64, error) {
 	conn, err := grpc.Dial(port, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
 		log.Fatalf("did not connect: %v", err)
@@ -57,7 +57,7 @@ func (c *Client) CreatePDF(id int64, ctx context.Context) (int64, error) {
 		return 0, internal.ErrInternalFailure
 	}
 
-	result, err := client.GetTicket(ctx, &pb.TicketRequset{Time: res.Starts_at, Price: float32(res.Price), Seat: res.Seat, Id: res.ID, Title: res.Title})
+	result, err := client.GetTicket(ctx, &pb.TicketRequset{Time: res.Starts_at, Price: float32(res.Price), Seat: res.Seat, Id: res.ID, Title: res.Title, Code: code})
 	if err != nil {
 		c.Log.Info("Failed to assert ticket object.",
 			zap.Error(err),
@@ -0,0 +1,240 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// minRSABits is the smallest RSA key accepted for signing or verification
+const minRSABits = 2048
+
+// DefaultIssuer is issuer and audience of tokens unless configured otherwise
+const DefaultIssuer = "cinema-service"
+
+// ErrNoKeys is returned when signing keys aren't configured or can't be read
+var ErrNoKeys = errors.New("access token keys are not configured")
+
+// Key is a struct to store key tokens are signed or verified with
+type Key struct {
+	ID      string // Key id, "kid" header of tokens signed with it
+	Method  jwt.SigningMethod
+	Private interface{} // *rsa.PrivateKey or ed25519.PrivateKey, nil for keys kept only to verify old tokens
+	Public  interface{} // *rsa.PublicKey or ed25519.PublicKey
+}
+
+// Keyring is a struct to store keys tokens are verified with and the one new tokens are signed with
+type Keyring struct {
+	Issuer   string // "iss" claim of issued tokens, required when verifying
+	Audience string // "aud" claim of issued tokens, required when verifying
+	signing  *Key
+	keys     map[string]*Key
+}
+
+// NewKeyring returns keyring signing tokens with key of passed id and verifying them with any of keys
+func NewKeyring(issuer string, audience string, signing string, keys ...*Key) (*Keyring, error) {
+	k := &Keyring{Issuer: issuer, Audience: audience, keys: map[string]*Key{}}
+
+	for _, key := range keys {
+		if _, ok := k.keys[key.ID]; ok {
+			return nil, fmt.Errorf("%w: key %q is listed twice", ErrNoKeys, key.ID)
+		}
+
+		k.keys[key.ID] = key
+	}
+
+	k.signing = k.keys[signing]
+	if k.signing == nil || k.signing.Private == nil {
+		return nil, fmt.Errorf("%w: no private key %q to sign tokens with", ErrNoKeys, signing)
+	}
+
+	return k, nil
+}
+
+// NewKey returns key for RSA or Ed25519 private or public key, private keys sign tokens too
+func NewKey(id string, key interface{}) (*Key, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: RSA key %q is shorter than %d bits", ErrNoKeys, id, minRSABits)
+		}
+
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Private: key, Public: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("%w: RSA key %q is shorter than %d bits", ErrNoKeys, id, minRSABits)
+		}
+
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Public: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: SigningMethodEdDSA, Private: key, Public: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{ID: id, Method: SigningMethodEdDSA, Public: key}, nil
+	}
+
+	return nil, fmt.Errorf("%w: key %q is neither RSA nor Ed25519", ErrNoKeys, id)
+}
+
+// LoadKeys reads PEM encoded keys from *.pem files of directory, file name without extension is key id.
+// PKCS#8 or PKCS#1 private keys sign and verify tokens, PKIX public keys only verify them.
+func LoadKeys(dir string) ([]*Key, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoKeys, err)
+	}
+
+	sort.Strings(paths)
+
+	var keys []*Key
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNoKeys, err)
+		}
+
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("%w: %s is not PEM encoded", ErrNoKeys, path)
+		}
+
+		var parsed interface{}
+
+		switch block.Type {
+		case "PRIVATE KEY":
+			parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case "RSA PRIVATE KEY":
+			parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case "PUBLIC KEY":
+			parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+		default:
+			err = fmt.Errorf("unsupported PEM block %q", block.Type)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrNoKeys, path, err)
+		}
+
+		key, err := NewKey(id, parsed)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no *.pem files in %q", ErrNoKeys, dir)
+	}
+
+	return keys, nil
+}
+
+// KeysFromEnv loads keyring from JWT_KEYS_DIR directory. New tokens are signed with JWT_SIGNING_KID key,
+// or private key with the last id in sort order when it isn't set. JWT_ISSUER and JWT_AUDIENCE
+// override DefaultIssuer in "iss" and "aud" claims.
+func KeysFromEnv() (*Keyring, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		return nil, fmt.Errorf("%w: JWT_KEYS_DIR is not set", ErrNoKeys)
+	}
+
+	keys, err := LoadKeys(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	signing := os.Getenv("JWT_SIGNING_KID")
+	if signing == "" {
+		for _, key := range keys {
+			if key.Private != nil {
+				signing = key.ID
+			}
+		}
+	}
+
+	return NewKeyring(env("JWT_ISSUER", DefaultIssuer), env("JWT_AUDIENCE", DefaultIssuer), signing, keys...)
+}
+
+// Ephemeral returns keyring with Ed25519 key generated in memory, its tokens don't survive restart
+func Ephemeral() (*Keyring, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := NewKey("ephemeral", private)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKeyring(DefaultIssuer, DefaultIssuer, key.ID, key)
+}
+
+// JWK is a struct to store public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv,omitempty"` // Ed25519 curve
+	X   string `json:"x,omitempty"`   // Ed25519 public key
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA public exponent
+}
+
+// JWKS is a struct to store public keys tokens can be verified with
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns public keys of keyring in key id order
+func (k *Keyring) JWKS() *JWKS {
+	res := &JWKS{Keys: []JWK{}}
+
+	for _, key := range k.keys {
+		jwk := JWK{Kid: key.ID, Alg: key.Method.Alg(), Use: "sig"}
+
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		res.Keys = append(res.Keys, jwk)
+	}
+
+	sort.Slice(res.Keys, func(i, j int) bool {
+		return res.Keys[i].Kid < res.Keys[j].Kid
+	})
+
+	return res
+}
+
+// env returns environment variable or fallback when it's empty
+func env(name string, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	return fallback
+}
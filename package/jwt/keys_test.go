@@ -0,0 +1,224 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKeys generates RSA and Ed25519 keys for tests
+func testKeys(t *testing.T) (*rsa.PrivateKey, ed25519.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, minRSABits)
+	require.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return rsaKey, edKey
+}
+
+// claims returns claims of user session expiring after ttl
+func claims(ttl time.Duration) *Claims {
+	c := &Claims{ID: 7, Session: 4}
+	c.ExpiresAt = time.Now().Add(ttl).Unix()
+
+	return c
+}
+
+func TestSignParse(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+
+	for _, private := range []interface{}{rsaKey, edKey} {
+		key, err := NewKey("k1", private)
+		require.NoError(t, err)
+
+		ring, err := NewKeyring("cinema", "cinema", "k1", key)
+		require.NoError(t, err)
+
+		token, err := ring.Sign(claims(time.Hour))
+		require.NoError(t, err)
+
+		res, err := ring.Parse(token)
+		require.NoError(t, err, key.Method.Alg())
+
+		assert.Equal(t, int64(7), res.ID)
+		assert.Equal(t, int64(4), res.Session)
+		assert.Equal(t, "cinema", res.Issuer)
+		assert.Equal(t, "cinema", res.Audience)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+
+	old, err := NewKey("2026-01", rsaKey)
+	require.NoError(t, err)
+
+	current, err := NewKey("2026-02", edKey)
+	require.NoError(t, err)
+
+	before, err := NewKeyring(DefaultIssuer, DefaultIssuer, "2026-01", old)
+	require.NoError(t, err)
+
+	token, err := before.Sign(claims(time.Hour))
+	require.NoError(t, err)
+
+	// Old key stays to verify tokens issued before rotation, new tokens use the new one
+	retired, err := NewKey("2026-01", &rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	after, err := NewKeyring(DefaultIssuer, DefaultIssuer, "2026-02", retired, current)
+	require.NoError(t, err)
+
+	_, err = after.Parse(token)
+	assert.NoError(t, err)
+
+	fresh, err := after.Sign(claims(time.Hour))
+	require.NoError(t, err)
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(fresh, &Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	// Public key alone can't sign
+	_, err = NewKeyring(DefaultIssuer, DefaultIssuer, "2026-01", retired, current)
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
+
+func TestParseRejects(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+
+	key, err := NewKey("k1", rsaKey)
+	require.NoError(t, err)
+
+	ring, err := NewKeyring(DefaultIssuer, DefaultIssuer, "k1", key)
+	require.NoError(t, err)
+
+	other, err := NewKeyring("someone-else", DefaultIssuer, "k1", key)
+	require.NoError(t, err)
+
+	stranger, err := NewKeyring(DefaultIssuer, "someone-else", "k1", key)
+	require.NoError(t, err)
+
+	edOnly, err := NewKey("k1", edKey)
+	require.NoError(t, err)
+
+	forged, err := NewKeyring(DefaultIssuer, DefaultIssuer, "k1", edOnly)
+	require.NoError(t, err)
+
+	sign := func(ring *Keyring, c *Claims) string {
+		token, err := ring.Sign(c)
+		require.NoError(t, err)
+
+		return token
+	}
+
+	// Public key used as HMAC secret must not verify, that's the classic algorithm confusion attack
+	public, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, claims(time.Hour))
+	hmac.Header["kid"] = "k1"
+	confused, err := hmac.SignedString(public)
+	require.NoError(t, err)
+
+	none := jwt.NewWithClaims(jwt.SigningMethodNone, claims(time.Hour))
+	none.Header["kid"] = "k1"
+	unsigned, err := none.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	noExpiry := claims(0)
+	noExpiry.ExpiresAt = 0
+
+	unknownKid := jwt.NewWithClaims(jwt.SigningMethodRS256, claims(time.Hour))
+	unknownKid.Header["kid"] = "k2"
+	unknown, err := unknownKid.SignedString(rsaKey)
+	require.NoError(t, err)
+
+	testParseCases := map[string]string{
+		"expired":            sign(ring, claims(-time.Hour)),
+		"without expiry":     sign(ring, noExpiry),
+		"wrong issuer":       sign(other, claims(time.Hour)),
+		"wrong audience":     sign(stranger, claims(time.Hour)),
+		"other algorithm":    sign(forged, claims(time.Hour)),
+		"hmac with public":   confused,
+		"unsigned":           unsigned,
+		"unknown key id":     unknown,
+		"malformed":          "not-a-token",
+		"without user":       sign(ring, &Claims{StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}}),
+		"tampered signature": sign(ring, claims(time.Hour)) + "x",
+	}
+
+	for name, token := range testParseCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := ring.Parse(token)
+			assert.Equal(t, ErrInvalidToken, err)
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	rsaKey, edKey := testKeys(t)
+	dir := t.TempDir()
+
+	write := func(name string, kind string, der []byte) {
+		data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	require.NoError(t, err)
+
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	write("2026-01.pem", "PUBLIC KEY", rsaPublic)
+	write("2026-02.pem", "PRIVATE KEY", edDER)
+	write("notes.txt", "PRIVATE KEY", edDER)
+
+	os.Setenv("JWT_KEYS_DIR", dir)
+	defer os.Unsetenv("JWT_KEYS_DIR")
+
+	ring, err := KeysFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, "2026-02", ring.signing.ID)
+	assert.Len(t, ring.keys, 2)
+
+	jwks := ring.JWKS()
+	require.Len(t, jwks.Keys, 2)
+
+	assert.Equal(t, "2026-01", jwks.Keys[0].Kid)
+	assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+	assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+	assert.Equal(t, "AQAB", jwks.Keys[0].E)
+
+	assert.Equal(t, "2026-02", jwks.Keys[1].Kid)
+	assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+	assert.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+	assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+
+	write("2026-03.pem", "PRIVATE KEY", []byte("garbage"))
+
+	_, err = KeysFromEnv()
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
+
+func TestNewKeyShortRSA(t *testing.T) {
+	short, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	_, err = NewKey("weak", short)
+	assert.ErrorIs(t, err, ErrNoKeys)
+}
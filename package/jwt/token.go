@@ -1,33 +1,76 @@
 package token
 
 import (
+	"errors"
 	"log"
-	"os"
+	"sync"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
 type Claims struct {
-	ID int64 `json:"ID"`
+	ID      int64 `json:"ID"`
+	Session int64 `json:"sid"` // Sign-in session token belongs to, revoked sessions reject their tokens
 	jwt.StandardClaims
 }
 
-var key = []byte(os.Getenv("ACCESS_SECRET"))
+// AccessTTL is how long access token stays valid, refresh token prolongs access after that
+const AccessTTL = 15 * time.Minute
 
-// GenerateJWT for user
-func GenerateJWT(id int64) (string, error) {
+// ErrInvalidToken is returned for expired or forged tokens
+var ErrInvalidToken = errors.New("invalid access token")
 
-	expirationTime := time.Now().Add(2 * time.Hour)
+var (
+	mu   sync.RWMutex
+	keys *Keyring // Signs and verifies tokens, ephemeral until SetKeys is called
+)
+
+// SetKeys replaces keyring tokens are signed and verified with
+func SetKeys(k *Keyring) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	keys = k
+}
+
+// Keys returns keyring tokens are signed and verified with
+func Keys() *Keyring {
+	mu.RLock()
+	k := keys
+	mu.RUnlock()
+
+	if k != nil {
+		return k
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if keys == nil {
+		ephemeral, err := Ephemeral()
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		keys = ephemeral
+	}
+
+	return keys
+}
+
+// GenerateJWT for user signed in within session
+func GenerateJWT(id int64, session int64) (string, error) {
+
+	expirationTime := time.Now().Add(AccessTTL)
 
 	atClaims := &Claims{}
 	atClaims.ID = id
+	atClaims.Session = session
 
 	atClaims.StandardClaims.ExpiresAt = expirationTime.Unix()
 
-	at := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-
-	AccessToken, err := at.SignedString(key)
+	AccessToken, err := Keys().Sign(atClaims)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -35,13 +78,47 @@ func GenerateJWT(id int64) (string, error) {
 	return AccessToken, nil
 }
 
-// VerifyToken params
-func VerifyToken(tokenString string) (jwt.Claims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return key, nil
+// ParseClaims verifies token signature, algorithm, expiration, issuer and audience and returns its claims
+func ParseClaims(tokenString string) (*Claims, error) {
+	return Keys().Parse(tokenString)
+}
+
+// Sign issues token with claims signed by signing key, issuer and audience of keyring are set on claims
+func (k *Keyring) Sign(claims *Claims) (string, error) {
+	claims.Issuer = k.Issuer
+	claims.Audience = k.Audience
+	claims.IssuedAt = time.Now().Unix()
+
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.ID
+
+	return token.SignedString(k.signing.Private)
+}
+
+// Parse verifies token with key named by its "kid" header and returns its claims. Token must be
+// signed with algorithm of that key, expire, and be issued by and for this keyring.
+func (k *Keyring) Parse(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+
+	parser := &jwt.Parser{ValidMethods: []string{jwt.SigningMethodRS256.Alg(), SigningMethodEdDSA.Alg()}}
+
+	token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		id, _ := token.Header["kid"].(string)
+
+		key, ok := k.keys[id]
+		if !ok || token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrInvalidToken
+		}
+
+		return key.Public, nil
 	})
-	if err != nil {
-		return nil, err
+	if err != nil || !token.Valid || claims.ID == 0 {
+		return nil, ErrInvalidToken
 	}
-	return token.Claims, err
+
+	if claims.ExpiresAt == 0 || !claims.VerifyIssuer(k.Issuer, true) || !claims.VerifyAudience(k.Audience, true) {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
 }
@@ -0,0 +1,134 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeName is a PAYMENT_PROVIDER value of fake provider
+const FakeName = "fake"
+
+// fakePrefix starts every fake payment reference
+const fakePrefix = "fake_"
+
+// Fake is a payment provider for local runs and tests, it moves no money.
+// Webhooks are signed with HMAC-SHA256 of payload, hex encoded.
+type Fake struct {
+	Async   bool // Leaves payments pending until authorized webhook arrives
+	Decline bool // Declines every authorization
+
+	secret []byte
+	mu     sync.Mutex
+	calls  []string
+}
+
+// NewFake returns Fake provider signing webhooks with secret
+func NewFake(secret []byte) *Fake {
+	return &Fake{secret: secret}
+}
+
+// Name identifies fake provider
+func (f *Fake) Name() string {
+	return FakeName
+}
+
+// Authorize accepts any positive amount unless provider declines everything
+func (f *Fake) Authorize(_ context.Context, intent *Intent) (*Authorization, error) {
+	f.record("authorize %d %.2f", intent.Order_ID, intent.Amount)
+
+	if f.Decline || intent.Amount <= 0 {
+		return nil, ErrDeclined
+	}
+
+	ref := make([]byte, 8)
+
+	_, err := rand.Read(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Authorization{Reference: fakePrefix + hex.EncodeToString(ref), Status: Authorized}
+	if f.Async {
+		res.Status = Pending
+	}
+
+	return res, nil
+}
+
+// Capture accepts any fake payment
+func (f *Fake) Capture(_ context.Context, reference string, amount float64) error {
+	f.record("capture %s %.2f", reference, amount)
+
+	return f.check(reference, amount)
+}
+
+// Refund accepts any fake payment
+func (f *Fake) Refund(_ context.Context, reference string, amount float64) error {
+	f.record("refund %s %.2f", reference, amount)
+
+	return f.check(reference, amount)
+}
+
+// VerifyWebhook checks payload signature, rejects everything without secret
+func (f *Fake) VerifyWebhook(payload []byte, signature string) (*Event, error) {
+	if len(f.secret) == 0 || !hmac.Equal([]byte(f.sign(payload)), []byte(signature)) {
+		return nil, ErrSignature
+	}
+
+	var event Event
+
+	err := json.Unmarshal(payload, &event)
+	if err != nil || event.ID == "" || event.Reference == "" {
+		return nil, ErrSignature
+	}
+
+	return &event, nil
+}
+
+// Webhook builds signed callback payload, the way provider would send it
+func (f *Fake) Webhook(id string, kind string, reference string) ([]byte, string) {
+	payload, _ := json.Marshal(&Event{ID: id, Type: kind, Reference: reference, Created: time.Now().UTC()})
+
+	return payload, f.sign(payload)
+}
+
+// Calls returns operations made with provider
+func (f *Fake) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.calls...)
+}
+
+// sign returns hex encoded HMAC-SHA256 of payload
+func (f *Fake) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// check accepts only fake references and positive amounts
+func (f *Fake) check(reference string, amount float64) error {
+	if !strings.HasPrefix(reference, fakePrefix) || amount <= 0 {
+		return ErrDeclined
+	}
+
+	return nil
+}
+
+// record stores operation for tests
+func (f *Fake) record(format string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,77 @@
+package payment
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFake(t *testing.T) {
+	ctx := context.Background()
+
+	f := NewFake([]byte("secret"))
+	f.Async = true
+
+	auth, err := f.Authorize(ctx, &Intent{Order_ID: 1, Amount: 24.4})
+	assert.NoError(t, err)
+	assert.Equal(t, Pending, auth.Status)
+	assert.True(t, strings.HasPrefix(auth.Reference, fakePrefix))
+
+	payload, signature := f.Webhook("evt_1", EventAuthorized, auth.Reference)
+
+	event, err := f.VerifyWebhook(payload, signature)
+	assert.NoError(t, err)
+	assert.Equal(t, "evt_1", event.ID)
+	assert.Equal(t, EventAuthorized, event.Type)
+	assert.Equal(t, auth.Reference, event.Reference)
+
+	_, err = f.VerifyWebhook(payload, strings.Repeat("0", len(signature)))
+	assert.Equal(t, ErrSignature, err)
+
+	_, err = NewFake(nil).VerifyWebhook(payload, signature)
+	assert.Equal(t, ErrSignature, err)
+
+	assert.NoError(t, f.Capture(ctx, auth.Reference, 24.4))
+	assert.NoError(t, f.Refund(ctx, auth.Reference, 12.2))
+	assert.Equal(t, ErrDeclined, f.Refund(ctx, "unknown", 12.2))
+
+	f.Decline = true
+
+	_, err = f.Authorize(ctx, &Intent{Order_ID: 2, Amount: 10})
+	assert.Equal(t, ErrDeclined, err)
+
+	assert.Equal(t, []string{
+		"authorize 1 24.40",
+		"capture " + auth.Reference + " 24.40",
+		"refund " + auth.Reference + " 12.20",
+		"refund unknown 12.20",
+		"authorize 2 10.00",
+	}, f.Calls())
+}
+
+func TestFromEnv(t *testing.T) {
+	testFromEnvCases := []struct {
+		name          string
+		provider      string
+		expectedError error
+	}{
+		{name: "failure: unset", provider: "", expectedError: ErrUnknownProvider},
+		{name: "failure: unknown", provider: "stripe", expectedError: ErrUnknownProvider},
+		{name: "success: fake", provider: FakeName},
+	}
+
+	for _, tc := range testFromEnvCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("PAYMENT_PROVIDER", tc.provider)
+
+			provider, err := FromEnv()
+			assert.Equal(t, tc.expectedError, err)
+
+			if tc.expectedError == nil {
+				assert.Equal(t, FakeName, provider.Name())
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+// Package payment describes payment providers orders are paid with.
+package payment
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// Payment statuses
+const (
+	Pending    = "pending"    // Customer hasn't confirmed payment yet
+	Authorized = "authorized" // Money is reserved on customer account
+	Captured   = "captured"   // Money is charged
+	Failed     = "failed"
+	Refunded   = "refunded" // Captured money is returned in full
+)
+
+// Webhook event types
+const (
+	EventAuthorized = "payment.authorized"
+	EventCaptured   = "payment.captured"
+	EventFailed     = "payment.failed"
+)
+
+var (
+	// ErrDeclined is returned when provider declines operation
+	ErrDeclined = errors.New("payment is declined")
+
+	// ErrSignature is returned for webhooks with forged or missing signature
+	ErrSignature = errors.New("invalid webhook signature")
+
+	// ErrUnknownProvider is returned for unsupported PAYMENT_PROVIDER values
+	ErrUnknownProvider = errors.New("unknown payment provider")
+)
+
+// Intent is a struct to store payment requested for order
+type Intent struct {
+	Order_ID int64
+	Amount   float64
+}
+
+// Authorization is a struct to store provider answer on payment intent
+type Authorization struct {
+	Reference string // Payment id on provider side
+	Status    string // Authorized, or Pending until webhook arrives
+}
+
+// Event is a struct to store verified webhook callback
+type Event struct {
+	ID        string    `json:"id"`        // Unique id, repeated deliveries share it
+	Type      string    `json:"type"`      // One of Event* types
+	Reference string    `json:"reference"` // Payment id on provider side
+	Created   time.Time `json:"created"`
+}
+
+// PaymentProvider charges customers for orders
+type PaymentProvider interface {
+	// Name identifies provider in stored payments
+	Name() string
+	// Authorize reserves intent amount on customer account
+	Authorize(ctx context.Context, intent *Intent) (*Authorization, error)
+	// Capture charges authorized payment
+	Capture(ctx context.Context, reference string, amount float64) error
+	// Refund returns part or all of captured amount
+	Refund(ctx context.Context, reference string, amount float64) error
+	// VerifyWebhook checks callback signature and decodes its event
+	VerifyWebhook(payload []byte, signature string) (*Event, error)
+}
+
+// FromEnv returns provider named by PAYMENT_PROVIDER environment variable,
+// fake provider moves no money and is used only when named explicitly
+func FromEnv() (PaymentProvider, error) {
+	switch os.Getenv("PAYMENT_PROVIDER") {
+	case FakeName:
+		return NewFake([]byte(os.Getenv("PAYMENT_WEBHOOK_SECRET"))), nil
+	default:
+		return nil, ErrUnknownProvider
+	}
+}
@@ -0,0 +1,132 @@
+// Package qr signs ticket validation codes and verifies them offline.
+//
+// Code is a base64url string of version byte, varint encoded ticket id,
+// session id, seat and expiry unix time followed by Ed25519 signature,
+// so door scanners need only public key to check tickets.
+package qr
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// version of code layout, first byte of every code
+const version = 1
+
+var (
+	// ErrMalformed is returned for codes which can't be decoded
+	ErrMalformed = errors.New("malformed ticket code")
+
+	// ErrSignature is returned for forged or damaged codes
+	ErrSignature = errors.New("invalid ticket code signature")
+
+	// ErrExpired is returned for codes used after expiry
+	ErrExpired = errors.New("ticket code is expired")
+
+	// ErrNoKey is returned when signing key isn't configured
+	ErrNoKey = errors.New("ticket signing key is not configured")
+)
+
+// Payload is a struct to store signed ticket data
+type Payload struct {
+	Ticket_ID  int64     `json:"ticket_id"`
+	Session_ID int64     `json:"session_id"`
+	Seat       int64     `json:"seat"`
+	Expires_at time.Time `json:"expires_at"`
+}
+
+// Sign encodes payload and signs it with private key
+func Sign(p *Payload, key ed25519.PrivateKey) string {
+	msg := make([]byte, 1+4*binary.MaxVarintLen64)
+	msg[0] = version
+
+	n := 1
+	n += binary.PutUvarint(msg[n:], uint64(p.Ticket_ID))
+	n += binary.PutUvarint(msg[n:], uint64(p.Session_ID))
+	n += binary.PutUvarint(msg[n:], uint64(p.Seat))
+	n += binary.PutVarint(msg[n:], p.Expires_at.Unix())
+	msg = msg[:n]
+
+	return base64.RawURLEncoding.EncodeToString(append(msg, ed25519.Sign(key, msg)...))
+}
+
+// Verify checks code signature with public key and returns payload unless it's expired by now
+func Verify(code string, key ed25519.PublicKey, now time.Time) (*Payload, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	if err != nil || len(raw) <= ed25519.SignatureSize || raw[0] != version {
+		return nil, ErrMalformed
+	}
+
+	msg, sig := raw[:len(raw)-ed25519.SignatureSize], raw[len(raw)-ed25519.SignatureSize:]
+	if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, msg, sig) {
+		return nil, ErrSignature
+	}
+
+	fields := make([]int64, 4)
+	rest := msg[1:]
+
+	for i := range fields {
+		var n int
+
+		if i < len(fields)-1 {
+			var v uint64
+			v, n = binary.Uvarint(rest)
+			fields[i] = int64(v)
+		} else {
+			fields[i], n = binary.Varint(rest)
+		}
+
+		if n <= 0 {
+			return nil, ErrMalformed
+		}
+
+		rest = rest[n:]
+	}
+
+	if len(rest) != 0 {
+		return nil, ErrMalformed
+	}
+
+	p := &Payload{
+		Ticket_ID:  fields[0],
+		Session_ID: fields[1],
+		Seat:       fields[2],
+		Expires_at: time.Unix(fields[3], 0).UTC(),
+	}
+
+	if !now.Before(p.Expires_at) {
+		return p, ErrExpired
+	}
+
+	return p, nil
+}
+
+// KeyFromEnv reads private key from base64 encoded seed in TICKET_SIGNING_KEY environment variable
+func KeyFromEnv() (ed25519.PrivateKey, error) {
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv("TICKET_SIGNING_KEY"))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%w: TICKET_SIGNING_KEY must be base64 of %d bytes", ErrNoKey, ed25519.SeedSize)
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// PublicKey returns base64 encoded public key for scanners
+func PublicKey(key ed25519.PrivateKey) string {
+	return base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+}
+
+// ParsePublicKey decodes public key returned by PublicKey
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrNoKey
+	}
+
+	return ed25519.PublicKey(key), nil
+}
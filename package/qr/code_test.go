@@ -0,0 +1,81 @@
+package qr
+
+import (
+	"crypto/ed25519"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	key := ed25519.NewKeyFromSeed([]byte(strings.Repeat("k", ed25519.SeedSize)))
+	other := ed25519.NewKeyFromSeed([]byte(strings.Repeat("o", ed25519.SeedSize)))
+
+	now := time.Date(2022, time.April, 1, 18, 0, 0, 0, time.UTC)
+	payload := &Payload{Ticket_ID: 1234, Session_ID: 56, Seat: 7, Expires_at: now.Add(time.Hour)}
+
+	code := Sign(payload, key)
+	forged := []byte(code)
+	forged[3] ^= 1
+
+	testVerifyCases := []struct {
+		name           string
+		code           string
+		key            ed25519.PublicKey
+		now            time.Time
+		expectedResult *Payload
+		expectedError  error
+	}{
+		{
+			name:           "success",
+			code:           code,
+			key:            key.Public().(ed25519.PublicKey),
+			now:            now,
+			expectedResult: payload,
+		},
+		{
+			name:           "failure: expired",
+			code:           code,
+			key:            key.Public().(ed25519.PublicKey),
+			now:            now.Add(time.Hour),
+			expectedResult: payload,
+			expectedError:  ErrExpired,
+		},
+		{
+			name:          "failure: signed by another key",
+			code:          code,
+			key:           other.Public().(ed25519.PublicKey),
+			now:           now,
+			expectedError: ErrSignature,
+		},
+		{
+			name:          "failure: tampered",
+			code:          string(forged),
+			key:           key.Public().(ed25519.PublicKey),
+			now:           now,
+			expectedError: ErrSignature,
+		},
+		{
+			name:          "failure: malformed",
+			code:          "not a code",
+			key:           key.Public().(ed25519.PublicKey),
+			now:           now,
+			expectedError: ErrMalformed,
+		},
+	}
+	for _, tc := range testVerifyCases {
+
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := Verify(tc.code, tc.key, tc.now)
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedResult, res)
+		})
+	}
+
+	parsed, err := ParsePublicKey(PublicKey(key))
+	assert.NoError(t, err)
+	assert.Equal(t, key.Public(), parsed)
+}
@@ -20,11 +20,102 @@ func (s *MockService) Retrieve(_ int64, _ context.Context) (internal.Identifiabl
 	return s.ExpectedResult, s.ExpectedError
 }
 
-func (s *MockService) RetrieveAll(_ context.Context) ([]internal.Identifiable, error) {
+func (s *MockService) RetrieveAll(q *internal.Query, _ context.Context) (*internal.Page, error) {
+	if s.ExpectedError != nil {
+		return nil, s.ExpectedError
+	}
 
-	return s.ExpectedArray, s.ExpectedError
+	return &internal.Page{Data: s.ExpectedArray, Meta: internal.Meta{Limit: q.Limit, Total: int64(len(s.ExpectedArray))}}, nil
+}
+
+func (s *MockService) Update(_ int64, _ internal.Identifiable, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
 }
 
 func (s *MockService) Delete(_ int64, _ context.Context) error {
 	return s.ExpectedError
 }
+
+func (s *MockService) DeleteVersion(_ int64, _ int64, _ context.Context) error {
+	return s.ExpectedError
+}
+
+func (s *MockService) RetrieveSeats(_ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) RetrieveHold(_ string, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) DeleteHold(_ string, _ int64, _ context.Context) error {
+	return s.ExpectedError
+}
+
+func (s *MockService) RetrieveByUser(_ int64, q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.RetrieveAll(q, ctx)
+}
+
+func (s *MockService) Cancel(_ int64, _ int64, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Refund(_ int64, _ string, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) CheckIn(_ int64, _ int64, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Issue(_ int64, _ context.Context) error {
+	return s.ExpectedError
+}
+
+func (s *MockService) Code(_ int64, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Verify(_ string, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) VerifyKey() (string, error) {
+	return "key", s.ExpectedError
+}
+
+func (s *MockService) Webhook(_ []byte, _ string, _ context.Context) error {
+	return s.ExpectedError
+}
+
+func (s *MockService) Join(_ int64, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Position(_ int64, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Leave(_ int64, _ int64, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Entries(_ int64, _ context.Context) ([]internal.Identifiable, error) {
+	return s.ExpectedArray, s.ExpectedError
+}
+
+func (s *MockService) Schedule(_ internal.Identifiable, _ context.Context) (internal.Identifiable, error) {
+	return s.ExpectedResult, s.ExpectedError
+}
+
+func (s *MockService) Showtimes(q *internal.Query, ctx context.Context) (*internal.Page, error) {
+	return s.RetrieveAll(q, ctx)
+}
+
+func (s *MockService) UserRoles(_ int64, _ context.Context) ([]internal.Identifiable, error) {
+	return s.ExpectedArray, s.ExpectedError
+}
+
+func (s *MockService) Assign(_ int64, _ []string, _ context.Context) ([]internal.Identifiable, error) {
+	return s.ExpectedArray, s.ExpectedError
+}